@@ -0,0 +1,196 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var log = logf.RuntimeLog.WithName("audit")
+
+// Record is the audit record of a single reconcile.
+type Record struct {
+	// Controller is the name of the controller.
+	Controller string `json:"controller"`
+
+	// Namespace and Name are the key of the reconciled object.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Result is the result of the reconcile, like in the controller_runtime_reconcile_total metric:
+	// success, error, requeue, requeue_after, cancelled or invalid_key.
+	Result string `json:"result"`
+
+	// Error is the error returned by the reconcile, if any.
+	Error string `json:"error,omitempty"`
+
+	// Duration is how long the reconcile took.
+	Duration time.Duration `json:"duration"`
+
+	// CorrelationID identifies the reconcile.  Reconcilers get it with reconcile.CorrelationIDFromContext,
+	// e.g. to add it to their logs and to the requests they make to other systems.
+	CorrelationID string `json:"correlationID"`
+
+	// Time is when the reconcile finished.
+	Time time.Time `json:"time"`
+}
+
+// Sink writes audit records to an external audit system.
+type Sink interface {
+	// Write writes a batch of records.  It is called by a single goroutine at a time, with a context which
+	// is done once the write times out.  Records of batches which failed to be written are dropped.  Each
+	// batch is a new slice, which the Sink may keep, e.g. to write it asynchronously.
+	Write(ctx context.Context, records []Record) error
+}
+
+// SinkFunc implements Sink with a function.
+type SinkFunc func(context.Context, []Record) error
+
+// Write implements Sink
+func (f SinkFunc) Write(ctx context.Context, records []Record) error {
+	return f(ctx, records)
+}
+
+// Options configure the batching of a Batcher.
+type Options struct {
+	// BufferSize is how many records are buffered for the Sink.  Records are dropped while the buffer is
+	// full, i.e. while the Sink is too slow, instead of blocking reconciles.  Defaults to 1000.
+	BufferSize int
+
+	// MaxBatchSize is how many records are written to the Sink at once at most.  Defaults to 100.
+	MaxBatchSize int
+
+	// FlushInterval is how long records are buffered at most before a smaller batch is written.  Defaults
+	// to 1 second.
+	FlushInterval time.Duration
+
+	// WriteTimeout is how long each write to the Sink may take.  Defaults to 10 seconds.
+	WriteTimeout time.Duration
+}
+
+// Batcher buffers records and writes them to a Sink in batches, from a single goroutine running while it is
+// started.  Failures of the Sink are logged and counted by the controller_runtime_audit_records_total metric,
+// but don't affect reconciles.
+type Batcher struct {
+	sink    Sink
+	opts    Options
+	records chan Record
+}
+
+// NewBatcher returns a Batcher writing to sink.  It must be started to write records, e.g. by adding it to
+// a manager.
+func NewBatcher(sink Sink, opts Options) *Batcher {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	if opts.MaxBatchSize <= 0 {
+		opts.MaxBatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 10 * time.Second
+	}
+	return &Batcher{sink: sink, opts: opts, records: make(chan Record, opts.BufferSize)}
+}
+
+// Record buffers r for the Sink without blocking.  It drops r if the buffer is full.
+func (b *Batcher) Record(r Record) {
+	select {
+	case b.records <- r:
+	default:
+		RecordsTotal.WithLabelValues(r.Controller, "dropped").Inc()
+	}
+}
+
+// Start writes the buffered records to the Sink until stop is closed, and then writes the records still
+// buffered.  It implements manager.Runnable.
+func (b *Batcher) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, b.opts.MaxBatchSize)
+	for {
+		select {
+		case r := <-b.records:
+			batch = append(batch, r)
+			if len(batch) < b.opts.MaxBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-stop:
+			b.drain(batch)
+			return nil
+		}
+		if len(batch) > 0 {
+			b.write(batch)
+			// The Sink may keep the batch, so never reuse it.
+			batch = make([]Record, 0, b.opts.MaxBatchSize)
+		}
+	}
+}
+
+// drain writes batch and the records still buffered.
+func (b *Batcher) drain(batch []Record) {
+	for {
+		select {
+		case r := <-b.records:
+			batch = append(batch, r)
+			if len(batch) < b.opts.MaxBatchSize {
+				continue
+			}
+			b.write(batch)
+			batch = make([]Record, 0, b.opts.MaxBatchSize)
+		default:
+			if len(batch) > 0 {
+				b.write(batch)
+			}
+			return
+		}
+	}
+}
+
+// write writes batch to the Sink, counting the records by controller.
+func (b *Batcher) write(batch []Record) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.opts.WriteTimeout)
+	defer cancel()
+	outcome := "written"
+	if err := b.sink.Write(ctx, batch); err != nil {
+		log.Error(err, "Failed to write audit records", "records", len(batch))
+		outcome = "failed"
+	}
+	for _, r := range batch {
+		RecordsTotal.WithLabelValues(r.Controller, outcome).Inc()
+	}
+}
+
+// RecordsTotal is a prometheus counter metric which holds the total number of audit records per controller
+// and outcome: written to the Sink, dropped because the buffer was full, or failed to be written.
+var RecordsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "controller_runtime_audit_records_total",
+	Help: "Total number of audit records of reconciles per controller and outcome",
+}, []string{"controller", "outcome"})
+
+func init() {
+	metrics.Registry.MustRegister(RecordsTotal)
+}
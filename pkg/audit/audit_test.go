@@ -0,0 +1,123 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"sigs.k8s.io/controller-runtime/pkg/audit"
+)
+
+var _ = Describe("Batcher", func() {
+	var mu sync.Mutex
+	var batches [][]audit.Record
+	var stop chan struct{}
+	recording := audit.SinkFunc(func(_ context.Context, records []audit.Record) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, append([]audit.Record(nil), records...))
+		return nil
+	})
+	written := func() [][]audit.Record {
+		mu.Lock()
+		defer mu.Unlock()
+		return batches
+	}
+	count := func(controller, outcome string) float64 {
+		var m dto.Metric
+		Expect(audit.RecordsTotal.WithLabelValues(controller, outcome).Write(&m)).To(Succeed())
+		return m.GetCounter().GetValue()
+	}
+	start := func(b *audit.Batcher) {
+		go func() {
+			defer GinkgoRecover()
+			Expect(b.Start(stop)).To(Succeed())
+		}()
+	}
+
+	BeforeEach(func() {
+		batches = nil
+		stop = make(chan struct{})
+	})
+
+	It("should write full batches, and smaller ones after FlushInterval", func() {
+		b := audit.NewBatcher(recording, audit.Options{MaxBatchSize: 2, FlushInterval: 100 * time.Millisecond})
+		for i := 0; i < 3; i++ {
+			b.Record(audit.Record{Controller: "batching", Name: fmt.Sprintf("obj-%d", i)})
+		}
+		start(b)
+		defer close(stop)
+
+		Eventually(written).Should(HaveLen(2))
+		Expect(written()[0]).To(HaveLen(2))
+		Expect(written()[1]).To(HaveLen(1))
+		Expect(written()[1][0].Name).To(Equal("obj-2"))
+		Expect(count("batching", "written")).To(Equal(3.0))
+	})
+
+	It("should never reuse the batches passed to the sink", func() {
+		keeping := audit.SinkFunc(func(_ context.Context, records []audit.Record) error {
+			mu.Lock()
+			defer mu.Unlock()
+			batches = append(batches, records)
+			return nil
+		})
+		b := audit.NewBatcher(keeping, audit.Options{MaxBatchSize: 1})
+		for i := 0; i < 3; i++ {
+			b.Record(audit.Record{Controller: "keeping", Name: fmt.Sprintf("obj-%d", i)})
+		}
+		start(b)
+		close(stop)
+
+		Eventually(written).Should(HaveLen(3))
+		for i, batch := range written() {
+			Expect(batch).To(ConsistOf(audit.Record{Controller: "keeping", Name: fmt.Sprintf("obj-%d", i)}))
+		}
+	})
+
+	It("should write the buffered records when stopped", func() {
+		b := audit.NewBatcher(recording, audit.Options{FlushInterval: time.Hour})
+		start(b)
+		b.Record(audit.Record{Controller: "draining", Name: "obj"})
+		close(stop)
+		Eventually(written).Should(HaveLen(1))
+	})
+
+	It("should drop records instead of blocking while the buffer is full", func() {
+		b := audit.NewBatcher(recording, audit.Options{BufferSize: 1})
+		b.Record(audit.Record{Controller: "dropping", Name: "kept"})
+		b.Record(audit.Record{Controller: "dropping", Name: "dropped"})
+		Expect(count("dropping", "dropped")).To(Equal(1.0))
+	})
+
+	It("should count the records the sink failed to write", func() {
+		failing := audit.SinkFunc(func(context.Context, []audit.Record) error {
+			return fmt.Errorf("sink unavailable")
+		})
+		b := audit.NewBatcher(failing, audit.Options{FlushInterval: 10 * time.Millisecond})
+		start(b)
+		defer close(stop)
+		b.Record(audit.Record{Controller: "failing", Name: "obj"})
+		Eventually(func() float64 { return count("failing", "failed") }).Should(Equal(1.0))
+	})
+})
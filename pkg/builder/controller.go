@@ -19,8 +19,10 @@ package builder
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -48,6 +50,8 @@ type Builder struct {
 	config         *rest.Config
 	ctrl           controller.Controller
 	name           string
+
+	defaultRequeueAfterByType map[schema.GroupVersionKind]time.Duration
 }
 
 // SimpleController returns a new Builder.
@@ -135,6 +139,15 @@ func (blder *Builder) Named(name string) *Builder {
 	return blder
 }
 
+// WithDefaultRequeueAfterByType sets how long after a successful reconcile the objects of each type are
+// reconciled again by default.  The entry for the type passed to For applies.  Reconciles returning a
+// Result with Requeue or RequeueAfter set, or an error, aren't affected.  See
+// controller.Options.DefaultRequeueAfterByType.
+func (blder *Builder) WithDefaultRequeueAfterByType(requeueAfter map[schema.GroupVersionKind]time.Duration) *Builder {
+	blder.defaultRequeueAfterByType = requeueAfter
+	return blder
+}
+
 // Complete builds the Application ControllerManagedBy.
 func (blder *Builder) Complete(r reconcile.Reconciler) error {
 	_, err := blder.Build(r)
@@ -241,6 +254,10 @@ func (blder *Builder) doController(r reconcile.Reconciler) error {
 	if err != nil {
 		return err
 	}
-	blder.ctrl, err = newController(name, blder.mgr, controller.Options{Reconciler: r})
+	blder.ctrl, err = newController(name, blder.mgr, controller.Options{
+		Reconciler:                r,
+		For:                       blder.apiType,
+		DefaultRequeueAfterByType: blder.defaultRequeueAfterByType,
+	})
 	return err
 }
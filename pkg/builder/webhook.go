@@ -17,12 +17,19 @@ limitations under the License.
 package builder
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -36,6 +43,19 @@ type WebhookBuilder struct {
 	gvk     schema.GroupVersionKind
 	mgr     manager.Manager
 	config  *rest.Config
+
+	validationCache   *admission.ResponseCacheOptions
+	objectSelector    *metav1.LabelSelector
+	namespaceSelector *metav1.LabelSelector
+	crdTimeout        time.Duration
+	handlers          []typedHandlerAt
+	conversionSamples []runtime.Object
+}
+
+// typedHandlerAt is a TypedHandlerFunc registered at a path.
+type typedHandlerAt struct {
+	path string
+	fn   TypedHandlerFunc
 }
 
 func WebhookManagedBy(m manager.Manager) *WebhookBuilder {
@@ -47,11 +67,71 @@ func WebhookManagedBy(m manager.Manager) *WebhookBuilder {
 // For takes a runtime.Object which should be a CR.
 // If the given object implements the admission.Defaulter interface, a MutatingWebhook will be wired for this type.
 // If the given object implements the admission.Validator interface, a ValidatingWebhook will be wired for this type.
+// If it also implements admission.ContextValidator, its validation can read other objects from the manager's cache
+// through admission.ReaderFromContext.
 func (blder *WebhookBuilder) For(apiType runtime.Object) *WebhookBuilder {
 	blder.apiType = apiType
 	return blder
 }
 
+// CacheValidationResponses enables caching the responses of the ValidatingWebhook wired for the type, so
+// that repeated requests for the same change of an object are not validated again.  Only use this if the
+// type's validation is idempotent and depends on nothing but the objects being validated.
+// See admission.ResponseCacheOptions for details.
+func (blder *WebhookBuilder) CacheValidationResponses(opts admission.ResponseCacheOptions) *WebhookBuilder {
+	blder.validationCache = &opts
+	return blder
+}
+
+// WithObjectSelector makes the webhooks wired for the type allow the requests for objects whose labels
+// don't match selector without defaulting or validating them, like the objectSelector of the webhook
+// configuration.  Set it to the same selector as the configuration, to honor it even if it's misapplied.
+// See admission.Webhook.ObjectSelector.
+func (blder *WebhookBuilder) WithObjectSelector(selector *metav1.LabelSelector) *WebhookBuilder {
+	blder.objectSelector = selector
+	return blder
+}
+
+// WithNamespaceSelector makes the webhooks wired for the type allow the requests for objects in namespaces
+// whose labels don't match selector without defaulting or validating them, like the namespaceSelector of the
+// webhook configuration.  The namespaces are read from the manager's cache.
+// See admission.Webhook.NamespaceSelector.
+func (blder *WebhookBuilder) WithNamespaceSelector(selector *metav1.LabelSelector) *WebhookBuilder {
+	blder.namespaceSelector = selector
+	return blder
+}
+
+// WaitForCRD makes Complete wait up to timeout for the CustomResourceDefinition of the type to be
+// established and to serve its version before registering the webhooks, e.g. when the CRD is installed
+// concurrently with the start of the manager.  Complete fails if the CRD isn't established by then.  The
+// CRD is looked up by its name, "<plural>.<group>": if the RESTMapper of the manager doesn't know the type
+// yet, the plural is guessed from the kind, e.g. "widgets" for Widget, so a CRD with an irregular plural
+// must be installed before the manager is created.
+func (blder *WebhookBuilder) WaitForCRD(timeout time.Duration) *WebhookBuilder {
+	blder.crdTimeout = timeout
+	return blder
+}
+
+// WithHandler registers a TypedWebhook for the type at path, calling fn with the objects of the requests
+// decoded into the type, e.g. to handle a type which is neither an admission.Defaulter nor an
+// admission.Validator.  The selectors of the builder apply to it.  It may be called repeatedly to register
+// several handlers.
+func (blder *WebhookBuilder) WithHandler(path string, fn TypedHandlerFunc) *WebhookBuilder {
+	blder.handlers = append(blder.handlers, typedHandlerAt{path: path, fn: fn})
+	return blder
+}
+
+// WithConversionReadinessCheck makes the webhook server of the manager check that sample round-trips
+// through every version of the type with conversion.CheckRoundTrip, and serve the result as a readiness
+// check at conversion.ReadinessCheckPath, shared by the types of all the builders of the manager.  A nil
+// sample uses the object given to For, usually a zero object, which only catches conversions failing
+// outright: set every field of the sample to also catch the fields lost by a conversion.  It may be called
+// again for more samples.
+func (blder *WebhookBuilder) WithConversionReadinessCheck(sample runtime.Object) *WebhookBuilder {
+	blder.conversionSamples = append(blder.conversionSamples, sample)
+	return blder
+}
+
 // Complete builds the webhook.
 func (blder *WebhookBuilder) Complete() error {
 	// Set the Config
@@ -63,6 +143,66 @@ func (blder *WebhookBuilder) Complete() error {
 	return blder.registerWebhooks()
 }
 
+// crdPollInterval is how often WaitForCRD checks whether the CRD is established.
+var crdPollInterval = time.Second
+
+// waitForCRD waits for the CustomResourceDefinition of the type to be established.
+func (blder *WebhookBuilder) waitForCRD() error {
+	cs, err := apiextensionsclientset.NewForConfig(blder.config)
+	if err != nil {
+		return err
+	}
+	name := blder.crdName()
+	err = wait.PollImmediate(crdPollInterval, blder.crdTimeout, func() (bool, error) {
+		crd, err := cs.ApiextensionsV1beta1().CustomResourceDefinitions().Get(name, metav1.GetOptions{})
+		if err != nil {
+			// The CRD may not be installed yet, nor the API server be ready, so keep trying until the timeout
+			log.V(1).Info("Unable to get CRD", "name", name, "error", err.Error())
+			return false, nil
+		}
+		return crdServes(crd, blder.gvk), nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out after %v waiting for the CRD of %v to be established", blder.crdTimeout, blder.gvk)
+	}
+	return err
+}
+
+// crdName returns the name of the CustomResourceDefinition of the type, <plural>.<group>.  The plural is
+// the resource of the type in the RESTMapper of the manager, or, if it doesn't know the type yet, guessed
+// from the kind.
+func (blder *WebhookBuilder) crdName() string {
+	gvr, _ := meta.UnsafeGuessKindToResource(blder.gvk)
+	if blder.mgr != nil {
+		if mapping, err := blder.mgr.GetRESTMapper().RESTMapping(blder.gvk.GroupKind(), blder.gvk.Version); err == nil {
+			gvr = mapping.Resource
+		}
+	}
+	return gvr.Resource + "." + gvr.Group
+}
+
+// crdServes reports whether crd is established and serves gvk.
+func crdServes(crd *apiextensionsv1beta1.CustomResourceDefinition, gvk schema.GroupVersionKind) bool {
+	if crd.Spec.Group != gvk.Group || crd.Spec.Names.Kind != gvk.Kind {
+		return false
+	}
+	served := len(crd.Spec.Versions) == 0 && crd.Spec.Version == gvk.Version
+	for _, version := range crd.Spec.Versions {
+		if version.Name == gvk.Version && version.Served {
+			served = true
+		}
+	}
+	if !served {
+		return false
+	}
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == apiextensionsv1beta1.Established && cond.Status == apiextensionsv1beta1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 func (blder *WebhookBuilder) loadRestConfig() error {
 	if blder.config != nil {
 		return nil
@@ -83,9 +223,24 @@ func (blder *WebhookBuilder) registerWebhooks() error {
 	if err != nil {
 		return err
 	}
+	if blder.crdTimeout > 0 {
+		if err := blder.waitForCRD(); err != nil {
+			return err
+		}
+	}
 
-	blder.registerDefaultingWebhook()
-	blder.registerValidatingWebhook()
+	if err := blder.registerDefaultingWebhook(); err != nil {
+		return err
+	}
+	if err := blder.registerValidatingWebhook(); err != nil {
+		return err
+	}
+	if err := blder.registerTypedWebhooks(); err != nil {
+		return err
+	}
+	if err := blder.registerConversionReadinessCheck(); err != nil {
+		return err
+	}
 
 	err = conversion.CheckConvertibility(blder.mgr.GetScheme(), blder.apiType)
 	if err != nil {
@@ -95,10 +250,13 @@ func (blder *WebhookBuilder) registerWebhooks() error {
 }
 
 // registerDefaultingWebhook registers a defaulting webhook if th
-func (blder *WebhookBuilder) registerDefaultingWebhook() {
+func (blder *WebhookBuilder) registerDefaultingWebhook() error {
 	if defaulter, isDefaulter := blder.apiType.(admission.Defaulter); isDefaulter {
 		mwh := admission.DefaultingWebhookFor(defaulter)
 		if mwh != nil {
+			if err := blder.setSelectors(mwh); err != nil {
+				return err
+			}
 			path := generateMutatePath(blder.gvk)
 
 			// Checking if the path is already registered.
@@ -111,12 +269,17 @@ func (blder *WebhookBuilder) registerDefaultingWebhook() {
 			}
 		}
 	}
+	return nil
 }
 
-func (blder *WebhookBuilder) registerValidatingWebhook() {
+func (blder *WebhookBuilder) registerValidatingWebhook() error {
 	if validator, isValidator := blder.apiType.(admission.Validator); isValidator {
 		vwh := admission.ValidatingWebhookFor(validator)
 		if vwh != nil {
+			vwh.ResponseCache = blder.validationCache
+			if err := blder.setSelectors(vwh); err != nil {
+				return err
+			}
 			path := generateValidatePath(blder.gvk)
 
 			// Checking if the path is already registered.
@@ -129,6 +292,67 @@ func (blder *WebhookBuilder) registerValidatingWebhook() {
 			}
 		}
 	}
+	return nil
+}
+
+// registerTypedWebhooks registers the webhooks of the handlers added with WithHandler.
+func (blder *WebhookBuilder) registerTypedWebhooks() error {
+	for _, h := range blder.handlers {
+		wh := TypedWebhook(blder.apiType, h.fn)
+		if err := blder.setSelectors(wh); err != nil {
+			return err
+		}
+		if blder.isAlreadyHandled(h.path) {
+			return fmt.Errorf("unable to register a webhook for %v at %q: the path is already handled", blder.gvk, h.path)
+		}
+		log.Info("Registering a webhook",
+			"GVK", blder.gvk,
+			"path", h.path)
+		blder.mgr.GetWebhookServer().Register(h.path, wh)
+	}
+	return nil
+}
+
+// registerConversionReadinessCheck adds the conversion samples of the builder to the conversion readiness
+// check of the webhook server, registering it first if needed.
+func (blder *WebhookBuilder) registerConversionReadinessCheck() error {
+	if len(blder.conversionSamples) == 0 {
+		return nil
+	}
+	srv := blder.mgr.GetWebhookServer()
+	check, isCheck := srv.Webhook(conversion.ReadinessCheckPath).(*conversion.ReadinessCheck)
+	if !isCheck {
+		if blder.isAlreadyHandled(conversion.ReadinessCheckPath) {
+			return fmt.Errorf("unable to register the conversion readiness check at %q: the path is already handled",
+				conversion.ReadinessCheckPath)
+		}
+		check = &conversion.ReadinessCheck{}
+		log.Info("Registering the conversion readiness check", "path", conversion.ReadinessCheckPath)
+		srv.Register(conversion.ReadinessCheckPath, check)
+	}
+	for _, sample := range blder.conversionSamples {
+		if sample == nil {
+			sample = blder.apiType.DeepCopyObject()
+		}
+		check.Add(sample)
+	}
+	return nil
+}
+
+// setSelectors sets the selectors of the builder on wh.
+func (blder *WebhookBuilder) setSelectors(wh *admission.Webhook) error {
+	var err error
+	if blder.objectSelector != nil {
+		if wh.ObjectSelector, err = metav1.LabelSelectorAsSelector(blder.objectSelector); err != nil {
+			return fmt.Errorf("invalid object selector: %v", err)
+		}
+	}
+	if blder.namespaceSelector != nil {
+		if wh.NamespaceSelector, err = metav1.LabelSelectorAsSelector(blder.namespaceSelector); err != nil {
+			return fmt.Errorf("invalid namespace selector: %v", err)
+		}
+	}
+	return nil
 }
 
 func (blder *WebhookBuilder) isAlreadyHandled(path string) bool {
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// TypedHandlerFunc handles an admission request whose objects have been decoded into a Go type.  obj is the
+// object of the request, and oldObj its old object, which the API server only sends for updates.  Either is
+// nil if the request doesn't carry it, e.g. obj for deletes.
+type TypedHandlerFunc func(ctx context.Context, obj, oldObj runtime.Object, req *admission.Request) admission.Response
+
+// TypedWebhook returns a Webhook which decodes the objects of every request into new instances of apiType
+// with the scheme of the manager it is registered with, and passes them to fn.  Requests whose objects fail
+// to decode are answered with a 400 error without calling fn.  This saves raw handlers of types which aren't
+// admission.Defaulters or admission.Validators from decoding the objects themselves.
+func TypedWebhook(apiType runtime.Object, fn TypedHandlerFunc) *admission.Webhook {
+	return &admission.Webhook{
+		Handler: &typedHandler{apiType: apiType, fn: fn},
+	}
+}
+
+// typedHandler implements the Handler of a TypedWebhook.
+type typedHandler struct {
+	apiType runtime.Object
+	fn      TypedHandlerFunc
+	decoder *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &typedHandler{}
+
+// InjectDecoder injects the decoder into a typedHandler.
+func (h *typedHandler) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// Handle handles admission requests.
+func (h *typedHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if h.decoder == nil {
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("no decoder has been injected"))
+	}
+
+	var obj, oldObj runtime.Object
+	if len(req.Object.Raw) > 0 {
+		obj = h.newObject()
+		if err := h.decoder.DecodeRaw(req.Object, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, fmt.Errorf("unable to decode the object: %v", err))
+		}
+	}
+	if len(req.OldObject.Raw) > 0 {
+		oldObj = h.newObject()
+		if err := h.decoder.DecodeRaw(req.OldObject, oldObj); err != nil {
+			return admission.Errored(http.StatusBadRequest, fmt.Errorf("unable to decode the old object: %v", err))
+		}
+	}
+	return h.fn(ctx, obj, oldObj, &req)
+}
+
+// newObject returns a zero instance of apiType to decode into, so that no field set on apiType leaks into the
+// fields the payload omits.
+func (h *typedHandler) newObject() runtime.Object {
+	return reflect.New(reflect.TypeOf(h.apiType).Elem()).Interface().(runtime.Object)
+}
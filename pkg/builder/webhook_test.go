@@ -17,15 +17,22 @@ limitations under the License.
 package builder
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
@@ -33,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 )
 
 var _ = Describe("application", func() {
@@ -270,6 +278,216 @@ var _ = Describe("application", func() {
 			Expect(w.Body).To(ContainSubstring(`"code":200`))
 		})
 	})
+
+	Describe("TypedWebhook", func() {
+		var wh *admission.Webhook
+		var obj, oldObj runtime.Object
+		var called bool
+
+		BeforeEach(func() {
+			obj, oldObj, called = nil, nil, false
+			wh = TypedWebhook(&TestValidator{}, func(_ context.Context, o, old runtime.Object, req *admission.Request) admission.Response {
+				obj, oldObj, called = o, old, true
+				return admission.Allowed("")
+			})
+			s := runtime.NewScheme()
+			builder := scheme.Builder{GroupVersion: testValidatorGVK.GroupVersion()}
+			builder.Register(&TestValidator{}, &TestValidatorList{})
+			Expect(builder.AddToScheme(s)).To(Succeed())
+			Expect(wh.InjectScheme(s)).To(Succeed())
+		})
+
+		It("should pass the object and the old object of updates decoded into the type", func() {
+			resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    runtime.RawExtension{Raw: []byte(`{"replica":2}`)},
+				OldObject: runtime.RawExtension{Raw: []byte(`{"replica":1}`)},
+			}})
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(obj).To(Equal(&TestValidator{Replica: 2}))
+			Expect(oldObj).To(Equal(&TestValidator{Replica: 1}))
+
+			By("passing no old object for creates")
+			resp = wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(`{"replica":3}`)},
+			}})
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(obj).To(Equal(&TestValidator{Replica: 3}))
+			Expect(oldObj).To(BeNil())
+		})
+
+		It("should decode the objects into zero instances of the type", func() {
+			wh = TypedWebhook(&TestValidator{Replica: 5}, func(_ context.Context, o, old runtime.Object, req *admission.Request) admission.Response {
+				obj, oldObj, called = o, old, true
+				return admission.Allowed("")
+			})
+			s := runtime.NewScheme()
+			builder := scheme.Builder{GroupVersion: testValidatorGVK.GroupVersion()}
+			builder.Register(&TestValidator{}, &TestValidatorList{})
+			Expect(builder.AddToScheme(s)).To(Succeed())
+			Expect(wh.InjectScheme(s)).To(Succeed())
+
+			resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    runtime.RawExtension{Raw: []byte(`{}`)},
+				OldObject: runtime.RawExtension{Raw: []byte(`{}`)},
+			}})
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(obj).To(Equal(&TestValidator{}))
+			Expect(oldObj).To(Equal(&TestValidator{}))
+		})
+
+		It("should answer requests whose objects fail to decode with a bad request", func() {
+			resp := wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    runtime.RawExtension{Raw: []byte(`{"replica":2}`)},
+				OldObject: runtime.RawExtension{Raw: []byte(`{"replica":"one"}`)},
+			}})
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Code).To(Equal(int32(http.StatusBadRequest)))
+			Expect(resp.Result.Message).To(ContainSubstring("unable to decode the old object"))
+			Expect(called).To(BeFalse())
+		})
+
+		It("should be registered by the builder at the path of WithHandler", func() {
+			m, err := manager.New(cfg, manager.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			builder := scheme.Builder{GroupVersion: testValidatorGVK.GroupVersion()}
+			builder.Register(&TestValidator{}, &TestValidatorList{})
+			Expect(builder.AddToScheme(m.GetScheme())).To(Succeed())
+
+			Expect(WebhookManagedBy(m).
+				For(&TestValidator{}).
+				WithHandler("/handle-testvalidator", func(_ context.Context, o, old runtime.Object, req *admission.Request) admission.Response {
+					return admission.Denied(fmt.Sprintf("replica %d", o.(*TestValidator).Replica))
+				}).
+				Complete()).To(Succeed())
+			Expect(m.GetWebhookServer().Paths()).To(ContainElement("/handle-testvalidator"))
+
+			By("refusing to register a handler at a path which is already handled")
+			Expect(WebhookManagedBy(m).
+				For(&TestValidator{}).
+				WithHandler("/handle-testvalidator", func(context.Context, runtime.Object, runtime.Object, *admission.Request) admission.Response {
+					return admission.Allowed("")
+				}).
+				Complete()).To(MatchError(ContainSubstring("the path is already handled")))
+		})
+	})
+
+	Describe("WithConversionReadinessCheck", func() {
+		It("should serve a conversion readiness check shared by the builders of the manager", func() {
+			m, err := manager.New(cfg, manager.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			builder := scheme.Builder{GroupVersion: testValidatorGVK.GroupVersion()}
+			builder.Register(&TestValidator{}, &TestValidatorList{})
+			Expect(builder.AddToScheme(m.GetScheme())).To(Succeed())
+
+			for i := 0; i < 2; i++ {
+				Expect(WebhookManagedBy(m).
+					For(&TestValidator{}).
+					WithConversionReadinessCheck(nil).
+					Complete()).To(Succeed())
+			}
+			check, isCheck := m.GetWebhookServer().Webhook(conversion.ReadinessCheckPath).(*conversion.ReadinessCheck)
+			Expect(isCheck).To(BeTrue())
+
+			By("reporting ready once the round-trips pass")
+			Expect(check.InjectScheme(m.GetScheme())).To(Succeed())
+			w := httptest.NewRecorder()
+			check.ServeHTTP(w, httptest.NewRequest("GET", conversion.ReadinessCheckPath, nil))
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("WaitForCRD", func() {
+		// establishedAfter is the number of gets after which the CRD is established, if any.
+		var establishedAfter, gets int
+		var apiServer *httptest.Server
+
+		BeforeEach(func() {
+			crdPollInterval = 10 * time.Millisecond
+			establishedAfter, gets = 0, 0
+			apiServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/apis/apiextensions.k8s.io/v1beta1/customresourcedefinitions/testdefaulters.foo.test.org" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				gets++
+				crd := apiextensionsv1beta1.CustomResourceDefinition{
+					TypeMeta:   metav1.TypeMeta{Kind: "CustomResourceDefinition", APIVersion: "apiextensions.k8s.io/v1beta1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "testdefaulters.foo.test.org"},
+					Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+						Group:    testDefaulterGVK.Group,
+						Version:  testDefaulterGVK.Version,
+						Names:    apiextensionsv1beta1.CustomResourceDefinitionNames{Kind: testDefaulterGVK.Kind},
+						Versions: []apiextensionsv1beta1.CustomResourceDefinitionVersion{{Name: "v1", Served: true}},
+					},
+				}
+				if establishedAfter > 0 && gets >= establishedAfter {
+					crd.Status.Conditions = []apiextensionsv1beta1.CustomResourceDefinitionCondition{
+						{Type: apiextensionsv1beta1.Established, Status: apiextensionsv1beta1.ConditionTrue},
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(&crd)).To(Succeed())
+			}))
+		})
+
+		AfterEach(func() {
+			apiServer.Close()
+			crdPollInterval = time.Second
+		})
+
+		It("should wait until the CRD of the type is established", func() {
+			establishedAfter = 3
+			blder := WebhookManagedBy(nil).WaitForCRD(5 * time.Second)
+			blder.config = &rest.Config{Host: apiServer.URL}
+			blder.gvk = testDefaulterGVK
+			Expect(blder.waitForCRD()).To(Succeed())
+			Expect(gets).To(Equal(3))
+		})
+
+		It("should look the CRD up by the plural of the type in the RESTMapper of the manager", func() {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.AddSpecific(testDefaulterGVK,
+				testDefaulterGVK.GroupVersion().WithResource("testdefaulteres"),
+				testDefaulterGVK.GroupVersion().WithResource("testdefaulter"), meta.RESTScopeNamespace)
+			blder := WebhookManagedBy(&restMapperManager{mapper: mapper})
+			blder.gvk = testDefaulterGVK
+			Expect(blder.crdName()).To(Equal("testdefaulteres.foo.test.org"))
+
+			By("guessing the plural if the RESTMapper doesn't know the type")
+			blder = WebhookManagedBy(&restMapperManager{mapper: meta.NewDefaultRESTMapper(nil)})
+			blder.gvk = testDefaulterGVK
+			Expect(blder.crdName()).To(Equal("testdefaulters.foo.test.org"))
+		})
+
+		It("should fail once the timeout expires if the CRD of the type isn't established", func() {
+			blder := WebhookManagedBy(nil).WaitForCRD(100 * time.Millisecond)
+			blder.config = &rest.Config{Host: apiServer.URL}
+			blder.gvk = testDefaulterGVK
+			Expect(blder.waitForCRD()).To(MatchError(ContainSubstring("waiting for the CRD of")))
+		})
+
+		It("should not treat CRDs which don't serve the version of the type as ready", func() {
+			crd := &apiextensionsv1beta1.CustomResourceDefinition{
+				Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+					Group:    testDefaulterGVK.Group,
+					Names:    apiextensionsv1beta1.CustomResourceDefinitionNames{Kind: testDefaulterGVK.Kind},
+					Versions: []apiextensionsv1beta1.CustomResourceDefinitionVersion{{Name: "v1", Served: false}},
+				},
+				Status: apiextensionsv1beta1.CustomResourceDefinitionStatus{
+					Conditions: []apiextensionsv1beta1.CustomResourceDefinitionCondition{
+						{Type: apiextensionsv1beta1.Established, Status: apiextensionsv1beta1.ConditionTrue},
+					},
+				},
+			}
+			Expect(crdServes(crd, testDefaulterGVK)).To(BeFalse())
+			crd.Spec.Versions[0].Served = true
+			Expect(crdServes(crd, testDefaulterGVK)).To(BeTrue())
+		})
+	})
 })
 
 // TestDefaulter
@@ -279,6 +497,14 @@ type TestDefaulter struct {
 	Replica int `json:"replica,omitempty"`
 }
 
+// restMapperManager is a manager.Manager which only provides a RESTMapper.
+type restMapperManager struct {
+	manager.Manager
+	mapper meta.RESTMapper
+}
+
+func (m *restMapperManager) GetRESTMapper() meta.RESTMapper { return m.mapper }
+
 var testDefaulterGVK = schema.GroupVersionKind{Group: "foo.test.org", Version: "v1", Kind: "TestDefaulter"}
 
 func (*TestDefaulter) GetObjectKind() schema.ObjectKind { return nil }
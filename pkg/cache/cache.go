@@ -17,6 +17,8 @@ limitations under the License.
 package cache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -34,6 +36,9 @@ import (
 
 var log = logf.RuntimeLog.WithName("object-cache")
 
+// ErrCacheNotStarted is returned when trying to read from the cache before it has been started.
+var ErrCacheNotStarted = errors.New("the cache is not started, can not read objects")
+
 // Cache knows how to load Kubernetes objects, fetch informers to request
 // to receive events for Kubernetes objects (at a low-level),
 // and add indicies to fields on the objects stored in the cache.
@@ -68,6 +73,29 @@ type Informers interface {
 	client.FieldIndexer
 }
 
+// InformerRestarter knows how to restart the informer of a single group-version-kind.  The Caches returned
+// by New and MultiNamespacedCacheBuilder implement it.
+type InformerRestarter interface {
+	// RestartInformer replaces the informer for gvk by a new one which lists all objects of the kind
+	// again, e.g. after a CRD is updated to serve a new version, without disturbing the informers of other
+	// kinds.  Reads keep being served by the old informer until the new one has synced, so reconciles in
+	// flight aren't affected.  The event handlers of the informer, like those of the controllers watching
+	// the kind, see an add event for every object, and a delete event for every object which is gone.
+	// It blocks until the new informer has synced, and leaves the old one running if ctx is done before.
+	// It does nothing if the cache has no informer for gvk or isn't started yet.
+	RestartInformer(ctx context.Context, gvk schema.GroupVersionKind) error
+}
+
+// InformerStopper knows how to stop the informer of a single group-version-kind.  The Caches returned by
+// New and MultiNamespacedCacheBuilder implement it.
+type InformerStopper interface {
+	// StopInformer stops the informer for gvk and removes it from the cache, e.g. once the kind is no longer
+	// served, so that it doesn't keep failing to list and watch it.  The event handlers of the informer see
+	// no further events.  Reading the kind or getting its informer again starts a new informer.  It does
+	// nothing if the cache has no informer for gvk.
+	StopInformer(gvk schema.GroupVersionKind) error
+}
+
 // Informer - informer allows you interact with the underlying informer
 type Informer interface {
 	// AddEventHandler adds an event handler to the shared informer using the shared informer's resync
@@ -99,6 +127,16 @@ type Options struct {
 	// Namespace restricts the cache's ListWatch to the desired namespace
 	// Default watches all namespaces
 	Namespace string
+
+	// ListChunkSize is the number of objects per request the informers page in their initial list, and any
+	// relist, by.  Paging reduces the peak memory usage of the cache and the load on the API server for
+	// large collections, but the pages are read from etcd instead of the watch cache of the API server.
+	// Defaults to 0, which lists everything at once.
+	ListChunkSize int64
+
+	// ListChunkSizes overrides ListChunkSize for specific GroupVersionKinds.  A chunk size of 0 lists
+	// everything at once.
+	ListChunkSizes map[schema.GroupVersionKind]int64
 }
 
 var defaultResyncTime = 10 * time.Hour
@@ -109,7 +147,8 @@ func New(config *rest.Config, opts Options) (Cache, error) {
 	if err != nil {
 		return nil, err
 	}
-	im := internal.NewInformersMap(config, opts.Scheme, opts.Mapper, *opts.Resync, opts.Namespace)
+	im := internal.NewInformersMap(config, opts.Scheme, opts.Mapper, *opts.Resync, opts.Namespace,
+		opts.ListChunkSize, opts.ListChunkSizes)
 	return &informerCache{InformersMap: im}, nil
 }
 
@@ -129,6 +168,15 @@ func defaultOpts(config *rest.Config, opts Options) (Options, error) {
 		}
 	}
 
+	if opts.ListChunkSize < 0 {
+		return opts, fmt.Errorf("invalid ListChunkSize %d, must not be negative", opts.ListChunkSize)
+	}
+	for gvk, chunkSize := range opts.ListChunkSizes {
+		if chunkSize < 0 {
+			return opts, fmt.Errorf("invalid ListChunkSize %d for %v, must not be negative", chunkSize, gvk)
+		}
+	}
+
 	// Default the resync period to 10 hours if unset
 	if opts.Resync == nil {
 		opts.Resync = &defaultResyncTime
@@ -19,9 +19,12 @@ package cache_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sync/atomic"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
 	kcorev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	kmetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,6 +36,7 @@ import (
 	kcache "k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	cachemetrics "sigs.k8s.io/controller-runtime/pkg/cache/internal/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -72,6 +76,13 @@ func deletePod(pod runtime.Object) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
+// roundTripperFunc implements http.RoundTripper with a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
 var _ = Describe("Informer Cache", func() {
 	CacheTest(cache.New)
 })
@@ -260,6 +271,86 @@ func CacheTest(createCacheFunc func(config *rest.Config, opts cache.Options) (ca
 					err := informerCache.Get(context.Background(), svcKey, svc)
 					Expect(err).To(HaveOccurred())
 				})
+
+				It("should return ErrCacheNotStarted if the cache has not been started", func() {
+					By("creating a cache which is not started")
+					unstarted, err := createCacheFunc(cfg, cache.Options{})
+					Expect(err).NotTo(HaveOccurred())
+
+					By("verifying that reading from it fails")
+					pod := &kcorev1.Pod{}
+					podKey := client.ObjectKey{Namespace: testNamespaceOne, Name: "test-pod-1"}
+					Expect(unstarted.Get(context.Background(), podKey, pod)).To(Equal(cache.ErrCacheNotStarted))
+					Expect(unstarted.List(context.Background(), &kcorev1.PodList{})).To(Equal(cache.ErrCacheNotStarted))
+				})
+
+				It("should return an ErrIndexNotFound if listing by a field which is not indexed", func() {
+					By("listing pods by an unindexed field")
+					err := informerCache.List(context.Background(), &kcorev1.PodList{},
+						client.InNamespace(testNamespaceOne), client.MatchingField("spec.nodeName", "node-1"))
+
+					By("verifying that an ErrIndexNotFound is returned")
+					Expect(err).To(Equal(&client.ErrIndexNotFound{Field: "spec.nodeName"}))
+				})
+
+				It("should page in the initial list by ListChunkSize", func() {
+					By("creating a cache which lists pods one at a time")
+					var pagedLists int32
+					pagingCfg := rest.CopyConfig(cfg)
+					pagingCfg.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+						return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+							if req.URL.Query().Get("limit") == "1" {
+								atomic.AddInt32(&pagedLists, 1)
+							}
+							return rt.RoundTrip(req)
+						})
+					}
+					pagingCache, err := createCacheFunc(pagingCfg, cache.Options{ListChunkSize: 1})
+					Expect(err).NotTo(HaveOccurred())
+					pagingStop := make(chan struct{})
+					defer close(pagingStop)
+					go func() {
+						defer GinkgoRecover()
+						Expect(pagingCache.Start(pagingStop)).To(Succeed())
+					}()
+					Expect(pagingCache.WaitForCacheSync(pagingStop)).To(BeTrue())
+
+					By("listing the pods in a namespace")
+					out := &kcorev1.PodList{}
+					Expect(pagingCache.List(context.Background(), out, client.InNamespace(testNamespaceTwo))).To(Succeed())
+
+					By("verifying that all pods are listed, one page at a time")
+					Expect(out.Items).To(HaveLen(2))
+					Expect(atomic.LoadInt32(&pagedLists)).To(BeNumerically(">", 1))
+				})
+
+				It("should refuse a negative ListChunkSize", func() {
+					_, err := createCacheFunc(cfg, cache.Options{ListChunkSize: -1})
+					Expect(err).To(MatchError(ContainSubstring("invalid ListChunkSize")))
+				})
+
+				It("should list objects in pages with Limit and Continue", func() {
+					By("listing the first page")
+					out := &kcorev1.PodList{}
+					Expect(informerCache.List(context.Background(), out, client.InNamespace(testNamespaceTwo), client.Limit(1))).To(Succeed())
+					Expect(out.Items).To(HaveLen(1))
+					Expect(out.Items[0].Name).To(Equal("test-pod-2"))
+					Expect(out.Continue).NotTo(BeEmpty())
+
+					By("continuing with the second and last page")
+					Expect(informerCache.List(context.Background(), out, client.InNamespace(testNamespaceTwo), client.Limit(1), client.Continue(out.Continue))).To(Succeed())
+					Expect(out.Items).To(HaveLen(1))
+					Expect(out.Items[0].Name).To(Equal("test-pod-3"))
+					Expect(out.Continue).To(BeEmpty())
+				})
+
+				It("should refuse continue tokens of other lists", func() {
+					out := &kcorev1.PodList{}
+					Expect(informerCache.List(context.Background(), out, client.InNamespace(testNamespaceTwo), client.Limit(1))).To(Succeed())
+					err := informerCache.List(context.Background(), out, client.InNamespace(testNamespaceTwo),
+						client.MatchingLabels(map[string]string{"test-label": "test-pod-3"}), client.Continue(out.Continue))
+					Expect(errors.IsBadRequest(err)).To(BeTrue())
+				})
 			})
 			Context("with unstructured objects", func() {
 				It("should be able to list objects that haven't been watched previously", func() {
@@ -496,6 +587,42 @@ func CacheTest(createCacheFunc func(config *rest.Config, opts cache.Options) (ca
 					Eventually(out).Should(Receive(Equal(pod)))
 					close(done)
 				})
+				It("should count the events received by the informer", func() {
+					By("getting a shared index informer for a pod")
+					pod := &kcorev1.Pod{
+						ObjectMeta: kmetav1.ObjectMeta{
+							Name:      "informer-metrics",
+							Namespace: "default",
+						},
+						Spec: kcorev1.PodSpec{
+							Containers: []kcorev1.Container{
+								{
+									Name:  "nginx",
+									Image: "nginx",
+								},
+							},
+						},
+					}
+					sii, err := informerCache.GetInformer(pod)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(sii.HasSynced()).To(BeTrue())
+
+					addEvents := func() float64 {
+						var m dto.Metric
+						Expect(cachemetrics.InformerEventsTotal.WithLabelValues("/v1, Kind=Pod", "add").Write(&m)).To(Succeed())
+						return m.GetCounter().GetValue()
+					}
+					before := addEvents()
+
+					By("adding an object")
+					cl, err := client.New(cfg, client.Options{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cl.Create(context.Background(), pod)).To(Succeed())
+					defer deletePod(pod)
+
+					By("verifying the add event was counted")
+					Eventually(addEvents).Should(BeNumerically(">", before))
+				})
 				// TODO: Add a test for when GVK is not in Scheme. Does code support informer for unstructured object?
 				It("should be able to get an informer by group/version/kind", func(done Done) {
 					By("getting an shared index informer for gvk = core/v1/pod")
@@ -567,6 +694,54 @@ func CacheTest(createCacheFunc func(config *rest.Config, opts cache.Options) (ca
 					actual := listObj.Items[0]
 					Expect(actual.Name).To(Equal("test-pod-3"))
 				})
+
+				It("should resync the handlers of an informer when it is restarted", func() {
+					By("adding an event handler counting the add events of a pod")
+					sii, err := informerCache.GetInformer(&kcorev1.Pod{})
+					Expect(err).NotTo(HaveOccurred())
+					var adds int32
+					sii.AddEventHandler(kcache.ResourceEventHandlerFuncs{AddFunc: func(obj interface{}) {
+						if obj.(*kcorev1.Pod).Name == "test-pod-1" {
+							atomic.AddInt32(&adds, 1)
+						}
+					}})
+					Eventually(func() int32 { return atomic.LoadInt32(&adds) }).Should(Equal(int32(1)))
+
+					By("restarting the informer")
+					gvk := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+					Expect(informerCache.(cache.InformerRestarter).RestartInformer(context.Background(), gvk)).To(Succeed())
+
+					By("verifying the pod is added again, and can still be read")
+					Eventually(func() int32 { return atomic.LoadInt32(&adds) }).Should(Equal(int32(2)))
+					Expect(sii.HasSynced()).To(BeTrue())
+					pod := &kcorev1.Pod{}
+					Expect(informerCache.Get(context.Background(), client.ObjectKey{Namespace: testNamespaceOne, Name: "test-pod-1"}, pod)).To(Succeed())
+				})
+
+				It("should start a new informer once an informer is stopped", func() {
+					By("adding an event handler counting the add events of a pod")
+					sii, err := informerCache.GetInformer(&kcorev1.Pod{})
+					Expect(err).NotTo(HaveOccurred())
+					var adds int32
+					sii.AddEventHandler(kcache.ResourceEventHandlerFuncs{AddFunc: func(obj interface{}) {
+						if obj.(*kcorev1.Pod).Name == "test-pod-1" {
+							atomic.AddInt32(&adds, 1)
+						}
+					}})
+					Eventually(func() int32 { return atomic.LoadInt32(&adds) }).Should(Equal(int32(1)))
+
+					By("stopping the informer")
+					gvk := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+					Expect(informerCache.(cache.InformerStopper).StopInformer(gvk)).To(Succeed())
+
+					By("verifying a new informer is started, which the old event handler doesn't see")
+					nsii, err := informerCache.GetInformer(&kcorev1.Pod{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(nsii).NotTo(BeIdenticalTo(sii))
+					pod := &kcorev1.Pod{}
+					Expect(informerCache.Get(context.Background(), client.ObjectKey{Namespace: testNamespaceOne, Name: "test-pod-1"}, pod)).To(Succeed())
+					Consistently(func() int32 { return atomic.LoadInt32(&adds) }).Should(Equal(int32(1)))
+				})
 			})
 			Context("with unstructured objects", func() {
 				It("should be able to get informer for the object", func(done Done) {
@@ -33,9 +33,11 @@ import (
 )
 
 var (
-	_ Informers     = &informerCache{}
-	_ client.Reader = &informerCache{}
-	_ Cache         = &informerCache{}
+	_ Informers         = &informerCache{}
+	_ client.Reader     = &informerCache{}
+	_ Cache             = &informerCache{}
+	_ InformerRestarter = &informerCache{}
+	_ InformerStopper   = &informerCache{}
 )
 
 // informerCache is a Kubernetes Object cache populated from InformersMap.  informerCache wraps an InformersMap.
@@ -45,6 +47,10 @@ type informerCache struct {
 
 // Get implements Reader
 func (ip *informerCache) Get(ctx context.Context, key client.ObjectKey, out runtime.Object) error {
+	if !ip.InformersMap.Started() {
+		return ErrCacheNotStarted
+	}
+
 	gvk, err := apiutil.GVKForObject(out, ip.Scheme)
 	if err != nil {
 		return err
@@ -59,6 +65,10 @@ func (ip *informerCache) Get(ctx context.Context, key client.ObjectKey, out runt
 
 // List implements Reader
 func (ip *informerCache) List(ctx context.Context, out runtime.Object, opts ...client.ListOptionFunc) error {
+	if !ip.InformersMap.Started() {
+		return ErrCacheNotStarted
+	}
+
 	gvk, err := apiutil.GVKForObject(out, ip.Scheme)
 	if err != nil {
 		return err
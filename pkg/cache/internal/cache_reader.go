@@ -18,8 +18,11 @@ package internal
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
@@ -102,6 +105,9 @@ func (c *CacheReader) List(_ context.Context, out runtime.Object, opts ...client
 		if !requiresExact {
 			return fmt.Errorf("non-exact field matches are not supported by the cache")
 		}
+		if _, indexed := c.indexer.GetIndexers()[FieldIndexName(field)]; !indexed {
+			return &client.ErrIndexNotFound{Field: field}
+		}
 		// list all objects by the field selector.  If this is namespaced and we have one, ask for the
 		// namespaced index key.  Otherwise, ask for the non-namespaced variant by using the fake "all namespaces"
 		// namespace.
@@ -118,6 +124,9 @@ func (c *CacheReader) List(_ context.Context, out runtime.Object, opts ...client
 	if listOpts.LabelSelector != nil {
 		labelSel = listOpts.LabelSelector
 	}
+	if listOpts.Limit > 0 || len(listOpts.Continue) > 0 {
+		return c.listPage(objs, labelSel, &listOpts, out)
+	}
 
 	runtimeObjs := make([]runtime.Object, 0, len(objs))
 	for _, item := range objs {
@@ -136,6 +145,122 @@ func (c *CacheReader) List(_ context.Context, out runtime.Object, opts ...client
 	return apimeta.SetList(out, filteredItems)
 }
 
+// continueToken is the position of a paginated list of a CacheReader, encoded into the Continue field of the
+// list.
+type continueToken struct {
+	// Query identifies the namespace and selectors of the list, which must be the same when it is continued.
+	Query string `json:"q"`
+
+	// Key is the store key of the last object returned.  Objects are returned in the order of their keys,
+	// so the position remains valid while objects are added and removed: continuing the list skips the
+	// objects removed meanwhile, and returns those added after the position.
+	Key string `json:"k"`
+}
+
+// keyedObject is an object in the store, with its key.
+type keyedObject struct {
+	key string
+	obj runtime.Object
+}
+
+// listPage writes the page of objs which matches labelSel and comes after the position of opts.Continue, of
+// up to opts.Limit objects, to out.  Only the objects of the page are copied.
+func (c *CacheReader) listPage(objs []interface{}, labelSel labels.Selector, opts *client.ListOptions, out runtime.Object) error {
+	query := listQuery(opts)
+	var after string
+	if len(opts.Continue) > 0 {
+		token, err := decodeContinueToken(opts.Continue)
+		if err != nil {
+			return errors.NewBadRequest(fmt.Sprintf("invalid continue token: %v", err))
+		}
+		if token.Query != query {
+			return errors.NewBadRequest("invalid continue token: the namespace or selectors of the list have changed")
+		}
+		after = token.Key
+	}
+
+	page := make([]keyedObject, 0, len(objs))
+	for _, item := range objs {
+		obj, isObj := item.(runtime.Object)
+		if !isObj {
+			return fmt.Errorf("cache contained %T, which is not an Object", item)
+		}
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return err
+		}
+		if key <= after {
+			continue
+		}
+		if labelSel != nil {
+			meta, err := apimeta.Accessor(obj)
+			if err != nil {
+				return err
+			}
+			if !labelSel.Matches(labels.Set(meta.GetLabels())) {
+				continue
+			}
+		}
+		page = append(page, keyedObject{key: key, obj: obj})
+	}
+	sort.Slice(page, func(i, j int) bool { return page[i].key < page[j].key })
+
+	var next string
+	if opts.Limit > 0 && int64(len(page)) > opts.Limit {
+		page = page[:opts.Limit]
+		var err error
+		if next, err = encodeContinueToken(continueToken{Query: query, Key: page[len(page)-1].key}); err != nil {
+			return err
+		}
+	}
+
+	items := make([]runtime.Object, 0, len(page))
+	for _, item := range page {
+		outObj := item.obj.DeepCopyObject()
+		outObj.GetObjectKind().SetGroupVersionKind(c.groupVersionKind)
+		items = append(items, outObj)
+	}
+	if err := apimeta.SetList(out, items); err != nil {
+		return err
+	}
+	listMeta, err := apimeta.ListAccessor(out)
+	if err != nil {
+		return err
+	}
+	listMeta.SetContinue(next)
+	return nil
+}
+
+// listQuery identifies the namespace and selectors of a list.
+func listQuery(opts *client.ListOptions) string {
+	query := opts.Namespace
+	if opts.LabelSelector != nil {
+		query += "?labels=" + opts.LabelSelector.String()
+	}
+	if opts.FieldSelector != nil {
+		query += "?fields=" + opts.FieldSelector.String()
+	}
+	return query
+}
+
+func encodeContinueToken(token continueToken) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeContinueToken(encoded string) (continueToken, error) {
+	var token continueToken
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return token, err
+	}
+	err = json.Unmarshal(data, &token)
+	return token, err
+}
+
 // objectKeyToStorageKey converts an object key to store key.
 // It's akin to MetaNamespaceKeyFunc.  It's separate from
 // String to allow keeping the key format easily in sync with
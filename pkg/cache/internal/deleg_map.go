@@ -17,6 +17,7 @@ limitations under the License.
 package internal
 
 import (
+	"context"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -45,11 +46,13 @@ func NewInformersMap(config *rest.Config,
 	scheme *runtime.Scheme,
 	mapper meta.RESTMapper,
 	resync time.Duration,
-	namespace string) *InformersMap {
+	namespace string,
+	listChunkSize int64,
+	listChunkSizes map[schema.GroupVersionKind]int64) *InformersMap {
 
 	return &InformersMap{
-		structured:   newStructuredInformersMap(config, scheme, mapper, resync, namespace),
-		unstructured: newUnstructuredInformersMap(config, scheme, mapper, resync, namespace),
+		structured:   newStructuredInformersMap(config, scheme, mapper, resync, namespace, listChunkSize, listChunkSizes),
+		unstructured: newUnstructuredInformersMap(config, scheme, mapper, resync, namespace, listChunkSize, listChunkSizes),
 
 		Scheme: scheme,
 	}
@@ -63,8 +66,17 @@ func (m *InformersMap) Start(stop <-chan struct{}) error {
 	return nil
 }
 
-// WaitForCacheSync waits until all the caches have been synced.
+// Started reports whether the informers have been started.
+func (m *InformersMap) Started() bool {
+	return m.structured.isStarted() && m.unstructured.isStarted()
+}
+
+// WaitForCacheSync waits until the informers have been started and all the caches have been synced.
 func (m *InformersMap) WaitForCacheSync(stop <-chan struct{}) bool {
+	if !m.structured.waitForStarted(stop) || !m.unstructured.waitForStarted(stop) {
+		return false
+	}
+
 	syncedFuncs := append([]cache.InformerSynced(nil), m.structured.HasSyncedFuncs()...)
 	syncedFuncs = append(syncedFuncs, m.unstructured.HasSyncedFuncs()...)
 
@@ -85,12 +97,30 @@ func (m *InformersMap) Get(gvk schema.GroupVersionKind, obj runtime.Object) (*Ma
 	return m.structured.Get(gvk, obj)
 }
 
+// RestartInformer replaces the structured and unstructured informers for gvk by new ones, which list all
+// objects again, and waits for them to sync.
+func (m *InformersMap) RestartInformer(ctx context.Context, gvk schema.GroupVersionKind) error {
+	if err := m.structured.Restart(ctx, gvk); err != nil {
+		return err
+	}
+	return m.unstructured.Restart(ctx, gvk)
+}
+
+// StopInformer stops the structured and unstructured informers for gvk, and removes them.
+func (m *InformersMap) StopInformer(gvk schema.GroupVersionKind) error {
+	m.structured.Stop(gvk)
+	m.unstructured.Stop(gvk)
+	return nil
+}
+
 // newStructuredInformersMap creates a new InformersMap for structured objects.
-func newStructuredInformersMap(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, resync time.Duration, namespace string) *specificInformersMap {
-	return newSpecificInformersMap(config, scheme, mapper, resync, namespace, createStructuredListWatch)
+func newStructuredInformersMap(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, resync time.Duration, namespace string,
+	listChunkSize int64, listChunkSizes map[schema.GroupVersionKind]int64) *specificInformersMap {
+	return newSpecificInformersMap(config, scheme, mapper, resync, namespace, listChunkSize, listChunkSizes, createStructuredListWatch)
 }
 
 // newUnstructuredInformersMap creates a new InformersMap for unstructured objects.
-func newUnstructuredInformersMap(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, resync time.Duration, namespace string) *specificInformersMap {
-	return newSpecificInformersMap(config, scheme, mapper, resync, namespace, createUnstructuredListWatch)
+func newUnstructuredInformersMap(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, resync time.Duration, namespace string,
+	listChunkSize int64, listChunkSizes map[schema.GroupVersionKind]int64) *specificInformersMap {
+	return newSpecificInformersMap(config, scheme, mapper, resync, namespace, listChunkSize, listChunkSizes, createUnstructuredListWatch)
 }
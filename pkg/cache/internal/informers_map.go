@@ -17,6 +17,7 @@ limitations under the License.
 package internal
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -30,7 +31,9 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/pager"
 
+	"sigs.k8s.io/controller-runtime/pkg/cache/internal/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
@@ -44,6 +47,8 @@ func newSpecificInformersMap(config *rest.Config,
 	mapper meta.RESTMapper,
 	resync time.Duration,
 	namespace string,
+	listChunkSize int64,
+	listChunkSizes map[schema.GroupVersionKind]int64,
 	createListWatcher createListWatcherFunc) *specificInformersMap {
 	ip := &specificInformersMap{
 		config:            config,
@@ -55,6 +60,9 @@ func newSpecificInformersMap(config *rest.Config,
 		resync:            resync,
 		createListWatcher: createListWatcher,
 		namespace:         namespace,
+		listChunkSize:     listChunkSize,
+		listChunkSizes:    listChunkSizes,
+		startWait:         make(chan struct{}),
 	}
 	return ip
 }
@@ -101,6 +109,10 @@ type specificInformersMap struct {
 	// start is true if the informers have been started
 	started bool
 
+	// startWait is a channel that is closed after the
+	// informer has been started.
+	startWait chan struct{}
+
 	// createClient knows how to create a client and a list object,
 	// and allows for abstracting over the particulars of structured vs
 	// unstructured objects.
@@ -109,6 +121,13 @@ type specificInformersMap struct {
 	// namespace is the namespace that all ListWatches are restricted to
 	// default or empty string means all namespaces
 	namespace string
+
+	// listChunkSize is the number of objects the initial list of an informer is paged in by.  0 means
+	// the list isn't paged.
+	listChunkSize int64
+
+	// listChunkSizes overrides listChunkSize for specific GroupVersionKinds.
+	listChunkSizes map[schema.GroupVersionKind]int64
 }
 
 // Start calls Run on each of the informers and sets started to true.  Blocks on the stop channel.
@@ -128,10 +147,28 @@ func (ip *specificInformersMap) Start(stop <-chan struct{}) {
 
 		// Set started to true so we immediately start any informers added later.
 		ip.started = true
+		close(ip.startWait)
 	}()
 	<-stop
 }
 
+// waitForStarted blocks until the informers have been started, and returns false if stop is closed before.
+func (ip *specificInformersMap) waitForStarted(stop <-chan struct{}) bool {
+	select {
+	case <-ip.startWait:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// isStarted reports whether the informers have been started.
+func (ip *specificInformersMap) isStarted() bool {
+	ip.mu.RLock()
+	defer ip.mu.RUnlock()
+	return ip.started
+}
+
 // HasSyncedFuncs returns all the HasSynced functions for the informers in this map.
 func (ip *specificInformersMap) HasSyncedFuncs() []cache.InformerSynced {
 	ip.mu.RLock()
@@ -183,16 +220,12 @@ func (ip *specificInformersMap) addInformerToMap(gvk schema.GroupVersionKind, ob
 	}
 
 	// Create a NewSharedIndexInformer and add it to the map.
-	var lw *cache.ListWatch
-	lw, err := ip.createListWatcher(gvk, ip)
+	ni, err := ip.newInformer(gvk, obj)
 	if err != nil {
 		return nil, false, err
 	}
-	ni := cache.NewSharedIndexInformer(lw, obj, ip.resync, cache.Indexers{
-		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
-	})
 	i := &MapEntry{
-		Informer: ni,
+		Informer: newRestartableInformer(ni, obj, ip.resync),
 		Reader:   CacheReader{indexer: ni.GetIndexer(), groupVersionKind: gvk},
 	}
 	ip.informersByGVK[gvk] = i
@@ -206,6 +239,60 @@ func (ip *specificInformersMap) addInformerToMap(gvk schema.GroupVersionKind, ob
 	return i, ip.started, nil
 }
 
+// newInformer returns a new SharedIndexInformer for gvk, which isn't running yet.
+func (ip *specificInformersMap) newInformer(gvk schema.GroupVersionKind, obj runtime.Object) (cache.SharedIndexInformer, error) {
+	lw, err := ip.createListWatcher(gvk, ip)
+	if err != nil {
+		return nil, err
+	}
+	if chunkSize := ip.listChunkSizeFor(gvk); chunkSize > 0 {
+		lw.ListFunc = chunkedListFunc(lw.ListFunc, chunkSize)
+	}
+	ni := cache.NewSharedIndexInformer(lw, obj, ip.resync, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+	// Count raw events before any predicates or handlers get a chance to filter them.
+	ni.AddEventHandler(eventCountingHandler(gvk))
+	return ni, nil
+}
+
+// listChunkSizeFor returns the number of objects the initial list of the informer for gvk is paged in by.
+func (ip *specificInformersMap) listChunkSizeFor(gvk schema.GroupVersionKind) int64 {
+	if chunkSize, found := ip.listChunkSizes[gvk]; found {
+		return chunkSize
+	}
+	return ip.listChunkSize
+}
+
+// chunkedListFunc wraps list so that it pages in the objects by chunkSize.  If the continue token of a page
+// expires before the next page is fetched, everything is listed again at once.
+func chunkedListFunc(list cache.ListFunc, chunkSize int64) cache.ListFunc {
+	p := pager.New(pager.SimplePageFunc(list))
+	p.PageSize = chunkSize
+	return func(opts metav1.ListOptions) (runtime.Object, error) {
+		// Informers list at resourceVersion "0", which the API server serves from its watch cache,
+		// ignoring the limit.  Only lists from etcd can be paged.
+		if opts.ResourceVersion == "0" {
+			opts.ResourceVersion = ""
+		}
+		return p.List(context.Background(), opts)
+	}
+}
+
+// eventCountingHandler returns a ResourceEventHandler that records every event
+// delivered to the informer for the given GroupVersionKind.
+func eventCountingHandler(gvk schema.GroupVersionKind) cache.ResourceEventHandler {
+	gvkLabel := gvk.String()
+	adds := metrics.InformerEventsTotal.WithLabelValues(gvkLabel, "add")
+	updates := metrics.InformerEventsTotal.WithLabelValues(gvkLabel, "update")
+	deletes := metrics.InformerEventsTotal.WithLabelValues(gvkLabel, "delete")
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { adds.Inc() },
+		UpdateFunc: func(interface{}, interface{}) { updates.Inc() },
+		DeleteFunc: func(interface{}) { deletes.Inc() },
+	}
+}
+
 // newListWatch returns a new ListWatch object that can be used to create a SharedIndexInformer.
 func createStructuredListWatch(gvk schema.GroupVersionKind, ip *specificInformersMap) (*cache.ListWatch, error) {
 	// Kubernetes APIs work against Resources, not GroupVersionKinds.  Map the
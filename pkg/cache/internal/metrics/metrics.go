@@ -0,0 +1,39 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// InformerEventsTotal is a prometheus counter metric which holds the total
+	// number of events received by the cache's informers.  It has two labels.
+	// gvk label refers to the GroupVersionKind of the informer, and verb label
+	// refers to the kind of event i.e. add, update, delete.
+	// Events are counted before any predicates or event handlers run.
+	InformerEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_informer_events_total",
+		Help: "Total number of events received by informers per GroupVersionKind",
+	}, []string{"gvk", "verb"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(InformerEventsTotal)
+}
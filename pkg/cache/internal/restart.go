@@ -0,0 +1,283 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Restart replaces the informer for gvk by a new one which lists all objects again, e.g. after a new version
+// of a CRD is served.  The event handlers and indexers of the old informer are added to the new one, so its
+// handlers see an add event for every object, and a delete event for every object which is gone.  Reads are
+// served by the old informer until the new one has synced.  It does nothing if there is no informer for gvk
+// or if the informers haven't been started yet.
+func (ip *specificInformersMap) Restart(ctx context.Context, gvk schema.GroupVersionKind) error {
+	ip.mu.RLock()
+	entry, found := ip.informersByGVK[gvk]
+	started, stop := ip.started, ip.stop
+	ip.mu.RUnlock()
+	if !found || !started {
+		// Informers which aren't running yet list all objects once they are started anyway.
+		return nil
+	}
+
+	ri := entry.Informer.(*restartableInformer)
+	ri.restartMu.Lock()
+	defer ri.restartMu.Unlock()
+
+	ni, err := ip.newInformer(gvk, ri.objType)
+	if err != nil {
+		return err
+	}
+	done, err := ri.prepare(ni)
+	if err != nil {
+		return err
+	}
+	go ni.Run(mergeStop(stop, done))
+	// Cancel the wait once Restart returns, so that the goroutine merging its stop channels ends with it.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if !cache.WaitForCacheSync(mergeStop(ctx.Done(), stop), ni.HasSynced) {
+		ri.abort()
+		return fmt.Errorf("failed waiting for the restarted %v informer to sync", gvk)
+	}
+
+	ip.mu.Lock()
+	if current, found := ip.informersByGVK[gvk]; !found || current.Informer != ri {
+		// Stopped meanwhile.
+		ip.mu.Unlock()
+		ri.abort()
+		return nil
+	}
+	ip.informersByGVK[gvk] = &MapEntry{
+		Informer: ri,
+		Reader:   CacheReader{indexer: ni.GetIndexer(), groupVersionKind: gvk},
+	}
+	ip.mu.Unlock()
+	ri.commit()
+	return nil
+}
+
+// Stop stops the informer for gvk and removes it from the map, e.g. once its kind is no longer served.  The
+// next Get for gvk creates a new informer.  It does nothing if there is no informer for gvk.
+func (ip *specificInformersMap) Stop(gvk schema.GroupVersionKind) {
+	ip.mu.Lock()
+	entry, found := ip.informersByGVK[gvk]
+	delete(ip.informersByGVK, gvk)
+	ip.mu.Unlock()
+	if !found {
+		return
+	}
+
+	// Wait for a restart in progress, which then finds the informer removed.
+	ri := entry.Informer.(*restartableInformer)
+	ri.restartMu.Lock()
+	defer ri.restartMu.Unlock()
+	ri.stop()
+}
+
+// mergeStop returns a channel which is closed once either a or b is closed.  Its goroutine runs until then,
+// so one of them must eventually be closed.
+func mergeStop(a, b <-chan struct{}) <-chan struct{} {
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		select {
+		case <-a:
+		case <-b:
+		}
+	}()
+	return merged
+}
+
+var _ cache.SharedIndexInformer = &restartableInformer{}
+
+// restartableInformer is the informer of a MapEntry.  It delegates to the SharedIndexInformer currently
+// running for the type, and remembers the event handlers and indexers added to it, so that they can be
+// added to a new SharedIndexInformer when the informer is restarted.
+type restartableInformer struct {
+	objType runtime.Object
+	resync  time.Duration
+
+	// restartMu serializes the restarts of the informer.
+	restartMu sync.Mutex
+
+	mu sync.RWMutex
+	// current is the running informer, until done is closed.
+	current cache.SharedIndexInformer
+	done    chan struct{}
+	// next is the informer replacing current while it syncs, until nextDone is closed.
+	next     cache.SharedIndexInformer
+	nextDone chan struct{}
+	// stopped is set once the informer has been stopped for good.
+	stopped bool
+
+	handlers []restartableHandler
+	indexers cache.Indexers
+}
+
+// restartableHandler is an event handler added to a restartableInformer.
+type restartableHandler struct {
+	handler cache.ResourceEventHandler
+	resync  time.Duration
+}
+
+func newRestartableInformer(i cache.SharedIndexInformer, objType runtime.Object, resync time.Duration) *restartableInformer {
+	return &restartableInformer{
+		objType:  objType,
+		resync:   resync,
+		current:  i,
+		done:     make(chan struct{}),
+		indexers: cache.Indexers{},
+	}
+}
+
+// AddEventHandler implements cache.SharedInformer
+func (ri *restartableInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	ri.AddEventHandlerWithResyncPeriod(handler, ri.resync)
+}
+
+// AddEventHandlerWithResyncPeriod implements cache.SharedInformer
+func (ri *restartableInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	ri.current.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	if ri.next != nil {
+		ri.next.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	}
+	ri.handlers = append(ri.handlers, restartableHandler{handler: handler, resync: resyncPeriod})
+}
+
+// AddIndexers implements cache.SharedIndexInformer
+func (ri *restartableInformer) AddIndexers(indexers cache.Indexers) error {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	if err := ri.current.AddIndexers(indexers); err != nil {
+		return err
+	}
+	for name, indexFunc := range indexers {
+		ri.indexers[name] = indexFunc
+	}
+	return nil
+}
+
+// Run implements cache.SharedInformer
+func (ri *restartableInformer) Run(stopCh <-chan struct{}) {
+	ri.mu.RLock()
+	current, done := ri.current, ri.done
+	ri.mu.RUnlock()
+	current.Run(mergeStop(stopCh, done))
+}
+
+// GetStore implements cache.SharedInformer
+func (ri *restartableInformer) GetStore() cache.Store {
+	return ri.running().GetStore()
+}
+
+// GetIndexer implements cache.SharedIndexInformer
+func (ri *restartableInformer) GetIndexer() cache.Indexer {
+	return ri.running().GetIndexer()
+}
+
+// GetController implements cache.SharedInformer
+func (ri *restartableInformer) GetController() cache.Controller {
+	return ri.running().GetController()
+}
+
+// HasSynced implements cache.SharedInformer
+func (ri *restartableInformer) HasSynced() bool {
+	return ri.running().HasSynced()
+}
+
+// LastSyncResourceVersion implements cache.SharedInformer
+func (ri *restartableInformer) LastSyncResourceVersion() string {
+	return ri.running().LastSyncResourceVersion()
+}
+
+func (ri *restartableInformer) running() cache.SharedIndexInformer {
+	ri.mu.RLock()
+	defer ri.mu.RUnlock()
+	return ri.current
+}
+
+// prepare adds the indexers and event handlers to the informer replacing the current one, and returns the
+// channel which stops it.
+func (ri *restartableInformer) prepare(next cache.SharedIndexInformer) (chan struct{}, error) {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	if len(ri.indexers) > 0 {
+		if err := next.AddIndexers(ri.indexers); err != nil {
+			return nil, err
+		}
+	}
+	for _, h := range ri.handlers {
+		next.AddEventHandlerWithResyncPeriod(h.handler, h.resync)
+	}
+	ri.next, ri.nextDone = next, make(chan struct{})
+	return ri.nextDone, nil
+}
+
+// stop stops the current informer for good.
+func (ri *restartableInformer) stop() {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	if !ri.stopped {
+		ri.stopped = true
+		close(ri.done)
+	}
+}
+
+// abort stops the informer which failed to replace the current one.
+func (ri *restartableInformer) abort() {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	close(ri.nextDone)
+	ri.next, ri.nextDone = nil, nil
+}
+
+// commit stops the current informer in favor of the synced one replacing it, and passes the objects which
+// are gone meanwhile to the event handlers as deleted.
+func (ri *restartableInformer) commit() {
+	ri.mu.Lock()
+	old, oldDone := ri.current, ri.done
+	ri.current, ri.done = ri.next, ri.nextDone
+	ri.next, ri.nextDone = nil, nil
+	current := ri.current
+	handlers := append([]restartableHandler(nil), ri.handlers...)
+	ri.mu.Unlock()
+	close(oldDone)
+
+	for _, obj := range old.GetStore().List() {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			continue
+		}
+		if _, exists, _ := current.GetStore().GetByKey(key); exists {
+			continue
+		}
+		for _, h := range handlers {
+			h.handler.OnDelete(cache.DeletedFinalStateUnknown{Key: key, Obj: obj})
+		}
+	}
+}
@@ -66,6 +66,8 @@ type multiNamespaceCache struct {
 }
 
 var _ Cache = &multiNamespaceCache{}
+var _ InformerRestarter = &multiNamespaceCache{}
+var _ InformerStopper = &multiNamespaceCache{}
 
 // Methods for multiNamespaceCache to conform to the Informers interface
 func (c *multiNamespaceCache) GetInformer(obj runtime.Object) (Informer, error) {
@@ -115,6 +117,26 @@ func (c *multiNamespaceCache) WaitForCacheSync(stop <-chan struct{}) bool {
 	return synced
 }
 
+// RestartInformer implements InformerRestarter
+func (c *multiNamespaceCache) RestartInformer(ctx context.Context, gvk schema.GroupVersionKind) error {
+	for _, cache := range c.namespaceToCache {
+		if err := cache.(InformerRestarter).RestartInformer(ctx, gvk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StopInformer implements InformerStopper
+func (c *multiNamespaceCache) StopInformer(gvk schema.GroupVersionKind) error {
+	for _, cache := range c.namespaceToCache {
+		if err := cache.(InformerStopper).StopInformer(gvk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *multiNamespaceCache) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
 	for _, cache := range c.namespaceToCache {
 		if err := cache.IndexField(obj, field, extractValue); err != nil {
@@ -143,6 +165,9 @@ func (c *multiNamespaceCache) List(ctx context.Context, list runtime.Object, opt
 		}
 		return cache.List(ctx, list, opts...)
 	}
+	if listOpts.Limit > 0 || len(listOpts.Continue) > 0 {
+		return fmt.Errorf("unable to list: paginated lists across all namespaces are not supported by the multi-namespace cache, list each namespace instead")
+	}
 
 	listAccessor, err := meta.ListAccessor(list)
 	if err != nil {
@@ -43,9 +43,37 @@ func NewDiscoveryRESTMapper(c *rest.Config) (meta.RESTMapper, error) {
 	return restmapper.NewDiscoveryRESTMapper(gr), nil
 }
 
+// ErrUnregisteredType is returned when the type of an object is not registered in the Scheme.
+type ErrUnregisteredType struct {
+	// Type is the Go type of the object, e.g. "*v1.Pod".
+	Type string
+
+	// Err is the error returned by the Scheme.
+	Err error
+}
+
+// Error implements error
+func (e *ErrUnregisteredType) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error returned by the Scheme, for errors.Is and errors.As.
+func (e *ErrUnregisteredType) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the error returned by the Scheme, for github.com/pkg/errors.Cause.
+func (e *ErrUnregisteredType) Cause() error {
+	return e.Err
+}
+
 // GVKForObject finds the GroupVersionKind associated with the given object, if there is only a single such GVK.
+// It returns an *ErrUnregisteredType if the object's type is not registered in the scheme.
 func GVKForObject(obj runtime.Object, scheme *runtime.Scheme) (schema.GroupVersionKind, error) {
 	gvks, isUnversioned, err := scheme.ObjectKinds(obj)
+	if runtime.IsNotRegisteredError(err) {
+		return schema.GroupVersionKind{}, &ErrUnregisteredType{Type: fmt.Sprintf("%T", obj), Err: err}
+	}
 	if err != nil {
 		return schema.GroupVersionKind{}, err
 	}
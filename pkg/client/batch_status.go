@@ -0,0 +1,216 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
+)
+
+var log = logf.RuntimeLog.WithName("client")
+
+var _ StatusWriter = &BatchingStatusWriter{}
+
+// BatchingStatusWriter is a StatusWriter which defers status updates, and writes them every flush interval
+// from a single goroutine running while it is started, e.g. by adding it to a manager.  Updates of the
+// status of the same object within an interval are coalesced into a single update of the latest status
+// written.  An update conflicting with a newer version of the object is retried with the latest status
+// written applied to that version, so the latest status wins.  Updates which fail otherwise are retried
+// with the next flush, unless the object is gone or invalid, or a newer status has been written meanwhile.
+// The status still pending is written once the writer is stopped.
+//
+// Updates return as soon as the status is buffered: the object passed to Update is not updated with the
+// response of the API server, and errors are only logged.  Patches can't be coalesced, so they are applied
+// right away, after writing the status still pending for the object.
+type BatchingStatusWriter struct {
+	client   Client
+	reader   Reader
+	scheme   *runtime.Scheme
+	interval time.Duration
+
+	mu sync.Mutex
+	// pending are the latest status updates which haven't been written yet.
+	pending map[overlayKey]*pendingStatus
+}
+
+// pendingStatus is a status update buffered by a BatchingStatusWriter.
+type pendingStatus struct {
+	obj  runtime.Object
+	opts []UpdateOptionFunc
+}
+
+// NewBatchingStatusWriter returns a BatchingStatusWriter which writes the status through c every
+// interval.  The objects whose updates conflict are read again through reader, which shouldn't be backed
+// by a cache that may still hold the conflicting version, e.g. the APIReader of a manager.
+func NewBatchingStatusWriter(c Client, reader Reader, scheme *runtime.Scheme, interval time.Duration) *BatchingStatusWriter {
+	return &BatchingStatusWriter{
+		client:   c,
+		reader:   reader,
+		scheme:   scheme,
+		interval: interval,
+		pending:  map[overlayKey]*pendingStatus{},
+	}
+}
+
+// Update implements StatusWriter
+func (w *BatchingStatusWriter) Update(_ context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	key, err := w.keyFor(obj)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[key] = &pendingStatus{obj: obj.DeepCopyObject(), opts: opts}
+	return nil
+}
+
+// Patch implements StatusWriter
+func (w *BatchingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	key, err := w.keyFor(obj)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	p, found := w.pending[key]
+	delete(w.pending, key)
+	w.mu.Unlock()
+	if found {
+		if err := w.write(ctx, key, p); err != nil {
+			return err
+		}
+	}
+	return w.client.Status().Patch(ctx, obj, patch, opts...)
+}
+
+// Start writes the pending status every flush interval until stop is closed, and then writes the status
+// still pending.  It implements manager.Runnable.
+func (w *BatchingStatusWriter) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Flush(context.Background()); err != nil {
+				log.Error(err, "Failed to write the status of some objects, retrying with the next flush")
+			}
+		case <-stop:
+			if err := w.Flush(context.Background()); err != nil {
+				log.Error(err, "Failed to write the status of some objects on shutdown")
+			}
+			return nil
+		}
+	}
+}
+
+// Flush writes the pending status now.  The updates which failed, and are still the latest written for
+// their objects, remain pending.
+func (w *BatchingStatusWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = map[overlayKey]*pendingStatus{}
+	w.mu.Unlock()
+
+	var errs []error
+	for key, p := range pending {
+		err := w.write(ctx, key, p)
+		if err == nil {
+			continue
+		}
+		errs = append(errs, err)
+		if apierrors.IsNotFound(err) || apierrors.IsInvalid(err) {
+			continue
+		}
+		w.mu.Lock()
+		if _, superseded := w.pending[key]; !superseded {
+			w.pending[key] = p
+		}
+		w.mu.Unlock()
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// write updates the status of the object of p, applying it to the latest version of the object on
+// conflicts.
+func (w *BatchingStatusWriter) write(ctx context.Context, key overlayKey, p *pendingStatus) error {
+	obj := p.obj
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		err := w.client.Status().Update(ctx, obj, p.opts...)
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		latest := obj.DeepCopyObject()
+		if err := w.reader.Get(ctx, key.ObjectKey, latest); err != nil {
+			return err
+		}
+		updated, convErr := withStatusOf(latest, p.obj)
+		if convErr != nil {
+			return convErr
+		}
+		// Retry the update with the latest version of the object.
+		obj = updated
+		return err
+	})
+}
+
+func (w *BatchingStatusWriter) keyFor(obj runtime.Object) (overlayKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, w.scheme)
+	if err != nil {
+		return overlayKey{}, err
+	}
+	key, err := ObjectKeyFromObject(obj)
+	if err != nil {
+		return overlayKey{}, err
+	}
+	return overlayKey{GroupKind: gvk.GroupKind(), ObjectKey: key}, nil
+}
+
+// withStatusOf returns obj, which it may modify, with the status of from.
+func withStatusOf(obj, from runtime.Object) (runtime.Object, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	fromContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+	if err != nil {
+		return nil, err
+	}
+	if status, found := fromContent["status"]; found {
+		content["status"] = runtime.DeepCopyJSONValue(status)
+	} else {
+		delete(content, "status")
+	}
+
+	if u, ok := obj.(runtime.Unstructured); ok {
+		u.SetUnstructuredContent(content)
+		return u, nil
+	}
+	// Convert into a new object, so that no field of the old status survives.
+	out := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
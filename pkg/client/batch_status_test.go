@@ -0,0 +1,156 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// countingClient counts the status updates made through it, and fails the first ones with a conflict.
+type countingClient struct {
+	client.Client
+
+	mu        sync.Mutex
+	updates   int
+	conflicts int
+}
+
+func (c *countingClient) Status() client.StatusWriter {
+	return &countingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+func (c *countingClient) statusUpdates() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.updates
+}
+
+type countingStatusWriter struct {
+	client.StatusWriter
+	client *countingClient
+}
+
+func (w *countingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOptionFunc) error {
+	w.client.mu.Lock()
+	w.client.updates++
+	conflict := w.client.conflicts > 0
+	if conflict {
+		w.client.conflicts--
+	}
+	w.client.mu.Unlock()
+	if conflict {
+		return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, "foo", nil)
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+// countingReader counts the objects read through it.
+type countingReader struct {
+	client.Reader
+
+	gets int
+}
+
+func (r *countingReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	r.gets++
+	return r.Reader.Get(ctx, key, obj)
+}
+
+var _ = Describe("BatchingStatusWriter", func() {
+	var c *countingClient
+	var reader *countingReader
+	var writer *client.BatchingStatusWriter
+	var ctx = context.TODO()
+	key := client.ObjectKey{Namespace: "default", Name: "foo"}
+
+	get := func() *corev1.Pod {
+		pod := &corev1.Pod{}
+		Expect(c.Get(ctx, key, pod)).To(Succeed())
+		return pod
+	}
+
+	BeforeEach(func() {
+		c = &countingClient{Client: fake.NewFakeClient(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		})}
+		reader = &countingReader{Reader: c}
+		writer = client.NewBatchingStatusWriter(c, reader, scheme.Scheme, time.Hour)
+	})
+
+	It("should coalesce the status updates of an object into a single update of the latest status", func() {
+		pod := get()
+		pod.Status.Phase = corev1.PodPending
+		Expect(writer.Update(ctx, pod)).To(Succeed())
+		pod.Status.Phase = corev1.PodRunning
+		Expect(writer.Update(ctx, pod)).To(Succeed())
+		Expect(c.statusUpdates()).To(Equal(0))
+		Expect(get().Status.Phase).To(BeEmpty())
+
+		Expect(writer.Flush(ctx)).To(Succeed())
+		Expect(c.statusUpdates()).To(Equal(1))
+		Expect(get().Status.Phase).To(Equal(corev1.PodRunning))
+
+		By("not writing anything once nothing is pending")
+		Expect(writer.Flush(ctx)).To(Succeed())
+		Expect(c.statusUpdates()).To(Equal(1))
+	})
+
+	It("should apply the latest status to the latest version of the object on conflicts", func() {
+		pod := get()
+		pod.Status.Phase = corev1.PodRunning
+		Expect(writer.Update(ctx, pod)).To(Succeed())
+
+		updated := get()
+		updated.Labels = map[string]string{"updated": "true"}
+		Expect(c.Update(ctx, updated)).To(Succeed())
+		c.conflicts = 1
+
+		Expect(writer.Flush(ctx)).To(Succeed())
+		Expect(c.statusUpdates()).To(Equal(2))
+		Expect(reader.gets).To(Equal(1))
+		Expect(get().Status.Phase).To(Equal(corev1.PodRunning))
+		Expect(get().Labels).To(HaveKeyWithValue("updated", "true"))
+	})
+
+	It("should write the pending status once it is stopped", func() {
+		stop := make(chan struct{})
+		done := make(chan error)
+		go func() { done <- writer.Start(stop) }()
+
+		pod := get()
+		pod.Status.Phase = corev1.PodRunning
+		Expect(writer.Update(ctx, pod)).To(Succeed())
+		Expect(c.statusUpdates()).To(Equal(0))
+
+		close(stop)
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(get().Status.Phase).To(Equal(corev1.PodRunning))
+	})
+})
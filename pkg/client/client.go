@@ -20,10 +20,14 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -38,6 +42,31 @@ type Options struct {
 
 	// Mapper, if provided, will be used to map GroupVersionKinds to Resources
 	Mapper meta.RESTMapper
+
+	// Timeouts, if provided, are the timeouts of the requests for objects of the given GroupVersionKinds,
+	// including lists of them.  Requests for other types are only bounded by their context and the
+	// rest.Config.  The timeouts are applied to the context of the requests, so they can't extend the
+	// deadline of the context passed to the Client, nor the rest.Config Timeout.  Requests for unstructured
+	// objects don't support contexts, so their timeouts replace the rest.Config Timeout if shorter instead.
+	Timeouts map[schema.GroupVersionKind]time.Duration
+
+	// UnstructuredFallback, if true, makes Get and List return the objects which can't be decoded into the
+	// requested Go type as unstructured, in the Object of the *ConversionError they fail with.  Callers can
+	// then still read such objects, e.g. to migrate them during a CRD version migration.  Defaults to false.
+	UnstructuredFallback bool
+
+	// DefaultPropagationPolicy, if set, is the propagation policy of the Deletes which don't specify one with
+	// PropagationPolicy, e.g. DeletePropagationForeground to never orphan the dependents of deleted objects.
+	// Defaults to "", leaving it to the API server.
+	DefaultPropagationPolicy metav1.DeletionPropagation
+
+	// DefaultGracePeriodSeconds, if set, is the grace period of the Deletes which don't specify one with
+	// GracePeriodSeconds.  Defaults to nil, leaving it to the API server.
+	DefaultGracePeriodSeconds *int64
+
+	// RateLimiter, if set, limits the rate of the requests of the Client, in addition to the QPS and Burst
+	// of the rest.Config.  Unlike those, its limits can be adjusted while the Client is in use.
+	RateLimiter *RateLimiter
 }
 
 // New returns a new Client using the provided config and Options.
@@ -69,11 +98,50 @@ func New(config *rest.Config, options Options) (Client, error) {
 		}
 	}
 
+	var defaultDeleteOpts []DeleteOptionFunc
+	switch options.DefaultPropagationPolicy {
+	case "":
+	case metav1.DeletePropagationOrphan, metav1.DeletePropagationBackground, metav1.DeletePropagationForeground:
+		defaultDeleteOpts = append(defaultDeleteOpts, PropagationPolicy(options.DefaultPropagationPolicy))
+	default:
+		return nil, fmt.Errorf("invalid DefaultPropagationPolicy %q, must be one of %q, %q or %q", options.DefaultPropagationPolicy,
+			metav1.DeletePropagationOrphan, metav1.DeletePropagationBackground, metav1.DeletePropagationForeground)
+	}
+	if options.DefaultGracePeriodSeconds != nil {
+		if *options.DefaultGracePeriodSeconds < 0 {
+			return nil, fmt.Errorf("invalid DefaultGracePeriodSeconds %d, must not be negative", *options.DefaultGracePeriodSeconds)
+		}
+		defaultDeleteOpts = append(defaultDeleteOpts, GracePeriodSeconds(*options.DefaultGracePeriodSeconds))
+	}
+
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
+	timeouts := make(map[schema.GroupVersionKind]time.Duration, len(options.Timeouts))
+	unstructuredTimeoutClients := make(map[schema.GroupVersionKind]*unstructuredClient, len(options.Timeouts))
+	for gvk, timeout := range options.Timeouts {
+		if len(gvk.Kind) == 0 || len(gvk.Version) == 0 {
+			return nil, fmt.Errorf("must specify the Version and Kind of the timeout for %v", gvk)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("timeout for %v must be positive, got %v", gvk, timeout)
+		}
+		timeouts[gvk] = timeout
+
+		// The dynamic client doesn't support contexts, so bound its requests with the config instead
+		timeoutConfig := rest.CopyConfig(config)
+		if timeoutConfig.Timeout == 0 || timeout < timeoutConfig.Timeout {
+			timeoutConfig.Timeout = timeout
+		}
+		timeoutDynamicClient, err := dynamic.NewForConfig(timeoutConfig)
+		if err != nil {
+			return nil, err
+		}
+		unstructuredTimeoutClients[gvk] = &unstructuredClient{client: timeoutDynamicClient, restMapper: options.Mapper}
+	}
+
 	c := &client{
 		typedClient: typedClient{
 			cache: clientCache{
@@ -83,14 +151,21 @@ func New(config *rest.Config, options Options) (Client, error) {
 				codecs:         serializer.NewCodecFactory(options.Scheme),
 				resourceByType: make(map[reflect.Type]*resourceMeta),
 			},
-			paramCodec: runtime.NewParameterCodec(options.Scheme),
+			paramCodec:           runtime.NewParameterCodec(options.Scheme),
+			unstructuredFallback: options.UnstructuredFallback,
 		},
 		unstructuredClient: unstructuredClient{
 			client:     dynamicClient,
 			restMapper: options.Mapper,
 		},
+		timeouts:                   timeouts,
+		unstructuredTimeoutClients: unstructuredTimeoutClients,
+		defaultDeleteOpts:          defaultDeleteOpts,
 	}
 
+	if options.RateLimiter != nil {
+		return WithRateLimiter(c, options.RateLimiter), nil
+	}
 	return c, nil
 }
 
@@ -101,58 +176,123 @@ var _ Client = &client{}
 type client struct {
 	typedClient        typedClient
 	unstructuredClient unstructuredClient
+
+	// timeouts are the request timeouts per GroupVersionKind
+	timeouts map[schema.GroupVersionKind]time.Duration
+
+	// unstructuredTimeoutClients are the unstructured clients of the GroupVersionKinds with a timeout
+	unstructuredTimeoutClients map[schema.GroupVersionKind]*unstructuredClient
+
+	// defaultDeleteOpts are applied to every Delete before its own options
+	defaultDeleteOpts []DeleteOptionFunc
+}
+
+// unstructuredClientFor returns the unstructured client to use for the unstructured object obj.
+func (c *client) unstructuredClientFor(obj runtime.Object) *unstructuredClient {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	if uc, found := c.unstructuredTimeoutClients[gvk]; found {
+		return uc
+	}
+	return &c.unstructuredClient
+}
+
+// withTimeout returns ctx with the timeout configured for the type of obj applied, if any.
+func (c *client) withTimeout(ctx context.Context, obj runtime.Object) (context.Context, context.CancelFunc) {
+	if _, isUnstructured := obj.(runtime.Unstructured); isUnstructured || len(c.timeouts) == 0 {
+		// Unstructured requests are bounded by unstructuredClientFor instead
+		return ctx, func() {}
+	}
+	gvk, err := apiutil.GVKForObject(obj, c.typedClient.cache.scheme)
+	if err != nil {
+		// Leave it to the request to report the error
+		return ctx, func() {}
+	}
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
+	timeout, found := c.timeouts[gvk]
+	if !found {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // Create implements client.Client
 func (c *client) Create(ctx context.Context, obj runtime.Object, opts ...CreateOptionFunc) error {
+	ctx, cancel := c.withTimeout(ctx, obj)
+	defer cancel()
+
 	_, ok := obj.(*unstructured.Unstructured)
 	if ok {
-		return c.unstructuredClient.Create(ctx, obj, opts...)
+		return c.unstructuredClientFor(obj).Create(ctx, obj, opts...)
 	}
 	return c.typedClient.Create(ctx, obj, opts...)
 }
 
 // Update implements client.Client
 func (c *client) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	ctx, cancel := c.withTimeout(ctx, obj)
+	defer cancel()
+
 	_, ok := obj.(*unstructured.Unstructured)
 	if ok {
-		return c.unstructuredClient.Update(ctx, obj, opts...)
+		return c.unstructuredClientFor(obj).Update(ctx, obj, opts...)
 	}
 	return c.typedClient.Update(ctx, obj, opts...)
 }
 
 // Delete implements client.Client
 func (c *client) Delete(ctx context.Context, obj runtime.Object, opts ...DeleteOptionFunc) error {
+	ctx, cancel := c.withTimeout(ctx, obj)
+	defer cancel()
+
+	if len(c.defaultDeleteOpts) > 0 {
+		// The options of the call are applied last, so they override the defaults
+		opts = append(append([]DeleteOptionFunc(nil), c.defaultDeleteOpts...), opts...)
+	}
+
 	_, ok := obj.(*unstructured.Unstructured)
 	if ok {
-		return c.unstructuredClient.Delete(ctx, obj, opts...)
+		return c.unstructuredClientFor(obj).Delete(ctx, obj, opts...)
 	}
 	return c.typedClient.Delete(ctx, obj, opts...)
 }
 
 // Patch implements client.Client
 func (c *client) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	ctx, cancel := c.withTimeout(ctx, obj)
+	defer cancel()
+
 	_, ok := obj.(*unstructured.Unstructured)
 	if ok {
-		return c.unstructuredClient.Patch(ctx, obj, patch, opts...)
+		return c.unstructuredClientFor(obj).Patch(ctx, obj, patch, opts...)
 	}
 	return c.typedClient.Patch(ctx, obj, patch, opts...)
 }
 
 // Get implements client.Client
 func (c *client) Get(ctx context.Context, key ObjectKey, obj runtime.Object) error {
+	ctx, cancel := c.withTimeout(ctx, obj)
+	defer cancel()
+
 	_, ok := obj.(*unstructured.Unstructured)
 	if ok {
-		return c.unstructuredClient.Get(ctx, key, obj)
+		return c.unstructuredClientFor(obj).Get(ctx, key, obj)
 	}
 	return c.typedClient.Get(ctx, key, obj)
 }
 
 // List implements client.Client
 func (c *client) List(ctx context.Context, obj runtime.Object, opts ...ListOptionFunc) error {
+	ctx, cancel := c.withTimeout(ctx, obj)
+	defer cancel()
+
 	_, ok := obj.(*unstructured.UnstructuredList)
 	if ok {
-		return c.unstructuredClient.List(ctx, obj, opts...)
+		return c.unstructuredClientFor(obj).List(ctx, obj, opts...)
 	}
 	return c.typedClient.List(ctx, obj, opts...)
 }
@@ -172,18 +312,24 @@ var _ StatusWriter = &statusWriter{}
 
 // Update implements client.StatusWriter
 func (sw *statusWriter) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	ctx, cancel := sw.client.withTimeout(ctx, obj)
+	defer cancel()
+
 	_, ok := obj.(*unstructured.Unstructured)
 	if ok {
-		return sw.client.unstructuredClient.UpdateStatus(ctx, obj, opts...)
+		return sw.client.unstructuredClientFor(obj).UpdateStatus(ctx, obj, opts...)
 	}
 	return sw.client.typedClient.UpdateStatus(ctx, obj, opts...)
 }
 
 // Patch implements client.Client
 func (sw *statusWriter) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	ctx, cancel := sw.client.withTimeout(ctx, obj)
+	defer cancel()
+
 	_, ok := obj.(*unstructured.Unstructured)
 	if ok {
-		return sw.client.unstructuredClient.PatchStatus(ctx, obj, patch, opts...)
+		return sw.client.unstructuredClientFor(obj).PatchStatus(ctx, obj, patch, opts...)
 	}
 	return sw.client.typedClient.PatchStatus(ctx, obj, patch, opts...)
 }
@@ -20,7 +20,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sync/atomic"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
 
@@ -29,10 +32,12 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kscheme "k8s.io/client-go/kubernetes/scheme"
@@ -172,6 +177,148 @@ var _ = Describe("Client", func() {
 
 			close(done)
 		})
+
+		It("should fail if a timeout is not positive", func(done Done) {
+			cl, err := client.New(cfg, client.Options{Timeouts: map[schema.GroupVersionKind]time.Duration{
+				corev1.SchemeGroupVersion.WithKind("Pod"): 0,
+			}})
+			Expect(err).To(HaveOccurred())
+			Expect(cl).To(BeNil())
+
+			close(done)
+		})
+
+		It("should fail if a timeout doesn't specify a Kind", func(done Done) {
+			cl, err := client.New(cfg, client.Options{Timeouts: map[schema.GroupVersionKind]time.Duration{
+				corev1.SchemeGroupVersion.WithKind(""): time.Second,
+			}})
+			Expect(err).To(HaveOccurred())
+			Expect(cl).To(BeNil())
+
+			close(done)
+		})
+	})
+
+	Describe("Timeouts", func() {
+		var server *httptest.Server
+		var cl client.Client
+
+		BeforeEach(func() {
+			By("serving every request slowly")
+			server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				select {
+				case <-req.Context().Done():
+					return
+				case <-time.After(200 * time.Millisecond):
+				}
+				resp.Header().Set("Content-Type", "application/json")
+				resp.Write([]byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`)) // nolint: errcheck
+			}))
+
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), meta.RESTScopeNamespace)
+			mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+			var err error
+			cl, err = client.New(&rest.Config{Host: server.URL}, client.Options{
+				Mapper: mapper,
+				Timeouts: map[schema.GroupVersionKind]time.Duration{
+					corev1.SchemeGroupVersion.WithKind("Pod"): 20 * time.Millisecond,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should time out requests for the types with a timeout", func() {
+			err := cl.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: "test"}, &corev1.Pod{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should time out lists of the types with a timeout", func() {
+			err := cl.List(context.TODO(), &corev1.PodList{}, client.InNamespace(ns))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should time out requests for unstructured objects of the types with a timeout", func() {
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Pod"))
+			err := cl.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: "test"}, u)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not time out requests for other types", func() {
+			cm := &corev1.ConfigMap{}
+			Expect(cl.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: "test"}, cm)).To(Succeed())
+			Expect(cm.Name).To(Equal("test"))
+		})
+	})
+
+	Describe("DefaultPropagationPolicy and DefaultGracePeriodSeconds", func() {
+		var server *httptest.Server
+		var deleteOpts chan metav1.DeleteOptions
+		var mapper meta.RESTMapper
+
+		BeforeEach(func() {
+			By("recording the options of every delete")
+			deleteOpts = make(chan metav1.DeleteOptions, 1)
+			server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				defer GinkgoRecover()
+				opts := metav1.DeleteOptions{}
+				Expect(json.NewDecoder(req.Body).Decode(&opts)).To(Succeed())
+				deleteOpts <- opts
+				resp.Header().Set("Content-Type", "application/json")
+				resp.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Success"}`)) // nolint: errcheck
+			}))
+
+			restMapper := meta.NewDefaultRESTMapper(nil)
+			restMapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+			mapper = restMapper
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		newClient := func() client.Client {
+			grace := int64(5)
+			cl, err := client.New(&rest.Config{Host: server.URL}, client.Options{
+				Mapper:                    mapper,
+				DefaultPropagationPolicy:  metav1.DeletePropagationForeground,
+				DefaultGracePeriodSeconds: &grace,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return cl
+		}
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "test"}}
+
+		It("should apply the defaults to Deletes", func() {
+			Expect(newClient().Delete(context.TODO(), configMap)).To(Succeed())
+			var opts metav1.DeleteOptions
+			Eventually(deleteOpts).Should(Receive(&opts))
+			Expect(*opts.PropagationPolicy).To(Equal(metav1.DeletePropagationForeground))
+			Expect(*opts.GracePeriodSeconds).To(Equal(int64(5)))
+		})
+
+		It("should let the options of a Delete override the defaults", func() {
+			Expect(newClient().Delete(context.TODO(), configMap, client.PropagationPolicy(metav1.DeletePropagationOrphan))).To(Succeed())
+			var opts metav1.DeleteOptions
+			Eventually(deleteOpts).Should(Receive(&opts))
+			Expect(*opts.PropagationPolicy).To(Equal(metav1.DeletePropagationOrphan))
+			Expect(*opts.GracePeriodSeconds).To(Equal(int64(5)))
+		})
+
+		It("should refuse invalid defaults", func() {
+			_, err := client.New(&rest.Config{Host: server.URL}, client.Options{Mapper: mapper, DefaultPropagationPolicy: "Sideways"})
+			Expect(err).To(MatchError(ContainSubstring("invalid DefaultPropagationPolicy")))
+
+			negative := int64(-1)
+			_, err = client.New(&rest.Config{Host: server.URL}, client.Options{Mapper: mapper, DefaultGracePeriodSeconds: &negative})
+			Expect(err).To(MatchError(ContainSubstring("invalid DefaultGracePeriodSeconds")))
+		})
 	})
 
 	Describe("Create", func() {
@@ -262,6 +409,9 @@ var _ = Describe("Client", func() {
 				err = cl.Create(context.TODO(), dep)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("no kind is registered for the type"))
+				Expect(err).To(BeAssignableToTypeOf(&client.ErrUnregisteredType{}))
+				Expect(runtime.IsNotRegisteredError(err.(*client.ErrUnregisteredType).Unwrap())).To(BeTrue())
+				Expect(err.(*client.ErrUnregisteredType).Cause()).To(BeIdenticalTo(err.(*client.ErrUnregisteredType).Unwrap()))
 			})
 
 			PIt("should fail if the GVK cannot be mapped to a Resource", func() {
@@ -2129,6 +2279,18 @@ var _ = Describe("Client", func() {
 			Expect(lo.Namespace).To(Equal("test"))
 		})
 
+		It("should be created from Limit and Continue", func() {
+			lo := &client.ListOptions{}
+			client.Limit(10)(lo)
+			client.Continue("token")(lo)
+			Expect(lo.Limit).To(Equal(int64(10)))
+			Expect(lo.Continue).To(Equal("token"))
+
+			mlo := lo.AsListOptions()
+			Expect(mlo.Limit).To(Equal(int64(10)))
+			Expect(mlo.Continue).To(Equal("token"))
+		})
+
 		It("should allow pre-built ListOptions", func() {
 			lo := &client.ListOptions{}
 			newLo := &client.ListOptions{}
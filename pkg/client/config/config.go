@@ -25,6 +25,7 @@ import (
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
 )
 
@@ -74,6 +75,33 @@ func GetConfig() (*rest.Config, error) {
 	return cfg, nil
 }
 
+// GetConfigWithContext creates a *rest.Config for talking to the Kubernetes API server of the context with
+// the given name of the kubeconfig, e.g. to talk to a second cluster next to the one of GetConfig.
+// The kubeconfig is located by the --kubeconfig flag, then the KUBECONFIG environment variable, then
+// $HOME/.kube/config.  There is no in-cluster fallback, since the in-cluster config has no contexts.
+//
+// It applies the same defaults for QPS and burst as GetConfig.
+func GetConfigWithContext(context string) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(kubeconfig) > 0 {
+		loadingRules.ExplicitPath = kubeconfig
+	}
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{
+		ClusterInfo:    clientcmdapi.Cluster{Server: apiServerURL},
+		CurrentContext: context,
+	}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.QPS == 0.0 {
+		cfg.QPS = 20.0
+		cfg.Burst = 30.0
+	}
+
+	return cfg, nil
+}
+
 // loadConfig loads a REST Config as per the rules specified in GetConfig
 func loadConfig() (*rest.Config, error) {
 	// If a flag is specified with the config location, use that
@@ -0,0 +1,296 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConversionError is returned when an object read from the API server can't be decoded into, or converted
+// to, the requested Go type, e.g. because the stored version of a CRD has drifted from the Go type.
+type ConversionError struct {
+	// GroupVersionKind is the kind of the object which failed to convert, as served by the API server.
+	GroupVersionKind schema.GroupVersionKind
+
+	// Key is the key of the object which failed to convert.  It is empty for lists.
+	Key ObjectKey
+
+	// Target is the Go type the object failed to convert to.
+	Target reflect.Type
+
+	// Field is the path of the field which failed to convert, e.g. spec.replicas, if it is known.
+	Field string
+
+	// Object is the object as served by the API server, as an *unstructured.Unstructured or an
+	// *unstructured.UnstructuredList.  It is only set by clients falling back to unstructured objects on
+	// conversion errors, and may be nil if the object couldn't be read as unstructured either.
+	Object runtime.Object
+
+	// Err is the error of the conversion.
+	Err error
+}
+
+// Error implements error
+func (e *ConversionError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "unable to convert %v", e.GroupVersionKind)
+	if len(e.Key.Name) > 0 {
+		fmt.Fprintf(&msg, " %v", e.Key)
+	}
+	fmt.Fprintf(&msg, " to %v", e.Target)
+	if len(e.Field) > 0 {
+		fmt.Fprintf(&msg, " at field %s", e.Field)
+	}
+	fmt.Fprintf(&msg, ": %v", e.Err)
+	return msg.String()
+}
+
+// ConvertingClient wraps a Client so that reconcilers can work with internal types, which aren't served by
+// the API server.  Objects of a registered internal type are converted to their external type before they
+// are sent to the server, and the response is converted back into the internal object.  Objects of any
+// other type are passed to the wrapped Client unchanged.
+//
+// Objects are converted with ConvertTo and ConvertFrom if the internal type is a conversion.Hub and the
+// external type is a conversion.Convertible, as for the conversion webhook.  Otherwise they are converted
+// by the Scheme, so custom conversion functions can be added with Scheme.AddConversionFunc.
+//
+// Lists and patches of internal types aren't supported.
+type ConvertingClient struct {
+	Client
+
+	// UnstructuredFallback, if true, sets the Object of the ConversionErrors returned when an object which
+	// was read can't be converted to its internal type to the object as read, so callers can still use it.
+	UnstructuredFallback bool
+
+	scheme *runtime.Scheme
+
+	// externals maps internal types to the external types they are converted to.
+	externals map[reflect.Type]reflect.Type
+}
+
+var _ Client = &ConvertingClient{}
+
+// NewConvertingClient returns a ConvertingClient wrapping c, which converts objects with scheme.
+// Register the internal types with Register.
+func NewConvertingClient(c Client, scheme *runtime.Scheme) *ConvertingClient {
+	return &ConvertingClient{Client: c, scheme: scheme, externals: map[reflect.Type]reflect.Type{}}
+}
+
+// Register makes the client convert objects of the type of internal to and from the type of external,
+// e.g. Register(&MyInternalType{}, &v1.MyType{}).  external must be registered with the Scheme.
+func (c *ConvertingClient) Register(internal, external runtime.Object) error {
+	if _, _, err := c.scheme.ObjectKinds(external); err != nil {
+		return err
+	}
+	internalType, externalType := reflect.TypeOf(internal), reflect.TypeOf(external)
+	if internalType.Kind() != reflect.Ptr || externalType.Kind() != reflect.Ptr {
+		return fmt.Errorf("internal type %T and external type %T must be pointers", internal, external)
+	}
+	c.externals[internalType] = externalType
+	return nil
+}
+
+// Get implements client.Client
+func (c *ConvertingClient) Get(ctx context.Context, key ObjectKey, obj runtime.Object) error {
+	external, isInternal := c.newExternal(obj)
+	if !isInternal {
+		return c.Client.Get(ctx, key, obj)
+	}
+	if err := c.Client.Get(ctx, key, external); err != nil {
+		return err
+	}
+	if err := c.convert(external, obj); err != nil {
+		return c.conversionError(external, obj, err)
+	}
+	return nil
+}
+
+// Create implements client.Client
+func (c *ConvertingClient) Create(ctx context.Context, obj runtime.Object, opts ...CreateOptionFunc) error {
+	return c.write(obj, func(external runtime.Object) error {
+		return c.Client.Create(ctx, external, opts...)
+	})
+}
+
+// Delete implements client.Client
+func (c *ConvertingClient) Delete(ctx context.Context, obj runtime.Object, opts ...DeleteOptionFunc) error {
+	external, isInternal := c.newExternal(obj)
+	if !isInternal {
+		return c.Client.Delete(ctx, obj, opts...)
+	}
+	if err := c.convert(obj, external); err != nil {
+		return fmt.Errorf("unable to convert %T to %T: %v", obj, external, err)
+	}
+	return c.Client.Delete(ctx, external, opts...)
+}
+
+// Update implements client.Client
+func (c *ConvertingClient) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	return c.write(obj, func(external runtime.Object) error {
+		return c.Client.Update(ctx, external, opts...)
+	})
+}
+
+// Patch implements client.Client
+func (c *ConvertingClient) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	if _, isInternal := c.newExternal(obj); isInternal {
+		return fmt.Errorf("objects of internal type %T can't be patched, use Update instead", obj)
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Status implements client.StatusClient
+func (c *ConvertingClient) Status() StatusWriter {
+	return &convertingStatusWriter{client: c, StatusWriter: c.Client.Status()}
+}
+
+// convertingStatusWriter converts objects of internal types like its ConvertingClient.
+type convertingStatusWriter struct {
+	client *ConvertingClient
+	StatusWriter
+}
+
+// Update implements client.StatusWriter
+func (sw *convertingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	return sw.client.write(obj, func(external runtime.Object) error {
+		return sw.StatusWriter.Update(ctx, external, opts...)
+	})
+}
+
+// Patch implements client.StatusWriter
+func (sw *convertingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	if _, isInternal := sw.client.newExternal(obj); isInternal {
+		return fmt.Errorf("objects of internal type %T can't be patched, use Update instead", obj)
+	}
+	return sw.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// write converts obj to its external type if it is an internal object, passes it to the given write, and
+// converts the server response back into obj.
+func (c *ConvertingClient) write(obj runtime.Object, write func(runtime.Object) error) error {
+	external, isInternal := c.newExternal(obj)
+	if !isInternal {
+		return write(obj)
+	}
+	if err := c.convert(obj, external); err != nil {
+		return fmt.Errorf("unable to convert %T to %T: %v", obj, external, err)
+	}
+	if err := write(external); err != nil {
+		return err
+	}
+	if err := c.convert(external, obj); err != nil {
+		return c.conversionError(external, obj, err)
+	}
+	return nil
+}
+
+// conversionError returns the ConversionError of the object in, as read from the server, which failed to
+// convert to out.
+func (c *ConvertingClient) conversionError(in, out runtime.Object, err error) error {
+	convErr := &ConversionError{Target: reflect.TypeOf(out), Err: err}
+	if gvk, gvkErr := apiutil.GVKForObject(in, c.scheme); gvkErr == nil {
+		convErr.GroupVersionKind = gvk
+	}
+	if m, metaErr := meta.Accessor(in); metaErr == nil {
+		convErr.Key = ObjectKey{Namespace: m.GetNamespace(), Name: m.GetName()}
+	}
+	if c.UnstructuredFallback {
+		if content, unstructuredErr := runtime.DefaultUnstructuredConverter.ToUnstructured(in); unstructuredErr == nil {
+			u := &unstructured.Unstructured{Object: content}
+			u.SetGroupVersionKind(convErr.GroupVersionKind)
+			convErr.Object = u
+		}
+	}
+	return convErr
+}
+
+// newExternal returns a new object of the external type of obj, and false if obj isn't of an internal type.
+func (c *ConvertingClient) newExternal(obj runtime.Object) (runtime.Object, bool) {
+	externalType, isInternal := c.externals[reflect.TypeOf(obj)]
+	if !isInternal {
+		return nil, false
+	}
+	return reflect.New(externalType.Elem()).Interface().(runtime.Object), true
+}
+
+// convert converts in into out, through conversion.Convertible if possible, or else the Scheme.
+func (c *ConvertingClient) convert(in, out runtime.Object) error {
+	if hub, isHub := out.(conversion.Hub); isHub {
+		if convertible, isConvertible := in.(conversion.Convertible); isConvertible {
+			return convertible.ConvertTo(hub)
+		}
+	}
+	if hub, isHub := in.(conversion.Hub); isHub {
+		if convertible, isConvertible := out.(conversion.Convertible); isConvertible {
+			return convertible.ConvertFrom(hub)
+		}
+	}
+	return c.scheme.Convert(in, out, nil)
+}
+
+// decodeErrorField matches the descriptions of the struct fields in the errors of the JSON decoder, e.g.
+// "v1.DeploymentSpec.Replicas".
+var decodeErrorField = regexp.MustCompile(`^\*?\w+\.\w+\.(\w+)$`)
+
+// failingField returns the JSON path of the field of target which failed to decode with err, e.g.
+// "spec.template.spec.containers[].image", or "" if err doesn't tell.
+//
+// The JSON decoder of the Scheme describes the failing field by the chain of the Go struct fields leading
+// to it, such as "v1.Deployment.Spec: v1.DeploymentSpec.Replicas: readUint32: ...", which is mapped back to
+// the JSON names of the fields of target.
+func failingField(err error, target reflect.Type) string {
+	var path []string
+	t := target
+	for _, segment := range strings.Split(err.Error(), ": ") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if strings.HasPrefix(segment, "[]") && t.Kind() == reflect.Slice {
+			if len(path) > 0 {
+				path[len(path)-1] += "[]"
+			}
+			t = t.Elem()
+			continue
+		}
+		match := decodeErrorField.FindStringSubmatch(segment)
+		if match == nil || t.Kind() != reflect.Struct {
+			break
+		}
+		field, found := t.FieldByName(match[1])
+		if !found {
+			break
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if len(name) == 0 {
+			name = field.Name
+		}
+		path = append(path, name)
+		t = field.Type
+	}
+	return strings.Join(path, ".")
+}
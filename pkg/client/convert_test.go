@@ -0,0 +1,205 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// greeting is an internal type, stored as a ConfigMap.
+type greeting struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+	Message string
+}
+
+func (g *greeting) DeepCopyObject() runtime.Object {
+	out := *g
+	g.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+var _ = Describe("ConvertingClient", func() {
+	var cl *client.ConvertingClient
+	var backing client.Client
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		s := runtime.NewScheme()
+		Expect(scheme.AddToScheme(s)).To(Succeed())
+		Expect(s.AddConversionFunc((*greeting)(nil), (*corev1.ConfigMap)(nil), func(a, b interface{}, _ conversion.Scope) error {
+			in, out := a.(*greeting), b.(*corev1.ConfigMap)
+			in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+			out.Data = map[string]string{"message": in.Message}
+			return nil
+		})).To(Succeed())
+		Expect(s.AddConversionFunc((*corev1.ConfigMap)(nil), (*greeting)(nil), func(a, b interface{}, _ conversion.Scope) error {
+			in, out := a.(*corev1.ConfigMap), b.(*greeting)
+			in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+			if in.Data["message"] == "" {
+				return fmt.Errorf("no message")
+			}
+			out.Message = in.Data["message"]
+			return nil
+		})).To(Succeed())
+
+		backing = fake.NewFakeClientWithScheme(s)
+		cl = client.NewConvertingClient(backing, s)
+		Expect(cl.Register(&greeting{}, &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should write objects of internal types as their external type", func() {
+		g := &greeting{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"}, Message: "hello world"}
+		Expect(cl.Create(ctx, g)).To(Succeed())
+
+		cm := &corev1.ConfigMap{}
+		Expect(backing.Get(ctx, client.ObjectKey{Namespace: "default", Name: "hello"}, cm)).To(Succeed())
+		Expect(cm.Data).To(Equal(map[string]string{"message": "hello world"}))
+
+		g.Message = "goodbye"
+		Expect(cl.Update(ctx, g)).To(Succeed())
+		Expect(backing.Get(ctx, client.ObjectKey{Namespace: "default", Name: "hello"}, cm)).To(Succeed())
+		Expect(cm.Data).To(Equal(map[string]string{"message": "goodbye"}))
+
+		Expect(cl.Delete(ctx, g)).To(Succeed())
+		Expect(backing.Get(ctx, client.ObjectKey{Namespace: "default", Name: "hello"}, cm)).NotTo(Succeed())
+	})
+
+	It("should read objects of internal types from their external type", func() {
+		Expect(backing.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+			Data:       map[string]string{"message": "hello world"},
+		})).To(Succeed())
+
+		g := &greeting{}
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "hello"}, g)).To(Succeed())
+		Expect(g.Name).To(Equal("hello"))
+		Expect(g.Message).To(Equal("hello world"))
+	})
+
+	It("should report which object failed to convert", func() {
+		Expect(backing.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"}})).To(Succeed())
+		cl.UnstructuredFallback = true
+
+		err := cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "empty"}, &greeting{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(`unable to convert /v1, Kind=ConfigMap default/empty to *client_test.greeting: no message`))
+		convErr, ok := err.(*client.ConversionError)
+		Expect(ok).To(BeTrue())
+		u, ok := convErr.Object.(*unstructured.Unstructured)
+		Expect(ok).To(BeTrue())
+		Expect(u.GetKind()).To(Equal("ConfigMap"))
+		Expect(u.GetName()).To(Equal("empty"))
+	})
+
+	It("should pass objects of other types through unchanged", func() {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"}}
+		Expect(cl.Create(ctx, cm)).To(Succeed())
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: "default", Name: "plain"}, &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should refuse to patch objects of internal types", func() {
+		g := &greeting{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"}}
+		err := cl.Patch(ctx, g, client.MergeFrom(g.DeepCopyObject()))
+		Expect(err).To(MatchError(ContainSubstring("can't be patched")))
+	})
+
+	It("should refuse to register external types which aren't in the Scheme", func() {
+		Expect(cl.Register(&greeting{}, &greeting{})).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("ConversionError", func() {
+	var server *httptest.Server
+	var mapper meta.RESTMapper
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/apis/apps/v1/namespaces/default/deployments/drifted":
+				fmt.Fprint(w, `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"drifted","namespace":"default"},`+
+					`"spec":{"template":{"spec":{"containers":[{"name":"app","ports":[{"containerPort":"http"}]}]}}}}`)
+			case "/apis/apps/v1/namespaces/default/deployments/missing":
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{appsv1.SchemeGroupVersion})
+		restMapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+		mapper = restMapper
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should tell which object and field failed to decode", func() {
+		cl, err := client.New(&rest.Config{Host: server.URL}, client.Options{Mapper: mapper})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = cl.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "drifted"}, &appsv1.Deployment{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("unable to convert apps/v1, Kind=Deployment default/drifted to *v1.Deployment " +
+			"at field spec.template.spec.containers[].ports[].containerPort: "))
+		convErr, ok := err.(*client.ConversionError)
+		Expect(ok).To(BeTrue())
+		Expect(convErr.Object).To(BeNil())
+	})
+
+	It("should return the object as unstructured with UnstructuredFallback", func() {
+		cl, err := client.New(&rest.Config{Host: server.URL}, client.Options{Mapper: mapper, UnstructuredFallback: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = cl.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "drifted"}, &appsv1.Deployment{})
+		convErr, ok := err.(*client.ConversionError)
+		Expect(ok).To(BeTrue())
+		u, ok := convErr.Object.(*unstructured.Unstructured)
+		Expect(ok).To(BeTrue())
+		Expect(u.GetName()).To(Equal("drifted"))
+	})
+
+	It("should not wrap errors of the request", func() {
+		cl, err := client.New(&rest.Config{Host: server.URL}, client.Options{Mapper: mapper})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = cl.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "missing"}, &appsv1.Deployment{})
+		Expect(err).To(HaveOccurred())
+		_, isConversionError := err.(*client.ConversionError)
+		Expect(isConversionError).To(BeFalse())
+	})
+})
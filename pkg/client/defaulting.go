@@ -0,0 +1,65 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// WithDefaulting returns a Client which applies the defaulting functions registered with scheme to the
+// objects read through c, e.g. those registered by the generated SetObjectDefaults functions of a CRD's Go
+// types.  The API server doesn't apply them to custom resources, so without it Reconcilers see the fields
+// they would default unset, unlike in the objects they create with the same scheme.
+//
+// Only Get and List default the objects: the objects returned by writes are left as the API server returned
+// them.  Unstructured objects aren't defaulted, since defaulting functions are registered per Go type.
+func WithDefaulting(c Client, scheme *runtime.Scheme) Client {
+	return &defaultingClient{Client: c, scheme: scheme}
+}
+
+// defaultingClient implements the Client returned by WithDefaulting.
+type defaultingClient struct {
+	Client
+
+	scheme *runtime.Scheme
+}
+
+// Get implements Reader
+func (c *defaultingClient) Get(ctx context.Context, key ObjectKey, obj runtime.Object) error {
+	if err := c.Client.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	c.scheme.Default(obj)
+	return nil
+}
+
+// List implements Reader
+func (c *defaultingClient) List(ctx context.Context, list runtime.Object, opts ...ListOptionFunc) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	// Defaulting functions are idempotent, so it doesn't matter whether the list type has its own, which
+	// defaults the items as well.
+	c.scheme.Default(list)
+	return meta.EachListItem(list, func(obj runtime.Object) error {
+		c.scheme.Default(obj)
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("WithDefaulting", func() {
+	var defaulting client.Client
+	var ctx = context.TODO()
+
+	BeforeEach(func() {
+		s := runtime.NewScheme()
+		Expect(corev1.AddToScheme(s)).To(Succeed())
+		s.AddTypeDefaultingFunc(&corev1.ConfigMap{}, func(obj interface{}) {
+			cm := obj.(*corev1.ConfigMap)
+			if cm.Data == nil {
+				cm.Data = map[string]string{"defaulted": "true"}
+			}
+		})
+		defaulting = client.WithDefaulting(fake.NewFakeClientWithScheme(s,
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unset"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "set"}, Data: map[string]string{"set": "true"}},
+		), s)
+	})
+
+	It("should default the objects it gets", func() {
+		cm := &corev1.ConfigMap{}
+		Expect(defaulting.Get(ctx, client.ObjectKey{Namespace: "default", Name: "unset"}, cm)).To(Succeed())
+		Expect(cm.Data).To(Equal(map[string]string{"defaulted": "true"}))
+
+		By("leaving the fields which are set alone")
+		cm = &corev1.ConfigMap{}
+		Expect(defaulting.Get(ctx, client.ObjectKey{Namespace: "default", Name: "set"}, cm)).To(Succeed())
+		Expect(cm.Data).To(Equal(map[string]string{"set": "true"}))
+	})
+
+	It("should default the items of the lists it lists", func() {
+		list := &corev1.ConfigMapList{}
+		Expect(defaulting.List(ctx, list, client.InNamespace("default"))).To(Succeed())
+		data := map[string]map[string]string{}
+		for _, cm := range list.Items {
+			data[cm.Name] = cm.Data
+		}
+		Expect(data).To(Equal(map[string]map[string]string{
+			"unset": {"defaulted": "true"},
+			"set":   {"set": "true"},
+		}))
+	})
+})
@@ -0,0 +1,253 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+const (
+	// maxPrettyDiffLength is the length after which PrettyDiff stops listing changes.
+	maxPrettyDiffLength = 1024
+
+	// maxPrettyDiffValueLength is the length after which PrettyDiff truncates values.
+	maxPrettyDiffValueLength = 64
+
+	// defaultMergeKey is the merge key assumed for lists of unstructured objects.
+	defaultMergeKey = "name"
+)
+
+// prettyDiffIgnoredFields are the fields which change on every update, and are left out of PrettyDiff.
+var prettyDiffIgnoredFields = []string{"resourceVersion", "managedFields"}
+
+// PrettyDiff returns a compact, human-readable description of the changes from oldObj to newObj, e.g. to log
+// what a reconcile changed.  Changes are listed by field path, as in
+//
+//	metadata.labels.app: <none> -> "web"; spec.template.spec.containers[name=nginx].image: "nginx:1.14" -> "nginx:1.15"
+//
+// Elements of lists of objects are identified by their merge key, as used by strategic merge patches, or by
+// "name" for unstructured objects if all the elements have a distinct one.  Other lists are shown whole if
+// they changed.  metadata.resourceVersion and metadata.managedFields are ignored.  Long values are truncated,
+// and so is the list of changes.  PrettyDiff returns an empty string if the objects don't differ.
+func PrettyDiff(oldObj, newObj runtime.Object) string {
+	oldContent, err := prettyDiffContent(oldObj)
+	if err != nil {
+		return fmt.Sprintf("<unable to diff: %v>", err)
+	}
+	newContent, err := prettyDiffContent(newObj)
+	if err != nil {
+		return fmt.Sprintf("<unable to diff: %v>", err)
+	}
+
+	var schema strategicpatch.LookupPatchMeta
+	if _, isUnstructured := newObj.(runtime.Unstructured); !isUnstructured {
+		if s, err := strategicpatch.NewPatchMetaFromStruct(newObj); err == nil {
+			schema = s
+		}
+	}
+
+	d := &differ{}
+	d.diffMaps("", oldContent, newContent, schema)
+
+	var out strings.Builder
+	for i, change := range d.changes {
+		if out.Len()+len(change) > maxPrettyDiffLength {
+			fmt.Fprintf(&out, "; ... %d more changes", len(d.changes)-i)
+			break
+		}
+		if i > 0 {
+			out.WriteString("; ")
+		}
+		out.WriteString(change)
+	}
+	return out.String()
+}
+
+// prettyDiffContent returns the unstructured content of obj, without the ignored fields.
+func prettyDiffContent(obj runtime.Object) (map[string]interface{}, error) {
+	var content map[string]interface{}
+	if u, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		content = runtime.DeepCopyJSON(u.UnstructuredContent())
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		for _, field := range prettyDiffIgnoredFields {
+			delete(metadata, field)
+		}
+	}
+	return content, nil
+}
+
+// noValue stands for a field which isn't set.
+type noValue struct{}
+
+// differ collects the changes between two unstructured objects.
+type differ struct {
+	changes []string
+}
+
+func (d *differ) diffMaps(path string, oldMap, newMap map[string]interface{}, schema strategicpatch.LookupPatchMeta) {
+	keys := make([]string, 0, len(oldMap)+len(newMap))
+	for key := range oldMap {
+		keys = append(keys, key)
+	}
+	for key := range newMap {
+		if _, found := oldMap[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var oldValue, newValue interface{} = noValue{}, noValue{}
+		if v, found := oldMap[key]; found {
+			oldValue = v
+		}
+		if v, found := newMap[key]; found {
+			newValue = v
+		}
+
+		var subSchema strategicpatch.LookupPatchMeta
+		mergeKey := ""
+		if schema != nil {
+			if _, isSlice := newValue.([]interface{}); isSlice {
+				if s, patchMeta, err := schema.LookupPatchMetadataForSlice(key); err == nil {
+					subSchema, mergeKey = s, patchMeta.GetPatchMergeKey()
+				}
+			} else if s, _, err := schema.LookupPatchMetadataForStruct(key); err == nil {
+				subSchema = s
+			}
+		}
+		d.diff(fieldPath(path, key), oldValue, newValue, subSchema, mergeKey)
+	}
+}
+
+func (d *differ) diff(path string, oldValue, newValue interface{}, schema strategicpatch.LookupPatchMeta, mergeKey string) {
+	if reflect.DeepEqual(oldValue, newValue) {
+		return
+	}
+
+	switch newTyped := newValue.(type) {
+	case map[string]interface{}:
+		if oldTyped, ok := oldValue.(map[string]interface{}); ok {
+			d.diffMaps(path, oldTyped, newTyped, schema)
+			return
+		}
+	case []interface{}:
+		if oldTyped, ok := oldValue.([]interface{}); ok {
+			if schema == nil && mergeKey == "" {
+				mergeKey = defaultMergeKey
+			}
+			if mergeKey != "" && hasDistinctKeys(oldTyped, mergeKey) && hasDistinctKeys(newTyped, mergeKey) {
+				d.diffMergedLists(path, oldTyped, newTyped, schema, mergeKey)
+				return
+			}
+		}
+	}
+
+	d.changes = append(d.changes, fmt.Sprintf("%s: %s -> %s", path, prettyValue(oldValue), prettyValue(newValue)))
+}
+
+// diffMergedLists diffs the elements of two lists by their mergeKey, which must be distinct.
+func (d *differ) diffMergedLists(path string, oldList, newList []interface{}, schema strategicpatch.LookupPatchMeta, mergeKey string) {
+	oldElems := map[interface{}]interface{}{}
+	keys := make([]interface{}, 0, len(oldList)+len(newList))
+	for _, elem := range oldList {
+		key := elem.(map[string]interface{})[mergeKey]
+		oldElems[key] = elem
+		keys = append(keys, key)
+	}
+	newElems := map[interface{}]interface{}{}
+	for _, elem := range newList {
+		key := elem.(map[string]interface{})[mergeKey]
+		newElems[key] = elem
+		if _, found := oldElems[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		var oldElem, newElem interface{} = noValue{}, noValue{}
+		if e, found := oldElems[key]; found {
+			oldElem = e
+		}
+		if e, found := newElems[key]; found {
+			newElem = e
+		}
+		d.diff(fmt.Sprintf("%s[%s=%v]", path, mergeKey, key), oldElem, newElem, schema, "")
+	}
+}
+
+// hasDistinctKeys returns true if list isn't empty, and all its elements are objects with a distinct
+// scalar value for key.
+func hasDistinctKeys(list []interface{}, key string) bool {
+	seen := map[interface{}]bool{}
+	for _, elem := range list {
+		m, ok := elem.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		value := m[key]
+		switch value.(type) {
+		case string, int64, float64, bool:
+		default:
+			return false
+		}
+		if seen[value] {
+			return false
+		}
+		seen[value] = true
+	}
+	return len(list) > 0
+}
+
+// fieldPath appends key to path, quoting it if it isn't a plain identifier (e.g. a label key).
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./[]=\" ") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// prettyValue formats a value as compact JSON, truncated if too long.
+func prettyValue(value interface{}) string {
+	if _, isNoValue := value.(noValue); isNoValue {
+		return "<none>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	if len(data) > maxPrettyDiffValueLength {
+		return string(data[:maxPrettyDiffValueLength]) + "..."
+	}
+	return string(data)
+}
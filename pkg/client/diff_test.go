@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("PrettyDiff", func() {
+	var dep *appsv1.Deployment
+
+	BeforeEach(func() {
+		replicas := int32(1)
+		dep = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "1"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{
+						{Name: "nginx", Image: "nginx:1.14", Args: []string{"-a"}},
+						{Name: "sidecar", Image: "sidecar:1"},
+					}},
+				},
+			},
+		}
+	})
+
+	It("should return an empty string if the objects don't differ", func() {
+		Expect(client.PrettyDiff(dep, dep.DeepCopy())).To(BeEmpty())
+	})
+
+	It("should ignore the resourceVersion", func() {
+		updated := dep.DeepCopy()
+		updated.ResourceVersion = "2"
+		Expect(client.PrettyDiff(dep, updated)).To(BeEmpty())
+	})
+
+	It("should list the changed fields by path", func() {
+		updated := dep.DeepCopy()
+		replicas := int32(3)
+		updated.Spec.Replicas = &replicas
+		updated.Labels = map[string]string{"app.kubernetes.io/name": "web"}
+
+		Expect(client.PrettyDiff(dep, updated)).To(Equal(
+			`metadata.labels: <none> -> {"app.kubernetes.io/name":"web"}; spec.replicas: 1 -> 3`))
+	})
+
+	It("should identify list elements by their merge key", func() {
+		updated := dep.DeepCopy()
+		updated.Spec.Template.Spec.Containers = []corev1.Container{
+			{Name: "sidecar", Image: "sidecar:1"},
+			{Name: "nginx", Image: "nginx:1.15", Args: []string{"-a", "-b"}},
+		}
+
+		Expect(client.PrettyDiff(dep, updated)).To(Equal(
+			`spec.template.spec.containers[name=nginx].args: ["-a"] -> ["-a","-b"]; ` +
+				`spec.template.spec.containers[name=nginx].image: "nginx:1.14" -> "nginx:1.15"`))
+	})
+
+	It("should identify elements of lists of unstructured objects by name", func() {
+		old := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"name": "a", "value": int64(1)},
+				map[string]interface{}{"name": "b", "value": int64(2)},
+			}},
+		}}
+		updated := old.DeepCopy()
+		updated.Object["spec"] = map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"name": "b", "value": int64(3)},
+			map[string]interface{}{"name": "c", "value": int64(4)},
+		}}
+
+		Expect(client.PrettyDiff(old, updated)).To(Equal(
+			`spec.items[name=a]: {"name":"a","value":1} -> <none>; ` +
+				`spec.items[name=b].value: 2 -> 3; ` +
+				`spec.items[name=c]: <none> -> {"name":"c","value":4}`))
+	})
+
+	It("should truncate long values and long lists of changes", func() {
+		updated := dep.DeepCopy()
+		updated.Annotations = map[string]string{}
+		for i := 0; i < 100; i++ {
+			updated.Annotations[fmt.Sprintf("annotation-%03d", i)] = strings.Repeat("x", 100)
+		}
+		old := dep.DeepCopy()
+		old.Annotations = map[string]string{}
+		for key := range updated.Annotations {
+			old.Annotations[key] = ""
+		}
+
+		diff := client.PrettyDiff(old, updated)
+		Expect(diff).To(HavePrefix(`metadata.annotations.annotation-000: "" -> "xxx`))
+		Expect(diff).To(ContainSubstring(`xxx...; `))
+		Expect(diff).To(MatchRegexp(`; \.\.\. \d+ more changes$`))
+		Expect(len(diff)).To(BeNumerically("<", 1100))
+	})
+})
@@ -53,8 +53,53 @@ func NewFakeClient(initObjs ...runtime.Object) client.Client {
 // for testing.
 // You can choose to initialize it with a slice of runtime.Object.
 func NewFakeClientWithScheme(clientScheme *runtime.Scheme, initObjs ...runtime.Object) client.Client {
-	tracker := testing.NewObjectTracker(clientScheme, scheme.Codecs.UniversalDecoder())
-	for _, obj := range initObjs {
+	return NewClientBuilder().WithScheme(clientScheme).WithRuntimeObjects(initObjs...).Build()
+}
+
+// ClientBuilder builds fake clients.
+type ClientBuilder struct {
+	scheme   *runtime.Scheme
+	initObjs []runtime.Object
+	tracker  testing.ObjectTracker
+}
+
+// NewClientBuilder returns a ClientBuilder for a fake client with scheme.Scheme and no objects.
+func NewClientBuilder() *ClientBuilder {
+	return &ClientBuilder{scheme: scheme.Scheme}
+}
+
+// WithScheme sets the scheme of the client.
+func (b *ClientBuilder) WithScheme(clientScheme *runtime.Scheme) *ClientBuilder {
+	b.scheme = clientScheme
+	return b
+}
+
+// WithRuntimeObjects adds objects to the client.
+func (b *ClientBuilder) WithRuntimeObjects(initObjs ...runtime.Object) *ClientBuilder {
+	b.initObjs = append(b.initObjs, initObjs...)
+	return b
+}
+
+// WithObjectTracker sets the tracker storing the objects of the client.  Defaults to the tracker of
+// testing.NewObjectTracker, which scans all objects of a kind for most operations.  Tests holding many
+// objects should use an IndexedTracker:
+//
+//	tracker := fake.NewIndexedTracker(s, scheme.Codecs.UniversalDecoder())
+//	cl := fake.NewClientBuilder().WithScheme(s).WithObjectTracker(tracker).Build()
+//
+// Label and field selectors are passed to trackers implementing SelectingTracker.
+func (b *ClientBuilder) WithObjectTracker(tracker testing.ObjectTracker) *ClientBuilder {
+	b.tracker = tracker
+	return b
+}
+
+// Build returns the fake client.  It panics if the objects can't be added to the tracker.
+func (b *ClientBuilder) Build() client.Client {
+	tracker := b.tracker
+	if tracker == nil {
+		tracker = testing.NewObjectTracker(b.scheme, scheme.Codecs.UniversalDecoder())
+	}
+	for _, obj := range b.initObjs {
 		err := tracker.Add(obj)
 		if err != nil {
 			panic(fmt.Errorf("failed to add object %v to fake client: %v", obj, err))
@@ -62,7 +107,7 @@ func NewFakeClientWithScheme(clientScheme *runtime.Scheme, initObjs ...runtime.O
 	}
 	return &fakeClient{
 		tracker: tracker,
-		scheme:  clientScheme,
+		scheme:  b.scheme,
 	}
 }
 
@@ -100,7 +145,13 @@ func (c *fakeClient) List(ctx context.Context, obj runtime.Object, opts ...clien
 	listOpts.ApplyOptions(opts)
 
 	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
-	o, err := c.tracker.List(gvr, gvk, listOpts.Namespace)
+	var o runtime.Object
+	st, selecting := c.tracker.(SelectingTracker)
+	if selecting {
+		o, err = st.ListSelected(gvr, gvk, listOpts.Namespace, listOpts.LabelSelector, listOpts.FieldSelector)
+	} else {
+		o, err = c.tracker.List(gvr, gvk, listOpts.Namespace)
+	}
 	if err != nil {
 		return err
 	}
@@ -114,7 +165,7 @@ func (c *fakeClient) List(ctx context.Context, obj runtime.Object, opts ...clien
 		return err
 	}
 
-	if listOpts.LabelSelector != nil {
+	if listOpts.LabelSelector != nil && !selecting {
 		objs, err := meta.ExtractList(obj)
 		if err != nil {
 			return err
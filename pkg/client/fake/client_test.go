@@ -18,6 +18,7 @@ package fake
 
 import (
 	"encoding/json"
+	"fmt"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -251,4 +253,76 @@ var _ = Describe("Fake client", func() {
 		})
 		AssertClientBehavior()
 	})
+
+	Context("with an IndexedTracker", func() {
+		var tracker *IndexedTracker
+
+		BeforeEach(func(done Done) {
+			tracker = NewIndexedTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+			Expect(tracker.IndexField(&appsv1.Deployment{}, "spec.replicas", func(obj runtime.Object) []string {
+				if replicas := obj.(*appsv1.Deployment).Spec.Replicas; replicas != nil {
+					return []string{fmt.Sprintf("%d", *replicas)}
+				}
+				return nil
+			})).To(Succeed())
+			cl = NewClientBuilder().WithObjectTracker(tracker).WithRuntimeObjects(dep, dep2, cm).Build()
+			close(done)
+		})
+		AssertClientBehavior()
+
+		It("should support label selectors with set-based requirements", func() {
+			list := &appsv1.DeploymentList{}
+			Expect(cl.List(nil, list, func(o *client.ListOptions) {
+				Expect(o.SetLabelSelector("test-label in (label-value, other-value)")).To(Succeed())
+			})).To(Succeed())
+			Expect(list.Items).To(ConsistOf(*dep2))
+
+			list = &appsv1.DeploymentList{}
+			Expect(cl.List(nil, list, func(o *client.ListOptions) {
+				Expect(o.SetLabelSelector("!test-label")).To(Succeed())
+			})).To(Succeed())
+			Expect(list.Items).To(ConsistOf(*dep))
+		})
+
+		It("should support field selectors on the name and on indexed fields", func() {
+			list := &appsv1.DeploymentList{}
+			Expect(cl.List(nil, list, client.MatchingField("metadata.name", "test-deployment-2"))).To(Succeed())
+			Expect(list.Items).To(ConsistOf(*dep2))
+
+			By("indexing the objects added after IndexField")
+			replicas := int32(3)
+			dep3 := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-deployment-3", Namespace: "ns2"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			}
+			Expect(cl.Create(nil, dep3)).To(Succeed())
+			list = &appsv1.DeploymentList{}
+			Expect(cl.List(nil, list, client.MatchingField("spec.replicas", "3"))).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Name).To(Equal("test-deployment-3"))
+
+			By("reindexing updated objects")
+			replicas = 1
+			dep3.Spec.Replicas = &replicas
+			Expect(cl.Update(nil, dep3)).To(Succeed())
+			list = &appsv1.DeploymentList{}
+			Expect(cl.List(nil, list, client.MatchingField("spec.replicas", "3"))).To(Succeed())
+			Expect(list.Items).To(BeEmpty())
+		})
+
+		It("should fail to select by fields which aren't indexed", func() {
+			list := &appsv1.DeploymentList{}
+			err := cl.List(nil, list, client.MatchingField("spec.paused", "true"))
+			Expect(err).To(MatchError(ContainSubstring("must be indexed with IndexField")))
+		})
+
+		It("should index objects added after IndexField", func() {
+			list := &corev1.ConfigMapList{}
+			Expect(tracker.IndexField(&corev1.ConfigMap{}, "data.test-key", func(obj runtime.Object) []string {
+				return []string{obj.(*corev1.ConfigMap).Data["test-key"]}
+			})).To(Succeed())
+			Expect(cl.List(nil, list, client.MatchingField("data.test-key", "test-value"))).To(Succeed())
+			Expect(list.Items).To(ConsistOf(*cm))
+		})
+	})
 })
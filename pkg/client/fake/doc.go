@@ -24,6 +24,12 @@ You can create a fake client with optional objects.
 
 You can invoke the methods defined in the Client interface.
 
+Fake clients are configured with a ClientBuilder.  Tests holding many objects should store them in an
+IndexedTracker, which indexes them by namespace, label and indexed fields:
+
+	tracker := NewIndexedTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+	client := NewClientBuilder().WithObjectTracker(tracker).WithRuntimeObjects(initObjs...).Build()
+
 When it doubt, it's almost always better not to use this package and instead use
 envtest.Environment with a real client and API server.
 */
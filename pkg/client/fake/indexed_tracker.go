@@ -0,0 +1,476 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SelectingTracker is a testing.ObjectTracker which can list the objects matching label and field
+// selectors itself, e.g. using indexes.  The fake client lists objects of trackers implementing it with
+// ListSelected instead of filtering the objects of List.
+type SelectingTracker interface {
+	testing.ObjectTracker
+
+	// ListSelected retrieves the objects of a given kind in the given namespace which match the label and
+	// field selectors.  Nil selectors match everything.
+	ListSelected(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string,
+		labelSel labels.Selector, fieldSel fields.Selector) (runtime.Object, error)
+}
+
+var _ SelectingTracker = &IndexedTracker{}
+var _ client.FieldIndexer = &IndexedTracker{}
+
+// IndexedTracker is a SelectingTracker for fake clients holding many objects.  Unlike the tracker of
+// testing.NewObjectTracker, it stores objects in maps, and indexes them by namespace, by label and by the
+// fields indexed with IndexField, so that getting, writing and listing objects by indexed selectors
+// doesn't scan all objects of a kind.  Field selectors may use metadata.name, metadata.namespace and the
+// fields indexed with IndexField, with the =, == and != operators.
+type IndexedTracker struct {
+	scheme  testing.ObjectScheme
+	decoder runtime.Decoder
+
+	mu        sync.RWMutex
+	resources map[schema.GroupVersionResource]*indexedResource
+	// indexers are the field indexers of each resource, by field.
+	indexers map[schema.GroupVersionResource]map[string]client.IndexerFunc
+	// watchers are the watchers of each resource by namespace, with "" watching all namespaces.
+	watchers map[schema.GroupVersionResource]map[string][]*watch.RaceFreeFakeWatcher
+}
+
+// indexedResource holds the objects of a resource along with their indexes.
+type indexedResource struct {
+	objects map[types.NamespacedName]runtime.Object
+	// indexes map index keys, like "namespace/ns1" or "label/app=foo", to the objects carrying them.
+	indexes map[string]map[types.NamespacedName]struct{}
+	// keys are the index keys of each object.
+	keys map[types.NamespacedName][]string
+}
+
+// NewIndexedTracker returns an empty IndexedTracker.
+func NewIndexedTracker(scheme testing.ObjectScheme, decoder runtime.Decoder) *IndexedTracker {
+	return &IndexedTracker{
+		scheme:    scheme,
+		decoder:   decoder,
+		resources: map[schema.GroupVersionResource]*indexedResource{},
+		indexers:  map[schema.GroupVersionResource]map[string]client.IndexerFunc{},
+		watchers:  map[schema.GroupVersionResource]map[string][]*watch.RaceFreeFakeWatcher{},
+	}
+}
+
+// IndexField implements client.FieldIndexer.  Objects which are tracked already are indexed as well.
+func (t *IndexedTracker) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	gvks, _, err := t.scheme.ObjectKinds(obj)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, gvk := range gvks {
+		gvr := guessResource(gvk)
+		if t.indexers[gvr] == nil {
+			t.indexers[gvr] = map[string]client.IndexerFunc{}
+		}
+		t.indexers[gvr][field] = extractValue
+		if res, found := t.resources[gvr]; found {
+			for key, o := range res.objects {
+				res.index(key, o, t.indexers[gvr])
+			}
+		}
+	}
+	return nil
+}
+
+// Add implements testing.ObjectTracker
+func (t *IndexedTracker) Add(obj runtime.Object) error {
+	if meta.IsListType(obj) {
+		list, err := meta.ExtractList(obj)
+		if err != nil {
+			return err
+		}
+		if errs := runtime.DecodeList(list, t.decoder); len(errs) > 0 {
+			return errs[0]
+		}
+		for _, o := range list {
+			if err := t.Add(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	gvks, _, err := t.scheme.ObjectKinds(obj)
+	if err != nil {
+		return err
+	}
+	if len(gvks) == 0 {
+		return fmt.Errorf("no registered kinds for %v", obj)
+	}
+	for _, gvk := range gvks {
+		if err := t.add(guessResource(gvk), obj, objMeta.GetNamespace(), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get implements testing.ObjectTracker
+func (t *IndexedTracker) Get(gvr schema.GroupVersionResource, ns, name string) (runtime.Object, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res, found := t.resources[gvr]
+	if !found {
+		return nil, errors.NewNotFound(gvr.GroupResource(), name)
+	}
+	o, found := res.objects[types.NamespacedName{Namespace: ns, Name: name}]
+	if !found {
+		return nil, errors.NewNotFound(gvr.GroupResource(), name)
+	}
+	obj := o.DeepCopyObject()
+	if status, ok := obj.(*metav1.Status); ok && status.Status != metav1.StatusSuccess {
+		return nil, &errors.StatusError{ErrStatus: *status}
+	}
+	return obj, nil
+}
+
+// Create implements testing.ObjectTracker
+func (t *IndexedTracker) Create(gvr schema.GroupVersionResource, obj runtime.Object, ns string) error {
+	return t.add(gvr, obj, ns, false)
+}
+
+// Update implements testing.ObjectTracker
+func (t *IndexedTracker) Update(gvr schema.GroupVersionResource, obj runtime.Object, ns string) error {
+	return t.add(gvr, obj, ns, true)
+}
+
+// List implements testing.ObjectTracker
+func (t *IndexedTracker) List(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string) (runtime.Object, error) {
+	return t.ListSelected(gvr, gvk, ns, nil, nil)
+}
+
+// ListSelected implements SelectingTracker
+func (t *IndexedTracker) ListSelected(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string,
+	labelSel labels.Selector, fieldSel fields.Selector) (runtime.Object, error) {
+	listGVK := gvk
+	listGVK.Kind = listGVK.Kind + "List"
+	// The scheme knows the internal version as runtime.APIVersionInternal, not as "".
+	if listGVK.Version == "" {
+		listGVK.Version = runtime.APIVersionInternal
+	}
+	list, err := t.scheme.New(listGVK)
+	if err != nil {
+		return nil, err
+	}
+	if !meta.IsListType(list) {
+		return nil, fmt.Errorf("%q is not a list type", listGVK.Kind)
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res, found := t.resources[gvr]
+	if !found {
+		return list, nil
+	}
+	keys, err := res.selectKeys(ns, labelSel, fieldSel, t.indexers[gvr])
+	if err != nil {
+		return nil, err
+	}
+
+	objs := make([]runtime.Object, 0, len(keys))
+	for _, key := range keys {
+		objs = append(objs, res.objects[key].DeepCopyObject())
+	}
+	if err := meta.SetList(list, objs); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Delete implements testing.ObjectTracker
+func (t *IndexedTracker) Delete(gvr schema.GroupVersionResource, ns, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := types.NamespacedName{Namespace: ns, Name: name}
+	res, found := t.resources[gvr]
+	if !found {
+		return errors.NewNotFound(gvr.GroupResource(), name)
+	}
+	obj, found := res.objects[key]
+	if !found {
+		return errors.NewNotFound(gvr.GroupResource(), name)
+	}
+	res.unindex(key)
+	delete(res.objects, key)
+	for _, w := range t.watches(gvr, ns) {
+		w.Delete(obj)
+	}
+	return nil
+}
+
+// Watch implements testing.ObjectTracker
+func (t *IndexedTracker) Watch(gvr schema.GroupVersionResource, ns string) (watch.Interface, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := watch.NewRaceFreeFake()
+	if t.watchers[gvr] == nil {
+		t.watchers[gvr] = map[string][]*watch.RaceFreeFakeWatcher{}
+	}
+	t.watchers[gvr][ns] = append(t.watchers[gvr][ns], w)
+	return w, nil
+}
+
+// add stores obj, replacing the existing object if replaceExisting is true.
+func (t *IndexedTracker) add(gvr schema.GroupVersionResource, obj runtime.Object, ns string, replaceExisting bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Store a copy, so that callers can't modify the stored object.
+	obj = obj.DeepCopyObject()
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if len(objMeta.GetNamespace()) == 0 {
+		objMeta.SetNamespace(ns)
+	}
+	if ns != objMeta.GetNamespace() {
+		return errors.NewBadRequest(fmt.Sprintf("request namespace does not match object namespace, request: %q object: %q",
+			ns, objMeta.GetNamespace()))
+	}
+
+	res, found := t.resources[gvr]
+	if !found {
+		res = &indexedResource{
+			objects: map[types.NamespacedName]runtime.Object{},
+			indexes: map[string]map[types.NamespacedName]struct{}{},
+			keys:    map[types.NamespacedName][]string{},
+		}
+		t.resources[gvr] = res
+	}
+	key := types.NamespacedName{Namespace: ns, Name: objMeta.GetName()}
+	_, exists := res.objects[key]
+	switch {
+	case exists && !replaceExisting:
+		return errors.NewAlreadyExists(gvr.GroupResource(), objMeta.GetName())
+	case !exists && replaceExisting:
+		return errors.NewNotFound(gvr.GroupResource(), objMeta.GetName())
+	}
+
+	res.objects[key] = obj
+	res.index(key, obj, t.indexers[gvr])
+	for _, w := range t.watches(gvr, ns) {
+		if exists {
+			w.Modify(obj)
+		} else {
+			w.Add(obj)
+		}
+	}
+	return nil
+}
+
+// watches returns the watchers of the objects of gvr in namespace ns.
+func (t *IndexedTracker) watches(gvr schema.GroupVersionResource, ns string) []*watch.RaceFreeFakeWatcher {
+	watches := append([]*watch.RaceFreeFakeWatcher{}, t.watchers[gvr][ns]...)
+	if ns != metav1.NamespaceAll {
+		watches = append(watches, t.watchers[gvr][metav1.NamespaceAll]...)
+	}
+	return watches
+}
+
+// index replaces the index keys of the object stored under key.
+func (r *indexedResource) index(key types.NamespacedName, obj runtime.Object, indexers map[string]client.IndexerFunc) {
+	r.unindex(key)
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	keys := []string{namespaceIndexKey(key.Namespace)}
+	for k, v := range objMeta.GetLabels() {
+		keys = append(keys, labelKeyIndexKey(k), labelIndexKey(k, v))
+	}
+	for field, extract := range indexers {
+		for _, v := range extract(obj) {
+			keys = append(keys, fieldIndexKey(field, v))
+		}
+	}
+
+	for _, k := range keys {
+		if r.indexes[k] == nil {
+			r.indexes[k] = map[types.NamespacedName]struct{}{}
+		}
+		r.indexes[k][key] = struct{}{}
+	}
+	r.keys[key] = keys
+}
+
+// unindex removes the index keys of the object stored under key.
+func (r *indexedResource) unindex(key types.NamespacedName) {
+	for _, k := range r.keys[key] {
+		delete(r.indexes[k], key)
+		if len(r.indexes[k]) == 0 {
+			delete(r.indexes, k)
+		}
+	}
+	delete(r.keys, key)
+}
+
+// selectKeys returns the keys of the objects in namespace ns matching the selectors, sorted.  It only
+// checks the objects of the smallest index set narrowing the selection.
+func (r *indexedResource) selectKeys(ns string, labelSel labels.Selector, fieldSel fields.Selector,
+	indexers map[string]client.IndexerFunc) ([]types.NamespacedName, error) {
+	var candidates []map[types.NamespacedName]struct{}
+	narrow := func(indexKeys ...string) {
+		union := map[types.NamespacedName]struct{}{}
+		for _, k := range indexKeys {
+			for key := range r.indexes[k] {
+				union[key] = struct{}{}
+			}
+		}
+		candidates = append(candidates, union)
+	}
+
+	if len(ns) > 0 {
+		narrow(namespaceIndexKey(ns))
+	}
+	if labelSel != nil {
+		reqs, _ := labelSel.Requirements()
+		for _, req := range reqs {
+			switch req.Operator() {
+			case selection.Equals, selection.DoubleEquals, selection.In:
+				var indexKeys []string
+				for _, v := range req.Values().List() {
+					indexKeys = append(indexKeys, labelIndexKey(req.Key(), v))
+				}
+				narrow(indexKeys...)
+			case selection.Exists:
+				narrow(labelKeyIndexKey(req.Key()))
+			}
+		}
+	}
+	var fieldReqs fields.Requirements
+	if fieldSel != nil {
+		fieldReqs = fieldSel.Requirements()
+	}
+	for _, req := range fieldReqs {
+		if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals && req.Operator != selection.NotEquals {
+			return nil, fmt.Errorf("field selector operator %q is not supported", req.Operator)
+		}
+		if _, indexed := indexers[req.Field]; !indexed && req.Field != "metadata.name" && req.Field != "metadata.namespace" {
+			return nil, fmt.Errorf("field selector %q is not supported: it must be indexed with IndexField", req.Field)
+		}
+		if req.Operator == selection.NotEquals {
+			continue
+		}
+		switch req.Field {
+		case "metadata.namespace":
+			narrow(namespaceIndexKey(req.Value))
+		case "metadata.name":
+			// Names are only unique per namespace, so this doesn't narrow the selection to a single object.
+		default:
+			narrow(fieldIndexKey(req.Field, req.Value))
+		}
+	}
+
+	var keys []types.NamespacedName
+	match := func(key types.NamespacedName) {
+		if len(ns) > 0 && key.Namespace != ns {
+			return
+		}
+		if labelSel != nil {
+			objMeta, err := meta.Accessor(r.objects[key])
+			if err != nil || !labelSel.Matches(labels.Set(objMeta.GetLabels())) {
+				return
+			}
+		}
+		for _, req := range fieldReqs {
+			if r.matchesField(key, req) != (req.Operator != selection.NotEquals) {
+				return
+			}
+		}
+		keys = append(keys, key)
+	}
+	if len(candidates) == 0 {
+		for key := range r.objects {
+			match(key)
+		}
+	} else {
+		smallest := candidates[0]
+		for _, c := range candidates[1:] {
+			if len(c) < len(smallest) {
+				smallest = c
+			}
+		}
+		for key := range smallest {
+			match(key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Namespace != keys[j].Namespace {
+			return keys[i].Namespace < keys[j].Namespace
+		}
+		return keys[i].Name < keys[j].Name
+	})
+	return keys, nil
+}
+
+// matchesField reports whether the field of the object stored under key has the value of req.
+func (r *indexedResource) matchesField(key types.NamespacedName, req fields.Requirement) bool {
+	switch req.Field {
+	case "metadata.name":
+		return key.Name == req.Value
+	case "metadata.namespace":
+		return key.Namespace == req.Value
+	}
+	_, found := r.indexes[fieldIndexKey(req.Field, req.Value)][key]
+	return found
+}
+
+func namespaceIndexKey(ns string) string       { return "namespace/" + ns }
+func labelKeyIndexKey(key string) string       { return "labelkey/" + key }
+func labelIndexKey(key, value string) string   { return "label/" + key + "=" + value }
+func fieldIndexKey(field, value string) string { return "field/" + field + "=" + value }
+
+// guessResource returns the resource of gvk, like testing.ObjectTracker.Add.
+func guessResource(gvk schema.GroupVersionKind) schema.GroupVersionResource {
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	// Resources don't have the concept of an internal version.
+	if gvr.Version == runtime.APIVersionInternal {
+		gvr.Version = ""
+	}
+	return gvr
+}
@@ -18,12 +18,14 @@ package client
 
 import (
 	"context"
+	"fmt"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 // ObjectKey identifies a Kubernetes Object.
@@ -122,6 +124,23 @@ type FieldIndexer interface {
 	IndexField(obj runtime.Object, field string, extractValue IndexerFunc) error
 }
 
+// ErrUnregisteredType is returned when the type of an object is not registered in the Scheme of a client
+// or cache.  Use errors.As to check for it.
+type ErrUnregisteredType = apiutil.ErrUnregisteredType
+
+// ErrIndexNotFound is returned when listing objects from a cache by a field which has no index.  Fields
+// have to be indexed with FieldIndexer.IndexField before they can be used in field selectors.
+// Use errors.As to check for it.
+type ErrIndexNotFound struct {
+	// Field is the field of the field selector.
+	Field string
+}
+
+// Error implements error
+func (e *ErrIndexNotFound) Error() string {
+	return fmt.Sprintf("index with name field:%s does not exist", e.Field)
+}
+
 // IgnoreNotFound returns nil on NotFound errors.
 // All other values that are not NotFound errors or nil are returned unmodified.
 func IgnoreNotFound(err error) error {
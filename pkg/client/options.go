@@ -170,6 +170,16 @@ type ListOptions struct {
 	// non-namespaced objects, or to list across all namespaces.
 	Namespace string
 
+	// Limit is the maximum number of objects to return.  If there are more,
+	// the Continue field of the ListMeta of the result is set to a token
+	// which lists the next objects when passed as Continue.  0 means no limit.
+	Limit int64
+
+	// Continue is the token returned by a previous list with a Limit, to
+	// list the objects after those it returned.  Lists continued that way
+	// must use the same selectors and namespace.
+	Continue string
+
 	// Raw represents raw ListOptions, as passed to the API server.  Note
 	// that these may not be respected by all implementations of interface,
 	// and the LabelSelector and FieldSelector fields are ignored.
@@ -213,6 +223,12 @@ func (o *ListOptions) AsListOptions() *metav1.ListOptions {
 	if o.FieldSelector != nil {
 		o.Raw.FieldSelector = o.FieldSelector.String()
 	}
+	if o.Limit > 0 {
+		o.Raw.Limit = o.Limit
+	}
+	if len(o.Continue) > 0 {
+		o.Raw.Continue = o.Continue
+	}
 	return o.Raw
 }
 
@@ -281,6 +297,22 @@ func InNamespace(ns string) ListOptionFunc {
 	}
 }
 
+// Limit is a functional option that sets the Limit field of a ListOptions
+// struct.
+func Limit(n int64) ListOptionFunc {
+	return func(opts *ListOptions) {
+		opts.Limit = n
+	}
+}
+
+// Continue is a functional option that sets the Continue field of a
+// ListOptions struct.
+func Continue(token string) ListOptionFunc {
+	return func(opts *ListOptions) {
+		opts.Continue = token
+	}
+}
+
 // UseListOptions is a functional option that replaces the fields of a
 // ListOptions struct with those of a different ListOptions struct.
 //
@@ -0,0 +1,311 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// NewOverlayClient returns a Client which remembers the objects written through it, and serves them to
+// later reads through it instead of the versions read by c, e.g. from a cache which hasn't observed the
+// writes yet.  This gives read-your-writes consistency to a short-lived sequence of calls, such as a single
+// reconcile; the overlay client should be discarded afterwards, since it never forgets its writes and
+// doesn't observe the writes of others.
+//
+// Creates, updates and patches, including those of the status, remember the object as returned by the
+// API server.  Deletes make the object not found, unless it has finalizers or is deleted in the foreground,
+// in which case it still exists and is read from c again.  A failed write also makes the object read from
+// c again.  Lists with a field selector don't include objects created through the overlay client, since
+// the selector can't be evaluated on them, and neither do paginated lists, since they can't be positioned
+// in the pages.
+func NewOverlayClient(c Client, scheme *runtime.Scheme) Client {
+	return &overlayClient{Client: c, scheme: scheme, objects: map[overlayKey]runtime.Object{}}
+}
+
+// overlayClient implements the Client returned by NewOverlayClient.
+type overlayClient struct {
+	Client
+
+	scheme *runtime.Scheme
+
+	mu sync.Mutex
+	// objects are the objects written through the overlay client.  Deleted objects are nil.
+	objects map[overlayKey]runtime.Object
+}
+
+// overlayKey identifies an object in the overlay.
+type overlayKey struct {
+	schema.GroupKind
+	ObjectKey
+}
+
+func (c *overlayClient) keyFor(obj runtime.Object) (overlayKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return overlayKey{}, err
+	}
+	key, err := ObjectKeyFromObject(obj)
+	if err != nil {
+		return overlayKey{}, err
+	}
+	return overlayKey{GroupKind: gvk.GroupKind(), ObjectKey: key}, nil
+}
+
+// record remembers obj after it has been written, or forgets it if the write failed.
+func (c *overlayClient) record(obj runtime.Object, err error) error {
+	key, keyErr := c.keyFor(obj)
+	if keyErr != nil {
+		// The object can't be written through c either, so there is nothing to remember.
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.objects, key)
+		return err
+	}
+	c.objects[key] = obj.DeepCopyObject()
+	return nil
+}
+
+// Create implements Writer
+func (c *overlayClient) Create(ctx context.Context, obj runtime.Object, opts ...CreateOptionFunc) error {
+	if (&CreateOptions{}).ApplyOptions(opts).DryRun != nil {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	return c.record(obj, c.Client.Create(ctx, obj, opts...))
+}
+
+// Update implements Writer
+func (c *overlayClient) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	if (&UpdateOptions{}).ApplyOptions(opts).DryRun != nil {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	return c.record(obj, c.Client.Update(ctx, obj, opts...))
+}
+
+// Patch implements Writer
+func (c *overlayClient) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	if (&PatchOptions{}).ApplyOptions(opts).DryRun != nil {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	return c.record(obj, c.Client.Patch(ctx, obj, patch, opts...))
+}
+
+// Delete implements Writer
+func (c *overlayClient) Delete(ctx context.Context, obj runtime.Object, opts ...DeleteOptionFunc) error {
+	key, err := c.keyFor(obj)
+	if err != nil {
+		return c.Client.Delete(ctx, obj, opts...)
+	}
+	err = c.Client.Delete(ctx, obj, opts...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil && !apierrors.IsNotFound(err) {
+		delete(c.objects, key)
+		return err
+	}
+	if lingers(obj, (&DeleteOptions{}).ApplyOptions(opts)) {
+		// The object will only be gone once its finalizers are done.
+		delete(c.objects, key)
+		return err
+	}
+	c.objects[key] = nil
+	return err
+}
+
+// lingers reports whether obj still exists after being deleted with opts.
+func lingers(obj runtime.Object, opts *DeleteOptions) bool {
+	if opts.PropagationPolicy != nil && *opts.PropagationPolicy == metav1.DeletePropagationForeground {
+		return true
+	}
+	m, err := meta.Accessor(obj)
+	return err != nil || len(m.GetFinalizers()) > 0
+}
+
+// Status implements StatusClient
+func (c *overlayClient) Status() StatusWriter {
+	return &overlayStatusWriter{overlay: c, StatusWriter: c.Client.Status()}
+}
+
+// overlayStatusWriter remembers the objects whose status it writes in the overlay.
+type overlayStatusWriter struct {
+	StatusWriter
+
+	overlay *overlayClient
+}
+
+// Update implements StatusWriter
+func (w *overlayStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	if (&UpdateOptions{}).ApplyOptions(opts).DryRun != nil {
+		return w.StatusWriter.Update(ctx, obj, opts...)
+	}
+	return w.overlay.record(obj, w.StatusWriter.Update(ctx, obj, opts...))
+}
+
+// Patch implements StatusWriter
+func (w *overlayStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	if (&PatchOptions{}).ApplyOptions(opts).DryRun != nil {
+		return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+	}
+	return w.overlay.record(obj, w.StatusWriter.Patch(ctx, obj, patch, opts...))
+}
+
+// Get implements Reader
+func (c *overlayClient) Get(ctx context.Context, key ObjectKey, obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return c.Client.Get(ctx, key, obj)
+	}
+
+	c.mu.Lock()
+	written, found := c.objects[overlayKey{GroupKind: gvk.GroupKind(), ObjectKey: key}]
+	c.mu.Unlock()
+	if !found {
+		return c.Client.Get(ctx, key, obj)
+	}
+	if written == nil {
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		return apierrors.NewNotFound(gvr.GroupResource(), key.Name)
+	}
+	return copyInto(written, obj)
+}
+
+// List implements Reader
+func (c *overlayClient) List(ctx context.Context, list runtime.Object, opts ...ListOptionFunc) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	gvk, err := apiutil.GVKForObject(list, c.scheme)
+	if err != nil || !strings.HasSuffix(gvk.Kind, "List") {
+		// Nothing of an unknown type can have been written through the overlay client.
+		return nil
+	}
+	gk := schema.GroupKind{Group: gvk.Group, Kind: strings.TrimSuffix(gvk.Kind, "List")}
+	listOpts := (&ListOptions{}).ApplyOptions(opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	written := map[ObjectKey]runtime.Object{}
+	for key, obj := range c.objects {
+		if key.GroupKind == gk && (listOpts.Namespace == "" || listOpts.Namespace == key.Namespace) {
+			written[key.ObjectKey] = obj
+		}
+	}
+	if len(written) == 0 {
+		return nil
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	merged := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		key, err := ObjectKeyFromObject(item)
+		if err != nil {
+			return err
+		}
+		obj, found := written[key]
+		if !found {
+			merged = append(merged, item)
+			continue
+		}
+		delete(written, key)
+		if obj == nil || !matchesLabels(obj, listOpts) {
+			continue
+		}
+		if err := copyInto(obj, item); err != nil {
+			return err
+		}
+		merged = append(merged, item)
+	}
+	if listOpts.FieldSelector == nil && listOpts.Limit == 0 && len(listOpts.Continue) == 0 {
+		for _, obj := range written {
+			if obj == nil || !matchesLabels(obj, listOpts) {
+				continue
+			}
+			item, err := c.newItem(list, gvk.GroupVersion().WithKind(gk.Kind))
+			if err != nil {
+				return err
+			}
+			if err := copyInto(obj, item); err != nil {
+				return err
+			}
+			merged = append(merged, item)
+		}
+	}
+	return meta.SetList(list, merged)
+}
+
+// newItem returns a new item for list.
+func (c *overlayClient) newItem(list runtime.Object, gvk schema.GroupVersionKind) (runtime.Object, error) {
+	if _, isUnstructured := list.(*unstructured.UnstructuredList); isUnstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		return u, nil
+	}
+	return c.scheme.New(gvk)
+}
+
+// matchesLabels reports whether obj matches the label selector of opts.
+func matchesLabels(obj runtime.Object, opts *ListOptions) bool {
+	if opts.LabelSelector == nil {
+		return true
+	}
+	m, err := meta.Accessor(obj)
+	return err == nil && opts.LabelSelector.Matches(labels.Set(m.GetLabels()))
+}
+
+// copyInto copies the content of in into out, converting between typed and unstructured objects.
+func copyInto(in, out runtime.Object) error {
+	if reflect.TypeOf(in) == reflect.TypeOf(out) {
+		reflect.ValueOf(out).Elem().Set(reflect.ValueOf(in.DeepCopyObject()).Elem())
+		return nil
+	}
+	if u, isUnstructured := out.(runtime.Unstructured); isUnstructured {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(in)
+		if err != nil {
+			return err
+		}
+		// Typed objects usually don't carry their apiVersion and kind.
+		gvk := out.GetObjectKind().GroupVersionKind()
+		u.SetUnstructuredContent(content)
+		if out.GetObjectKind().GroupVersionKind().Empty() {
+			out.GetObjectKind().SetGroupVersionKind(gvk)
+		}
+		return nil
+	}
+	if u, isUnstructured := in.(runtime.Unstructured); isUnstructured {
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(runtime.DeepCopyJSON(u.UnstructuredContent()), out)
+	}
+	return fmt.Errorf("unable to copy %T into %T", in, out)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("OverlayClient", func() {
+	var overlay client.Client
+	var ctx = context.TODO()
+
+	configMap := func(name string, finalizers ...string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "default",
+			Name:       name,
+			Labels:     map[string]string{"app": "test"},
+			Finalizers: finalizers,
+		}}
+	}
+
+	BeforeEach(func() {
+		// The reader is a stale copy of the API server, which never observes the writes.
+		server := fake.NewFakeClient(configMap("existing"), configMap("finalized", "test"))
+		stale := fake.NewFakeClient(configMap("existing"), configMap("finalized", "test"))
+		overlay = client.NewOverlayClient(&client.DelegatingClient{Reader: stale, Writer: server, StatusClient: server}, scheme.Scheme)
+	})
+
+	It("should read the objects created through it", func() {
+		Expect(overlay.Create(ctx, configMap("created"))).To(Succeed())
+
+		cm := &corev1.ConfigMap{}
+		Expect(overlay.Get(ctx, client.ObjectKey{Namespace: "default", Name: "created"}, cm)).To(Succeed())
+		Expect(cm.Name).To(Equal("created"))
+
+		By("reading it as unstructured")
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		Expect(overlay.Get(ctx, client.ObjectKey{Namespace: "default", Name: "created"}, u)).To(Succeed())
+		Expect(u.GetName()).To(Equal("created"))
+		Expect(u.GetKind()).To(Equal("ConfigMap"))
+	})
+
+	It("should read the objects updated through it", func() {
+		cm := configMap("existing")
+		Expect(overlay.Get(ctx, client.ObjectKey{Namespace: "default", Name: "existing"}, cm)).To(Succeed())
+		cm.Data = map[string]string{"updated": "true"}
+		Expect(overlay.Update(ctx, cm)).To(Succeed())
+
+		read := &corev1.ConfigMap{}
+		Expect(overlay.Get(ctx, client.ObjectKey{Namespace: "default", Name: "existing"}, read)).To(Succeed())
+		Expect(read.Data).To(HaveKeyWithValue("updated", "true"))
+	})
+
+	It("should not find the objects deleted through it, unless they have finalizers", func() {
+		Expect(overlay.Delete(ctx, configMap("existing"))).To(Succeed())
+		err := overlay.Get(ctx, client.ObjectKey{Namespace: "default", Name: "existing"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		Expect(overlay.Delete(ctx, configMap("finalized", "test"))).To(Succeed())
+		Expect(overlay.Get(ctx, client.ObjectKey{Namespace: "default", Name: "finalized"}, &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should merge the writes into lists", func() {
+		Expect(overlay.Create(ctx, configMap("created"))).To(Succeed())
+		Expect(overlay.Delete(ctx, configMap("existing"))).To(Succeed())
+		unlabelled := configMap("unlabelled")
+		unlabelled.Labels = nil
+		Expect(overlay.Create(ctx, unlabelled)).To(Succeed())
+
+		list := &corev1.ConfigMapList{}
+		Expect(overlay.List(ctx, list, client.InNamespace("default"), client.MatchingLabels(map[string]string{"app": "test"}))).To(Succeed())
+		var names []string
+		for _, cm := range list.Items {
+			names = append(names, cm.Name)
+		}
+		Expect(names).To(ConsistOf("created", "finalized"))
+	})
+})
@@ -0,0 +1,260 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// RateLimiter limits the rate of the requests of the Clients created with it as their Options.RateLimiter,
+// with a QPS and burst which can be adjusted while the Clients are in use, e.g. to throttle an operator
+// overwhelming the API server during an incident without redeploying it.  It applies on top of the QPS and
+// Burst of the rest.Config of the Clients, so it can only tighten those.
+//
+// It serves its limits as JSON, e.g. {"qps":5,"burst":10}, to GET requests, and sets them from the same
+// JSON in PUT requests.  It doesn't authenticate them, so only mount it on a server restricted to the users
+// allowed to adjust the limits, e.g. one bound to a loopback address.  Its limits are exported in the
+// controller_runtime_client_rate_limit_qps and controller_runtime_client_rate_limit_burst metrics, and how
+// long requests waited for it in the controller_runtime_client_rate_limiter_wait_seconds metric.
+//
+// Only the requests of the Clients are limited: the watches and lists of caches use their own rest.Config.
+type RateLimiter struct {
+	name string
+
+	mu      sync.Mutex
+	limits  RateLimits
+	limiter *rate.Limiter
+}
+
+// RateLimits are the limits of a RateLimiter.
+type RateLimits struct {
+	// QPS is how many requests are allowed per second on average.  0 disables the limit.
+	QPS float32 `json:"qps"`
+
+	// Burst is how many requests are allowed at once.  It must be at least 1 if QPS is set.
+	Burst int `json:"burst"`
+}
+
+// Validate returns an error if the limits are invalid.
+func (l RateLimits) Validate() error {
+	if l.QPS < 0 {
+		return fmt.Errorf("invalid QPS %v, must not be negative", l.QPS)
+	}
+	if l.QPS > 0 && l.Burst < 1 {
+		return fmt.Errorf("invalid burst %d, must be at least 1 with a QPS", l.Burst)
+	}
+	return nil
+}
+
+// NewRateLimiter returns a RateLimiter with the given limits, whose metrics are labeled with name.
+func NewRateLimiter(name string, limits RateLimits) (*RateLimiter, error) {
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+	l := &RateLimiter{name: name}
+	l.apply(limits)
+	return l, nil
+}
+
+// Limits returns the current limits.
+func (l *RateLimiter) Limits() RateLimits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+// SetLimits replaces the limits.  The requests waiting already are still allowed with the old limits.
+func (l *RateLimiter) SetLimits(limits RateLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	l.apply(limits)
+	log.Info("Set the client rate limits", "limiter", l.name, "qps", limits.QPS, "burst", limits.Burst)
+	return nil
+}
+
+func (l *RateLimiter) apply(limits RateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	if limits.QPS == 0 {
+		l.limiter = rate.NewLimiter(rate.Inf, 0)
+	} else {
+		l.limiter = rate.NewLimiter(rate.Limit(limits.QPS), limits.Burst)
+	}
+	RateLimitQPS.WithLabelValues(l.name).Set(float64(limits.QPS))
+	RateLimitBurst.WithLabelValues(l.name).Set(float64(limits.Burst))
+}
+
+// wait blocks until a request is allowed, or ctx is done.
+func (l *RateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	limiter := l.limiter
+	l.mu.Unlock()
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	RateLimiterWait.WithLabelValues(l.name).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// ServeHTTP implements http.Handler
+func (l *RateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var limits RateLimits
+		if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+			http.Error(w, fmt.Sprintf("unable to decode the rate limits: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := l.SetLimits(limits); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(l.Limits()); err != nil {
+		log.Error(err, "Failed to write the client rate limits", "limiter", l.name)
+	}
+}
+
+var (
+	// RateLimitQPS is a prometheus gauge metric which holds the QPS of the client rate limiters, 0 if they
+	// don't limit the requests.
+	RateLimitQPS = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "controller_runtime_client_rate_limit_qps",
+		Help: "QPS of the client rate limiters, 0 if unlimited",
+	}, []string{"limiter"})
+
+	// RateLimitBurst is a prometheus gauge metric which holds the burst of the client rate limiters.
+	RateLimitBurst = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "controller_runtime_client_rate_limit_burst",
+		Help: "Burst of the client rate limiters",
+	}, []string{"limiter"})
+
+	// RateLimiterWait is a prometheus histogram metric which holds how long the requests of clients waited
+	// for their rate limiter.
+	RateLimiterWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "controller_runtime_client_rate_limiter_wait_seconds",
+		Help: "Length of time requests of clients waited for their rate limiter",
+	}, []string{"limiter"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(RateLimitQPS, RateLimitBurst, RateLimiterWait)
+}
+
+// WithRateLimiter returns a Client whose requests through c wait for limiter, e.g. to limit a Client which
+// isn't created by New.  Wrapping a Client which reads from a cache limits the reads from the cache too.
+func WithRateLimiter(c Client, limiter *RateLimiter) Client {
+	return &rateLimitedClient{Client: c, limiter: limiter}
+}
+
+// rateLimitedClient is a Client whose requests wait for a RateLimiter.
+type rateLimitedClient struct {
+	Client
+	limiter *RateLimiter
+}
+
+// Create implements Writer
+func (c *rateLimitedClient) Create(ctx context.Context, obj runtime.Object, opts ...CreateOptionFunc) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Update implements Writer
+func (c *rateLimitedClient) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Delete implements Writer
+func (c *rateLimitedClient) Delete(ctx context.Context, obj runtime.Object, opts ...DeleteOptionFunc) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// Patch implements Writer
+func (c *rateLimitedClient) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Get implements Reader
+func (c *rateLimitedClient) Get(ctx context.Context, key ObjectKey, obj runtime.Object) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+// List implements Reader
+func (c *rateLimitedClient) List(ctx context.Context, list runtime.Object, opts ...ListOptionFunc) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+// Status implements StatusClient
+func (c *rateLimitedClient) Status() StatusWriter {
+	return &rateLimitedStatusWriter{StatusWriter: c.Client.Status(), limiter: c.limiter}
+}
+
+// rateLimitedStatusWriter is a StatusWriter whose requests wait for a RateLimiter.
+type rateLimitedStatusWriter struct {
+	StatusWriter
+	limiter *RateLimiter
+}
+
+// Update implements StatusWriter
+func (sw *rateLimitedStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...UpdateOptionFunc) error {
+	if err := sw.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return sw.StatusWriter.Update(ctx, obj, opts...)
+}
+
+// Patch implements StatusWriter
+func (sw *rateLimitedStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch Patch, opts ...PatchOptionFunc) error {
+	if err := sw.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return sw.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("RateLimiter", func() {
+	var limiter *client.RateLimiter
+	var limited client.Client
+	key := client.ObjectKey{Namespace: "default", Name: "foo"}
+
+	get := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return limited.Get(ctx, key, &corev1.ConfigMap{})
+	}
+
+	BeforeEach(func() {
+		var err error
+		limiter, err = client.NewRateLimiter("test", client.RateLimits{QPS: 0.1, Burst: 1})
+		Expect(err).NotTo(HaveOccurred())
+		limited = client.WithRateLimiter(fake.NewFakeClient(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		}), limiter)
+	})
+
+	It("should limit the requests until its limits are loosened", func() {
+		Expect(get(time.Second)).To(Succeed())
+		Expect(get(100 * time.Millisecond)).NotTo(Succeed())
+
+		Expect(limiter.SetLimits(client.RateLimits{QPS: 0})).To(Succeed())
+		Expect(get(100 * time.Millisecond)).To(Succeed())
+		Expect(get(100 * time.Millisecond)).To(Succeed())
+	})
+
+	It("should serve and set its limits over HTTP", func() {
+		w := httptest.NewRecorder()
+		limiter.ServeHTTP(w, httptest.NewRequest("GET", "/client-rate-limit", nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(MatchJSON(`{"qps":0.1,"burst":1}`))
+
+		w = httptest.NewRecorder()
+		limiter.ServeHTTP(w, httptest.NewRequest("PUT", "/client-rate-limit", strings.NewReader(`{"qps":5,"burst":10}`)))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(MatchJSON(`{"qps":5,"burst":10}`))
+		Expect(limiter.Limits()).To(Equal(client.RateLimits{QPS: 5, Burst: 10}))
+
+		By("refusing invalid limits")
+		w = httptest.NewRecorder()
+		limiter.ServeHTTP(w, httptest.NewRequest("PUT", "/client-rate-limit", strings.NewReader(`{"qps":5,"burst":0}`)))
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(limiter.Limits()).To(Equal(client.RateLimits{QPS: 5, Burst: 10}))
+
+		w = httptest.NewRecorder()
+		limiter.ServeHTTP(w, httptest.NewRequest("POST", "/client-rate-limit", nil))
+		Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("should refuse invalid limits", func() {
+		_, err := client.NewRateLimiter("test", client.RateLimits{QPS: -1})
+		Expect(err).To(MatchError(ContainSubstring("invalid QPS")))
+	})
+})
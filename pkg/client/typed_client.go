@@ -18,8 +18,11 @@ package client
 
 import (
 	"context"
+	"reflect"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
 )
 
 // client is a client.Client that reads and writes directly from/to an API server.  It lazily initializes
@@ -27,6 +30,10 @@ import (
 type typedClient struct {
 	cache      clientCache
 	paramCodec runtime.ParameterCodec
+
+	// unstructuredFallback, if true, returns the objects which fail to decode as unstructured in their
+	// ConversionErrors.
+	unstructuredFallback bool
 }
 
 // Create implements client.Client
@@ -116,11 +123,12 @@ func (c *typedClient) Get(ctx context.Context, key ObjectKey, obj runtime.Object
 	if err != nil {
 		return err
 	}
-	return r.Get().
+	result := r.Get().
 		NamespaceIfScoped(key.Namespace, r.isNamespaced()).
 		Resource(r.resource()).
 		Context(ctx).
-		Name(key.Name).Do().Into(obj)
+		Name(key.Name).Do()
+	return c.into(result, key, obj)
 }
 
 // List implements client.Client
@@ -131,13 +139,39 @@ func (c *typedClient) List(ctx context.Context, obj runtime.Object, opts ...List
 	}
 	listOpts := ListOptions{}
 	listOpts.ApplyOptions(opts)
-	return r.Get().
+	result := r.Get().
 		NamespaceIfScoped(listOpts.Namespace, r.isNamespaced()).
 		Resource(r.resource()).
 		VersionedParams(listOpts.AsListOptions(), c.paramCodec).
 		Context(ctx).
-		Do().
-		Into(obj)
+		Do()
+	return c.into(result, ObjectKey{}, obj)
+}
+
+// into decodes the object read with result into obj, turning errors decoding the object into
+// ConversionErrors telling which object and field failed to decode.
+func (c *typedClient) into(result rest.Result, key ObjectKey, obj runtime.Object) error {
+	err := result.Into(obj)
+	if err == nil || result.Error() != nil {
+		// Errors of the request itself aren't about decoding.
+		return err
+	}
+
+	convErr := &ConversionError{Key: key, Target: reflect.TypeOf(obj), Field: failingField(err, reflect.TypeOf(obj)), Err: err}
+	raw, rawErr := result.Raw()
+	if rawErr != nil {
+		return convErr
+	}
+	// Find out what the server sent, which only works for JSON responses.
+	u, _, decodeErr := unstructured.UnstructuredJSONScheme.Decode(raw, nil, nil)
+	if decodeErr != nil {
+		return convErr
+	}
+	convErr.GroupVersionKind = u.GetObjectKind().GroupVersionKind()
+	if c.unstructuredFallback {
+		convErr.Object = u
+	}
+	return convErr
 }
 
 // UpdateStatus used by StatusWriter to write status.
@@ -0,0 +1,226 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// Cluster provides the clients and the cache of a Kubernetes cluster.  It is a manager.Runnable, running
+// its cache once added to a Manager.
+type Cluster interface {
+	// GetConfig returns an initialized Config
+	GetConfig() *rest.Config
+
+	// GetScheme returns an initialized Scheme
+	GetScheme() *runtime.Scheme
+
+	// GetClient returns a client configured with the Config, which reads from the cache and writes to the
+	// API server.
+	GetClient() client.Client
+
+	// GetFieldIndexer returns a client.FieldIndexer configured with the client
+	GetFieldIndexer() client.FieldIndexer
+
+	// GetCache returns a cache.Cache
+	GetCache() cache.Cache
+
+	// GetRESTMapper returns a RESTMapper
+	GetRESTMapper() meta.RESTMapper
+
+	// GetAPIReader returns a reader that will be configured to use the API server.
+	// This should be used sparingly and only when the client does not fit your
+	// use case.
+	GetAPIReader() client.Reader
+
+	// Start starts the cache, and blocks until stop is closed.
+	Start(stop <-chan struct{}) error
+
+	// NeedLeaderElection implements manager.LeaderElectionRunnable.  It returns false, so the cache is
+	// started whether or not the Manager is the leader.
+	NeedLeaderElection() bool
+}
+
+// Options are the arguments for creating a new Cluster
+type Options struct {
+	// Scheme is the scheme used to resolve runtime.Objects to GroupVersionKinds / Resources
+	// Defaults to the kubernetes/client-go scheme.Scheme.
+	Scheme *runtime.Scheme
+
+	// MapperProvider provides the rest mapper used to map go types to Kubernetes APIs
+	MapperProvider func(c *rest.Config) (meta.RESTMapper, error)
+
+	// SyncPeriod determines the minimum frequency at which watched resources of the cluster are
+	// resynced.  Defaults to 10 hours if unset.
+	SyncPeriod *time.Duration
+
+	// Namespace if specified restricts the cache to watch objects in the desired namespace.
+	// Defaults to all namespaces.
+	Namespace string
+
+	// NewCache is the function that will create the cache to be used
+	NewCache NewCacheFunc
+
+	// NewClient will create the client to be used.  Defaults to a client reading from the cache and
+	// writing to the API server.
+	NewClient NewClientFunc
+}
+
+// NewCacheFunc allows a user to define how to create a cache
+type NewCacheFunc func(config *rest.Config, opts cache.Options) (cache.Cache, error)
+
+// NewClientFunc allows a user to define how to create a client
+type NewClientFunc func(cache cache.Cache, config *rest.Config, options client.Options) (client.Client, error)
+
+// New returns a new Cluster for the API server of config.
+func New(config *rest.Config, options Options) (Cluster, error) {
+	if config == nil {
+		return nil, fmt.Errorf("must specify Config")
+	}
+
+	options = setOptionsDefaults(options)
+
+	mapper, err := options.MapperProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := options.NewCache(config, cache.Options{Scheme: options.Scheme, Mapper: mapper, Resync: options.SyncPeriod, Namespace: options.Namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	apiReader, err := client.New(config, client.Options{Scheme: options.Scheme, Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+
+	writeObj, err := options.NewClient(c, config, client.Options{Scheme: options.Scheme, Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cluster{
+		config:    config,
+		scheme:    options.Scheme,
+		mapper:    mapper,
+		cache:     c,
+		client:    writeObj,
+		apiReader: apiReader,
+	}, nil
+}
+
+// NewForContext returns a new Cluster for the context with the given name of the kubeconfig, which is
+// located as described by config.GetConfigWithContext.
+func NewForContext(context string, options Options) (Cluster, error) {
+	cfg, err := config.GetConfigWithContext(context)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg, options)
+}
+
+// defaultNewClient creates the default caching client
+func defaultNewClient(cache cache.Cache, config *rest.Config, options client.Options) (client.Client, error) {
+	c, err := client.New(config, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.DelegatingClient{
+		Reader: &client.DelegatingReader{
+			CacheReader:  cache,
+			ClientReader: c,
+		},
+		Writer:       c,
+		StatusClient: c,
+	}, nil
+}
+
+// setOptionsDefaults set default values for Options fields
+func setOptionsDefaults(options Options) Options {
+	if options.Scheme == nil {
+		options.Scheme = scheme.Scheme
+	}
+	if options.MapperProvider == nil {
+		options.MapperProvider = apiutil.NewDiscoveryRESTMapper
+	}
+	if options.NewCache == nil {
+		options.NewCache = cache.New
+	}
+	if options.NewClient == nil {
+		options.NewClient = defaultNewClient
+	}
+	return options
+}
+
+var _ Cluster = &cluster{}
+
+type cluster struct {
+	config    *rest.Config
+	scheme    *runtime.Scheme
+	mapper    meta.RESTMapper
+	cache     cache.Cache
+	client    client.Client
+	apiReader client.Reader
+}
+
+func (c *cluster) GetConfig() *rest.Config {
+	return c.config
+}
+
+func (c *cluster) GetScheme() *runtime.Scheme {
+	return c.scheme
+}
+
+func (c *cluster) GetClient() client.Client {
+	return c.client
+}
+
+func (c *cluster) GetFieldIndexer() client.FieldIndexer {
+	return c.cache
+}
+
+func (c *cluster) GetCache() cache.Cache {
+	return c.cache
+}
+
+func (c *cluster) GetRESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+func (c *cluster) GetAPIReader() client.Reader {
+	return c.apiReader
+}
+
+func (c *cluster) Start(stop <-chan struct{}) error {
+	return c.cache.Start(stop)
+}
+
+func (c *cluster) NeedLeaderElection() bool {
+	return false
+}
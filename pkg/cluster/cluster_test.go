@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("cluster", func() {
+	Describe("New", func() {
+		It("should return an error if there is no Config", func() {
+			c, err := New(nil, Options{})
+			Expect(c).To(BeNil())
+			Expect(err.Error()).To(ContainSubstring("must specify Config"))
+		})
+
+		It("should return an error if it can't create a RestMapper", func() {
+			expected := fmt.Errorf("expected error: RestMapper")
+			c, err := New(cfg, Options{
+				MapperProvider: func(c *rest.Config) (meta.RESTMapper, error) { return nil, expected },
+			})
+			Expect(c).To(BeNil())
+			Expect(err).To(Equal(expected))
+		})
+
+		It("should not need leader election", func() {
+			c, err := New(cfg, Options{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.NeedLeaderElection()).To(BeFalse())
+		})
+	})
+
+	Describe("Start", func() {
+		It("should read objects from the cache once started", func(done Done) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "cluster-"}}
+			ns, err := clientset.CoreV1().Namespaces().Create(ns)
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err := New(cfg, Options{})
+			Expect(err).NotTo(HaveOccurred())
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				defer GinkgoRecover()
+				Expect(c.Start(stop)).To(Succeed())
+			}()
+			Expect(c.GetCache().WaitForCacheSync(stop)).To(BeTrue())
+
+			Eventually(func() error {
+				return c.GetClient().Get(context.TODO(), client.ObjectKey{Name: ns.Name}, &corev1.Namespace{})
+			}).Should(Succeed())
+
+			close(done)
+		}, 10)
+	})
+})
@@ -0,0 +1,34 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package cluster provides the clients and caches of a cluster other than the one of a Manager, for controllers
+which reconcile objects in one cluster based on the state of another.
+
+A Cluster is created for a kubeconfig context and added to the Manager, which starts and stops its cache:
+
+	other, err := cluster.NewForContext("cluster-b", cluster.Options{})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(other); err != nil {
+		return err
+	}
+
+The Reconciler then reads from other.GetClient() next to the Manager's client, and Controllers can watch
+objects of the other cluster with source.NewKindWithCache(&corev1.Secret{}, other.GetCache()).
+*/
+package cluster
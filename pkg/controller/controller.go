@@ -18,8 +18,14 @@ package controller
 
 import (
 	"fmt"
+	"time"
 
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/audit"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/internal/controller"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -35,8 +41,176 @@ type Options struct {
 
 	// Reconciler reconciles an object
 	Reconciler reconcile.Reconciler
+
+	// CancelOnNewerVersion, if true, cancels the context of an in-flight reconcile when an update with a
+	// newer resourceVersion of the same object is enqueued, and reprocesses the Request afterwards.
+	// The Reconciler must implement reconcile.ContextReconciler and respect context cancellation for this
+	// to have any effect.  Defaults to false.
+	CancelOnNewerVersion bool
+
+	// BatchKey, if set, groups the Requests enqueued by all watches by the key it returns, and the
+	// Requests sharing a key are reconciled together in a single call.  The Reconciler must implement
+	// reconcile.BatchReconciler.  Defaults to nil, reconciling every Request on its own.
+	BatchKey func(reconcile.Request) string
+
+	// For is the type of the objects reconciled by the Reconciler (e.g. &appsv1.Deployment{}).  It is required
+	// by AllEnqueuer.EnqueueAll, and the updates of the watched objects of this type start their
+	// reconcile.ProgressiveRequeues over.  Defaults to nil.
+	For runtime.Object
+
+	// ObservedGenerationFor, if set, is the type of the objects reconciled by the Reconciler (e.g. &appsv1.Deployment{}).
+	// After every successful reconcile which doesn't requeue, the Controller then sets the status.observedGeneration
+	// of the reconciled object to the metadata.generation it had when the reconcile started with
+	// controllerutil.SetObservedGeneration, and patches the status if it changed.  Only observedGeneration is
+	// patched, so this doesn't conflict with the status written by the Reconciler.  The patch is skipped if the
+	// generation changed during the reconcile, leaving it to the reconcile of the new generation.  Only use this
+	// if the Reconciler doesn't track observedGeneration itself.
+	// Defaults to nil, leaving observedGeneration alone.
+	ObservedGenerationFor runtime.Object
+
+	// HealthCheck, if set, is polled every HealthCheckPeriod to check the external dependencies of the
+	// Reconciler (e.g. a database).  While it returns an error, the Controller stops reconciling, and
+	// enqueued Requests wait in the queue rather than failing and backing off one by one.  Reconciling
+	// resumes once HealthCheck succeeds.  No Request is reconciled before HealthCheck first succeeded.
+	// Defaults to nil, never pausing.
+	HealthCheck func() error
+
+	// HealthCheckPeriod is how often HealthCheck is polled.  Defaults to 10 seconds.
+	HealthCheckPeriod time.Duration
+
+	// PriorityQueue, if true, makes the Controller reconcile the Requests with the highest priority
+	// first.  Requests get a priority from their EventHandler, e.g. with handler.WithPriorityFromLabel,
+	// and handler.DefaultPriority otherwise.  With BatchKey, a batch gets the highest priority of its
+	// Requests.  The queue doesn't expose workqueue metrics.  Defaults to false, reconciling Requests in
+	// the order they are enqueued.
+	PriorityQueue bool
+
+	// MaxBackoff, if positive, caps the delay with which a Request is retried after its reconcile failed
+	// repeatedly.  The overall rate limit of the queue, 10 Requests per second after a burst of 100, is
+	// unaffected.  Defaults to 0, letting the delay grow exponentially up to 1000 seconds.
+	MaxBackoff time.Duration
+
+	// ResetBackoffOnUpdate, if true, lets an update of an object carrying a newer resourceVersion end the
+	// backoff of its Request, which is then reconciled right away, since the new data may fix the failure.
+	// Defaults to false, keeping the Request waiting for the rest of its backoff.
+	ResetBackoffOnUpdate bool
+
+	// MetricsClass, if set, maps the Request of every reconcile to a class, e.g. the namespace of the object to
+	// the class of its tenant, and the reconciles are additionally counted and timed per class by the
+	// controller_runtime_reconcile_class_total and controller_runtime_reconcile_class_time_seconds metrics.
+	// Classes which aren't in MetricsClasses are counted as "other", which bounds the number of series.
+	// MetricsClass is called for every reconcile, so it should be cheap.  Defaults to nil.
+	MetricsClass func(reconcile.Request) string
+
+	// MetricsClasses are the values of MetricsClass which are kept in the metrics.  It is required with
+	// MetricsClass.
+	MetricsClasses []string
+
+	// RecordTriggerEvents, if true, makes the event which enqueued a Request (its verb, the old resourceVersion
+	// and the top-level fields changed by an update) available to Reconcilers implementing
+	// reconcile.ContextReconciler through reconcile.TriggerEventFromContext.  Requests enqueued by several
+	// events meanwhile, requeued or enqueued by EnqueueAll get an event with the verb
+	// reconcile.TriggerUnknown.  Updates are compared field by field, so this costs some CPU for every update
+	// event.  It has no effect with BatchKey.  Defaults to false.
+	RecordTriggerEvents bool
+
+	// RecordQueueWait, if true, measures how long Requests wait in the queue from the event enqueueing them
+	// until their reconcile starts, separately from the duration of the reconcile, in the
+	// controller_runtime_reconcile_queue_wait_seconds metric.  Reconcilers implementing
+	// reconcile.ContextReconciler get the time with reconcile.EnqueueTimeFromContext, e.g. to start a trace
+	// span covering the wait.  Requests which were only requeued aren't measured.  It has no effect with
+	// BatchKey.  Defaults to false.
+	RecordQueueWait bool
+
+	// ReadYourWrites, if true, gives every reconcile a Client whose reads see the objects created, updated,
+	// patched and deleted through it during the same reconcile, even before the cache has observed the
+	// writes.  Only Reconcilers implementing reconcile.ContextReconciler which get their Client with
+	// reconcile.ClientFromContext see it.  Writes of others remain subject to the staleness of the cache.
+	// Defaults to false.
+	ReadYourWrites bool
+
+	// StatusFlushInterval, if positive, gives every reconcile a client.BatchingStatusWriter which defers the
+	// status updates made through it, and writes them every StatusFlushInterval from the Manager, coalescing
+	// the updates of the same object within an interval into a single update of the latest status.
+	// Conflicting updates are retried with the latest version of the object.  This reduces the writes of
+	// frequent, status-only reconciles, at the cost of their status being written late and their errors
+	// only being logged.  The pending status is written once the Manager stops, so the Manager needs a
+	// GracefulShutdownTimeout giving the final flush time to finish.  Only Reconcilers implementing
+	// reconcile.ContextReconciler which get their StatusWriter with reconcile.StatusWriterFromContext use it.
+	// Defaults to 0, writing the status right away.
+	StatusFlushInterval time.Duration
+
+	// DefaultRequeueAfterByType maps the types of objects to how long after a successful reconcile their
+	// Requests are reconciled again by default, e.g. for a periodic resync of some types only.  The entry for
+	// the GroupVersionKind of For applies, so For is required with it.  The default only applies to reconciles
+	// returning an empty Result and no error: Results with Requeue or RequeueAfter set, and errors, take
+	// precedence over it.  Requests of objects which are gone from the cache aren't requeued.  Defaults to
+	// nil, only reconciling Requests again once they are enqueued.
+	DefaultRequeueAfterByType map[schema.GroupVersionKind]time.Duration
+
+	// KeyParser, if set, parses every Request into a key which Reconcilers implementing
+	// reconcile.ContextReconciler get with reconcile.KeyFromContext, so that they don't have to parse
+	// structured object names themselves.  A Request failing to parse is logged, counted with the result
+	// "invalid_key" and dropped without being retried: its object name won't change.  Defaults to nil,
+	// making the key the NamespacedName of the Request.
+	KeyParser reconcile.KeyParser
+
+	// AuditSink, if set, receives an audit.Record of every reconcile: the key of the object, the result, the
+	// error, the duration and a correlation ID which Reconcilers implementing reconcile.ContextReconciler get
+	// with reconcile.CorrelationIDFromContext.  Records are buffered and written in batches by an
+	// audit.Batcher added to the Manager, so a slow or failing sink never blocks or fails reconciles: records
+	// are dropped instead, and counted by the controller_runtime_audit_records_total metric.  Reconciles of
+	// batches aren't audited.  Defaults to nil.
+	AuditSink audit.Sink
+
+	// AuditOptions configure the batching of the records written to AuditSink.
+	AuditOptions audit.Options
+
+	// MinReconcileInterval, if positive, is a floor on how often the same Request is reconciled,
+	// independently of other Requests: a Request dequeued sooner than this after the start of its last
+	// reconcile is delayed until the interval has passed, and the events received meanwhile are coalesced
+	// into a single reconcile.  This keeps objects which are updated constantly from monopolizing the
+	// workers.  Unlike the rate limiting of failures, it applies to every reconcile.  It has no effect with
+	// BatchKey.  Defaults to 0, reconciling Requests as soon as they are dequeued.
+	MinReconcileInterval time.Duration
+
+	// RequeueReasons are the reasons which Reconcilers give for requeues with reconcile.Result.RequeueReason,
+	// e.g. with reconcile.RequeueAfterWithReason.  Requeues giving a reason are logged and counted per reason
+	// by the controller_runtime_reconcile_requeue_total metric, where reasons which aren't declared here are
+	// counted as "other", which bounds the number of series.  Reasons of batches aren't counted.  Defaults to
+	// nil, counting all reasons as "other".
+	RequeueReasons []string
+
+	// CancelOnShutdown, if true, cancels the context of the reconciles in flight once the Manager stops, and
+	// keeps the Controller from returning until they have.  Reconcilers implementing
+	// reconcile.ContextReconciler can register save-points with reconcile.RegisterSavePoint, which are called
+	// once a reconcile cancelled by the shutdown has returned, to persist its progress, e.g. in a status
+	// field, so that it resumes from there after a restart instead of redoing its work.  The Manager only
+	// waits for the Controller as long as its GracefulShutdownTimeout.  Requests dequeued once the shutdown
+	// has begun aren't reconciled anymore.  Reconciles of batches aren't cancelled.  Defaults to false,
+	// leaving reconciles running until the process exits.
+	CancelOnShutdown bool
+
+	// MaxProgressiveRequeueAfter, if positive, is the ceiling of the delays of the requeues of the
+	// reconcile.ProgressiveRequeue Results of the Controller, over their own Max.  It bounds how stale an
+	// object polled with backoff may get, whichever Progression its Reconciler returns.  Defaults to 0,
+	// capping the delays only by the Max of their Progression.
+	MaxProgressiveRequeueAfter time.Duration
+}
+
+// AllEnqueuer is implemented by the Controllers which can enqueue all the objects they reconcile, such as
+// the ones returned by New.
+type AllEnqueuer interface {
+	// EnqueueAll enqueues a reconcile.Request for every object of the For type in the cache, e.g. to
+	// reconcile all objects again after a change to some global configuration.  The Requests are enqueued
+	// like the ones of events, paced so that the objects are not all reconciled at once.  Reconcilers
+	// implementing reconcile.ContextReconciler can also get this function with
+	// reconcile.EnqueueAllFromContext.
+	EnqueueAll() error
 }
 
+var _ AllEnqueuer = &controller.Controller{}
+
 // Controller implements a Kubernetes API.  A Controller manages a work queue fed reconcile.Requests
 // from source.Sources.  Work is performed through the reconcile.Reconciler for each enqueued item.
 // Work typically is reads and writes Kubernetes objects to make the system state match the state specified
@@ -65,10 +239,36 @@ func New(name string, mgr manager.Manager, options Options) (Controller, error)
 		return nil, fmt.Errorf("must specify Reconciler")
 	}
 
+	if _, ok := options.Reconciler.(reconcile.BatchReconciler); options.BatchKey != nil && !ok {
+		return nil, fmt.Errorf("must specify a Reconciler implementing reconcile.BatchReconciler with BatchKey")
+	}
+
 	if len(name) == 0 {
 		return nil, fmt.Errorf("must specify Name for Controller")
 	}
 
+	if options.MetricsClass != nil && len(options.MetricsClasses) == 0 {
+		return nil, fmt.Errorf("must specify MetricsClasses with MetricsClass")
+	}
+
+	for _, reason := range options.RequeueReasons {
+		if len(reason) == 0 {
+			return nil, fmt.Errorf("must not specify empty RequeueReasons")
+		}
+	}
+
+	var defaultRequeueAfter time.Duration
+	if options.DefaultRequeueAfterByType != nil {
+		if options.For == nil {
+			return nil, fmt.Errorf("must specify For with DefaultRequeueAfterByType")
+		}
+		gvk, err := apiutil.GVKForObject(options.For, mgr.GetScheme())
+		if err != nil {
+			return nil, err
+		}
+		defaultRequeueAfter = options.DefaultRequeueAfterByType[gvk]
+	}
+
 	if options.MaxConcurrentReconciles <= 0 {
 		options.MaxConcurrentReconciles = 1
 	}
@@ -78,17 +278,74 @@ func New(name string, mgr manager.Manager, options Options) (Controller, error)
 		return nil, err
 	}
 
+	rateLimiter := workqueue.DefaultControllerRateLimiter()
+	if options.MaxBackoff > 0 {
+		rateLimiter = controller.NewDefaultRateLimiter(options.MaxBackoff)
+	}
+
+	var queue workqueue.RateLimitingInterface
+	if options.PriorityQueue {
+		queue = controller.NewPriorityQueue(rateLimiter)
+	} else {
+		queue = workqueue.NewNamedRateLimitingQueue(rateLimiter, name)
+	}
+	// Let fresh events supersede pending RequeueAfters, rather than reconciling twice
+	queue = controller.NewDelayDedupingQueue(queue)
+
+	var metricsClass func(reconcile.Request) string
+	if options.MetricsClass != nil {
+		metricsClass = controller.NewMetricsClass(options.MetricsClass, options.MetricsClasses)
+	}
+
+	var recordAudit func(audit.Record)
+	if options.AuditSink != nil {
+		batcher := audit.NewBatcher(options.AuditSink, options.AuditOptions)
+		if err := mgr.Add(batcher); err != nil {
+			return nil, err
+		}
+		recordAudit = batcher.Record
+	}
+
+	var statusWriter client.StatusWriter
+	if options.StatusFlushInterval > 0 {
+		batching := client.NewBatchingStatusWriter(mgr.GetClient(), mgr.GetAPIReader(), mgr.GetScheme(),
+			options.StatusFlushInterval)
+		if err := mgr.Add(batching); err != nil {
+			return nil, err
+		}
+		statusWriter = batching
+	}
+
 	// Create controller with dependencies set
 	c := &controller.Controller{
-		Do:                      options.Reconciler,
-		Cache:                   mgr.GetCache(),
-		Config:                  mgr.GetConfig(),
-		Scheme:                  mgr.GetScheme(),
-		Client:                  mgr.GetClient(),
-		Recorder:                mgr.GetEventRecorderFor(name),
-		Queue:                   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
-		MaxConcurrentReconciles: options.MaxConcurrentReconciles,
-		Name:                    name,
+		Do:                         options.Reconciler,
+		Cache:                      mgr.GetCache(),
+		Config:                     mgr.GetConfig(),
+		Scheme:                     mgr.GetScheme(),
+		Client:                     mgr.GetClient(),
+		Recorder:                   mgr.GetEventRecorderFor(name),
+		Queue:                      queue,
+		MaxConcurrentReconciles:    options.MaxConcurrentReconciles,
+		CancelOnNewerVersion:       options.CancelOnNewerVersion,
+		ResetBackoffOnUpdate:       options.ResetBackoffOnUpdate,
+		MetricsClass:               metricsClass,
+		RecordTriggerEvents:        options.RecordTriggerEvents,
+		RecordQueueWait:            options.RecordQueueWait,
+		ReadYourWrites:             options.ReadYourWrites,
+		StatusWriter:               statusWriter,
+		DefaultRequeueAfter:        defaultRequeueAfter,
+		KeyParser:                  options.KeyParser,
+		Audit:                      recordAudit,
+		BatchKey:                   options.BatchKey,
+		For:                        options.For,
+		ObservedGenerationFor:      options.ObservedGenerationFor,
+		HealthCheck:                options.HealthCheck,
+		HealthCheckPeriod:          options.HealthCheckPeriod,
+		MinReconcileInterval:       options.MinReconcileInterval,
+		RequeueReasons:             options.RequeueReasons,
+		CancelOnShutdown:           options.CancelOnShutdown,
+		MaxProgressiveRequeueAfter: options.MaxProgressiveRequeueAfter,
+		Name:                       name,
 	}
 
 	// Add the controller as a Manager components
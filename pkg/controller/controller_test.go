@@ -18,9 +18,12 @@ package controller_test
 
 import (
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -64,6 +67,50 @@ var _ = Describe("controller.Controller", func() {
 			close(done)
 		})
 
+		It("should return an error if BatchKey is specified for a Reconciler which can't reconcile batches", func(done Done) {
+			m, err := manager.New(cfg, manager.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err := controller.New("foo", m, controller.Options{
+				Reconciler: rec,
+				BatchKey:   func(r reconcile.Request) string { return r.Namespace },
+			})
+			Expect(c).To(BeNil())
+			Expect(err.Error()).To(ContainSubstring("must specify a Reconciler implementing reconcile.BatchReconciler"))
+
+			close(done)
+		})
+
+		It("should return an error if MetricsClass is specified without MetricsClasses", func(done Done) {
+			m, err := manager.New(cfg, manager.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err := controller.New("foo", m, controller.Options{
+				Reconciler:   rec,
+				MetricsClass: func(r reconcile.Request) string { return r.Namespace },
+			})
+			Expect(c).To(BeNil())
+			Expect(err.Error()).To(ContainSubstring("must specify MetricsClasses with MetricsClass"))
+
+			close(done)
+		})
+
+		It("should return an error if DefaultRequeueAfterByType is specified without For", func(done Done) {
+			m, err := manager.New(cfg, manager.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err := controller.New("foo", m, controller.Options{
+				Reconciler: rec,
+				DefaultRequeueAfterByType: map[schema.GroupVersionKind]time.Duration{
+					corev1.SchemeGroupVersion.WithKind("Pod"): time.Hour,
+				},
+			})
+			Expect(c).To(BeNil())
+			Expect(err.Error()).To(ContainSubstring("must specify For with DefaultRequeueAfterByType"))
+
+			close(done)
+		})
+
 		It("NewController should return an error if injecting Reconciler fails", func(done Done) {
 			m, err := manager.New(cfg, manager.Options{})
 			Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,203 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Bus lets the Reconcilers of a manager enqueue Requests for each other's controllers by name, e.g. to have
+// controller-b reconcile a derived object once controller-a is done, without watching some synthetic
+// object.  Controllers receive the Requests by watching the Source the Bus returns for their name:
+//
+//	bus := controllerutil.NewBus(time.Second)
+//	a, err := controller.New("controller-a", mgr, controller.Options{Reconciler: bus.Reconciler(r)})
+//	b, err := controller.New("controller-b", mgr, controller.Options{Reconciler: rb})
+//	err = b.Watch(bus.Source("controller-b"), &handler.EnqueueRequestForObject{})
+//
+// and Reconcilers of controller-a then call
+//
+//	err := controllerutil.BusFromContext(ctx).Enqueue("controller-b", req)
+//
+// Requests are delivered as GenericEvents carrying their name and namespace.  Enqueueing the same Request
+// for the same controller more often than once per the minimum interval of the Bus delays it instead, and
+// Requests which are waiting already aren't enqueued again.
+type Bus struct {
+	minInterval time.Duration
+
+	mu sync.Mutex
+	// targets are the registered controllers, with their sources once they are started.
+	targets map[string]*busSource
+	// delivered are the times Requests were last delivered.
+	delivered map[busKey]time.Time
+	// delayed are the Requests waiting to be delivered.
+	delayed   map[busKey]bool
+	lastSweep time.Time
+}
+
+// busKey identifies a Request enqueued for a controller.
+type busKey struct {
+	target string
+	req    reconcile.Request
+}
+
+// NewBus returns a Bus which delivers the same Request to the same controller at most once per
+// minInterval.
+func NewBus(minInterval time.Duration) *Bus {
+	return &Bus{
+		minInterval: minInterval,
+		targets:     map[string]*busSource{},
+		delivered:   map[busKey]time.Time{},
+		delayed:     map[busKey]bool{},
+	}
+}
+
+// Source registers the controller with the given name on the Bus, and returns the Source through which it
+// receives the Requests enqueued for it.  It must be watched by exactly one controller.
+func (b *Bus) Source(name string) source.Source {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, found := b.targets[name]; found {
+		return s
+	}
+	s := &busSource{name: name}
+	b.targets[name] = s
+	return s
+}
+
+// Reconciler wraps r so that BusFromContext can be used from it.  The dependencies injected into the returned
+// Reconciler, e.g. by the manager, are passed on to r.
+func (b *Bus) Reconciler(r reconcile.Reconciler) reconcile.ContextReconciler {
+	return &wrappingReconciler{reconciler: r, prepare: func(ctx context.Context, _ reconcile.Request) context.Context {
+		return context.WithValue(ctx, busKeyType{}, b)
+	}}
+}
+
+// Enqueue enqueues req for the controller with the given name.  It fails if no controller of that name has
+// been registered with Source, or if it hasn't started watching it yet.
+func (b *Bus) Enqueue(target string, req reconcile.Request) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, found := b.targets[target]
+	if !found {
+		return fmt.Errorf("unable to enqueue %v for controller %q: no such controller on the bus", req, target)
+	}
+	if !s.started() {
+		return fmt.Errorf("unable to enqueue %v for controller %q: the controller isn't watching the bus yet", req, target)
+	}
+
+	now := time.Now()
+	b.sweep(now)
+	key := busKey{target: target, req: req}
+	if b.delayed[key] {
+		return nil
+	}
+	if last, found := b.delivered[key]; found && now.Sub(last) < b.minInterval {
+		b.delayed[key] = true
+		time.AfterFunc(last.Add(b.minInterval).Sub(now), func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.delayed, key)
+			b.delivered[key] = time.Now()
+			s.deliver(req)
+		})
+		return nil
+	}
+	b.delivered[key] = now
+	s.deliver(req)
+	return nil
+}
+
+// sweep forgets the deliveries which are too old to delay further enqueues, at most once per minInterval.
+func (b *Bus) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < b.minInterval {
+		return
+	}
+	b.lastSweep = now
+	for key, last := range b.delivered {
+		if now.Sub(last) >= b.minInterval {
+			delete(b.delivered, key)
+		}
+	}
+}
+
+// busKeyType is the context key of the Bus of a reconcile.
+type busKeyType struct{}
+
+// BusFromContext returns the Bus of the reconcile, or nil if the Reconciler isn't wrapped by
+// Bus.Reconciler.
+func BusFromContext(ctx context.Context) *Bus {
+	b, _ := ctx.Value(busKeyType{}).(*Bus)
+	return b
+}
+
+var _ source.Source = &busSource{}
+
+// busSource delivers the Requests enqueued on a Bus for a controller.
+type busSource struct {
+	name string
+
+	mu         sync.Mutex
+	handler    handler.EventHandler
+	queue      workqueue.RateLimitingInterface
+	predicates []predicate.Predicate
+}
+
+// Start implements source.Source
+func (s *busSource) Start(h handler.EventHandler, q workqueue.RateLimitingInterface, prct ...predicate.Predicate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.handler != nil {
+		return fmt.Errorf("the bus source of controller %q is already watched", s.name)
+	}
+	s.handler, s.queue, s.predicates = h, q, prct
+	return nil
+}
+
+func (s *busSource) started() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handler != nil
+}
+
+// deliver passes req to the handler as a GenericEvent.
+func (s *busSource) deliver(req reconcile.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	evt := event.GenericEvent{Meta: &metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
+	for _, p := range s.predicates {
+		if !p.Generic(evt) {
+			return
+		}
+	}
+	s.handler.Generic(evt, s.queue)
+}
+
+func (s *busSource) String() string {
+	return fmt.Sprintf("bus source: %s", s.name)
+}
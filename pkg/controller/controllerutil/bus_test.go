@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Bus", func() {
+	var bus *controllerutil.Bus
+	var queue workqueue.RateLimitingInterface
+	foo := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "foo"}}
+
+	BeforeEach(func() {
+		bus = controllerutil.NewBus(100 * time.Millisecond)
+		queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	})
+
+	AfterEach(func() {
+		queue.ShutDown()
+	})
+
+	It("should enqueue requests for the controllers watching it from wrapped Reconcilers", func() {
+		Expect(bus.Source("controller-b").Start(&handler.EnqueueRequestForObject{}, queue)).To(Succeed())
+
+		enqueuing := bus.Reconciler(reconcile.ContextFunc(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, controllerutil.BusFromContext(ctx).Enqueue("controller-b", req)
+		}))
+		_, err := enqueuing.ReconcileContext(context.Background(), foo)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(queue.Len()).To(Equal(1))
+		item, _ := queue.Get()
+		Expect(item).To(Equal(foo))
+	})
+
+	It("should pass the dependencies set by the manager on to the wrapped Reconciler", func() {
+		m, err := manager.New(cfg, manager.Options{})
+		Expect(err).NotTo(HaveOccurred())
+		inner := &injectedReconciler{}
+		Expect(m.SetFields(bus.Reconciler(inner))).To(Succeed())
+		Expect(inner.client).To(BeIdenticalTo(m.GetClient()))
+	})
+
+	It("should delay requests enqueued again within the minimum interval, only once", func() {
+		Expect(bus.Source("controller-b").Start(&handler.EnqueueRequestForObject{}, queue)).To(Succeed())
+
+		Expect(bus.Enqueue("controller-b", foo)).To(Succeed())
+		item, _ := queue.Get()
+		queue.Done(item)
+
+		Expect(bus.Enqueue("controller-b", foo)).To(Succeed())
+		Expect(bus.Enqueue("controller-b", foo)).To(Succeed())
+		Expect(queue.Len()).To(Equal(0))
+
+		Eventually(queue.Len).Should(Equal(1))
+		item, _ = queue.Get()
+		queue.Done(item)
+		Consistently(queue.Len, 200*time.Millisecond).Should(Equal(0))
+	})
+
+	It("should fail to enqueue requests for controllers which aren't on the bus", func() {
+		Expect(bus.Enqueue("controller-b", foo)).To(MatchError(ContainSubstring("no such controller on the bus")))
+
+		bus.Source("controller-b")
+		Expect(bus.Enqueue("controller-b", foo)).To(MatchError(ContainSubstring("isn't watching the bus yet")))
+	})
+
+	It("should fail to start the source of a controller twice", func() {
+		Expect(bus.Source("controller-b").Start(&handler.EnqueueRequestForObject{}, queue)).To(Succeed())
+		Expect(bus.Source("controller-b").Start(&handler.EnqueueRequestForObject{}, queue)).NotTo(Succeed())
+	})
+
+	It("should not be found in the context of Reconcilers which aren't wrapped", func() {
+		Expect(controllerutil.BusFromContext(context.Background())).To(BeNil())
+	})
+})
@@ -20,9 +20,12 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -120,8 +123,15 @@ const ( // They should complete the sentence "Deployment default/foo has been ..
 //
 // The MutateFn is called regardless of creating or updating an object.
 //
+// By default, an existing object is only updated if the MutateFn changed it
+// in any way.  Use WithComparison or IgnoringFields to restrict which changes
+// should result in an update.
+//
 // It returns the executed operation and an error.
-func CreateOrUpdate(ctx context.Context, c client.Client, obj runtime.Object, f MutateFn) (OperationResult, error) {
+func CreateOrUpdate(ctx context.Context, c client.Client, obj runtime.Object, f MutateFn, opts ...CreateOrUpdateOptionFunc) (OperationResult, error) {
+	cuOpts := &CreateOrUpdateOptions{}
+	cuOpts.ApplyOptions(opts)
+
 	key, err := client.ObjectKeyFromObject(obj)
 	if err != nil {
 		return OperationResultNone, err
@@ -145,7 +155,11 @@ func CreateOrUpdate(ctx context.Context, c client.Client, obj runtime.Object, f
 		return OperationResultNone, err
 	}
 
-	if reflect.DeepEqual(existing, obj) {
+	equal, err := cuOpts.equal(existing, obj)
+	if err != nil {
+		return OperationResultNone, err
+	}
+	if equal {
 		return OperationResultNone, nil
 	}
 
@@ -155,6 +169,32 @@ func CreateOrUpdate(ctx context.Context, c client.Client, obj runtime.Object, f
 	return OperationResultUpdated, nil
 }
 
+// MergeStatus changes the status of obj with the MutateFn f, and writes only the fields f changed to the
+// status subresource with a merge patch.  obj should be the object as last read, e.g. in the reconcile
+// calling MergeStatus, and is updated with the content returned by the Server.
+//
+// Unlike Status().Update, the patch doesn't carry the resourceVersion of obj and leaves the other
+// fields of the status alone, so that controllers writing disjoint fields of the status of the same
+// object neither conflict nor overwrite each other.  Note that a merge patch replaces lists as a whole,
+// so writers sharing a list in the status (e.g. conditions) still overwrite each other's entries.
+//
+// It returns OperationResultNone without writing anything if f didn't change obj, and
+// OperationResultUpdated otherwise.
+func MergeStatus(ctx context.Context, c client.Client, obj runtime.Object, f MutateFn) (OperationResult, error) {
+	existing := obj.DeepCopyObject()
+	if err := f(); err != nil {
+		return OperationResultNone, err
+	}
+	if reflect.DeepEqual(existing, obj) {
+		return OperationResultNone, nil
+	}
+
+	if err := c.Status().Patch(ctx, obj, client.MergeFrom(existing)); err != nil {
+		return OperationResultNone, err
+	}
+	return OperationResultUpdated, nil
+}
+
 // mutate wraps a MutateFn and applies validation to its result
 func mutate(f MutateFn, key client.ObjectKey, obj runtime.Object) error {
 	if err := f(); err != nil {
@@ -168,3 +208,148 @@ func mutate(f MutateFn, key client.ObjectKey, obj runtime.Object) error {
 
 // MutateFn is a function which mutates the existing object into it's desired state.
 type MutateFn func() error
+
+// CreateOrUpdateOptions contains options for CreateOrUpdate.
+type CreateOrUpdateOptions struct {
+	// Equal reports whether the existing object and the object mutated by the
+	// MutateFn are equivalent, in which case no update is issued.  It takes
+	// precedence over IgnoredFields.  Defaults to a deep comparison of the
+	// complete objects.
+	Equal func(existing, mutated runtime.Object) bool
+
+	// IgnoredFields are dot-separated paths of fields (e.g. "spec.replicas" or
+	// "metadata.annotations") that are left out when comparing the existing
+	// object with the mutated one.  This is useful for fields defaulted or
+	// managed by someone else, which would otherwise trigger spurious updates.
+	IgnoredFields []string
+}
+
+// ApplyOptions executes the given CreateOrUpdateOptionFuncs and returns the mutated
+// CreateOrUpdateOptions.
+func (o *CreateOrUpdateOptions) ApplyOptions(optFuncs []CreateOrUpdateOptionFunc) *CreateOrUpdateOptions {
+	for _, optFunc := range optFuncs {
+		optFunc(o)
+	}
+	return o
+}
+
+// equal compares existing and mutated according to the options.
+func (o *CreateOrUpdateOptions) equal(existing, mutated runtime.Object) (bool, error) {
+	if o.Equal != nil {
+		return o.Equal(existing, mutated), nil
+	}
+	if len(o.IgnoredFields) == 0 {
+		return reflect.DeepEqual(existing, mutated), nil
+	}
+
+	existingContent, err := contentWithoutFields(existing, o.IgnoredFields)
+	if err != nil {
+		return false, err
+	}
+	mutatedContent, err := contentWithoutFields(mutated, o.IgnoredFields)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(existingContent, mutatedContent), nil
+}
+
+// contentWithoutFields returns the unstructured content of obj with the given fields removed.
+func contentWithoutFields(obj runtime.Object, fields []string) (map[string]interface{}, error) {
+	var content map[string]interface{}
+	if u, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		content = runtime.DeepCopyJSON(u.UnstructuredContent())
+	} else {
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range fields {
+		unstructured.RemoveNestedField(content, strings.Split(field, ".")...)
+	}
+	return content, nil
+}
+
+// CreateOrUpdateOptionFunc is a function that mutates a CreateOrUpdateOptions struct.
+// It implements the functional options pattern.
+type CreateOrUpdateOptionFunc func(*CreateOrUpdateOptions)
+
+// WithComparison is a functional option that sets the function used by CreateOrUpdate
+// to decide whether the mutated object differs from the existing one.
+func WithComparison(equal func(existing, mutated runtime.Object) bool) CreateOrUpdateOptionFunc {
+	return func(opts *CreateOrUpdateOptions) {
+		opts.Equal = equal
+	}
+}
+
+// IgnoringFields is a functional option that excludes the given dot-separated field
+// paths when CreateOrUpdate compares the mutated object with the existing one.
+func IgnoringFields(fields ...string) CreateOrUpdateOptionFunc {
+	return func(opts *CreateOrUpdateOptions) {
+		opts.IgnoredFields = append(opts.IgnoredFields, fields...)
+	}
+}
+
+// SetObservedGeneration sets the status.observedGeneration of obj to its metadata.generation, to signal
+// that the current spec of obj has been reconciled.  obj must either be unstructured, or a pointer to a
+// struct with a Status field that carries an int64 ObservedGeneration field (as most built-in types do).
+// Objects without a generation (e.g. types which don't track it) are left as is.
+//
+// It returns whether the observedGeneration changed, i.e. whether the status needs to be updated.
+func SetObservedGeneration(obj runtime.Object) (bool, error) {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return false, err
+	}
+	generation := objMeta.GetGeneration()
+	if generation == 0 {
+		return false, nil
+	}
+
+	if u, isUnstructured := obj.(*unstructured.Unstructured); isUnstructured {
+		observed, _, err := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+		if err != nil {
+			return false, err
+		}
+		if observed == generation {
+			return false, nil
+		}
+		return true, unstructured.SetNestedField(u.Object, generation, "status", "observedGeneration")
+	}
+
+	field, err := observedGenerationField(obj)
+	if err != nil {
+		return false, err
+	}
+	if field.Int() == generation {
+		return false, nil
+	}
+	field.SetInt(generation)
+	return true, nil
+}
+
+// observedGenerationField returns the settable status.observedGeneration field of obj.
+func observedGenerationField(obj runtime.Object) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("expected a pointer to a struct, got %T", obj)
+	}
+
+	status := v.Elem().FieldByName("Status")
+	if status.Kind() == reflect.Ptr {
+		if status.IsNil() {
+			status.Set(reflect.New(status.Type().Elem()))
+		}
+		status = status.Elem()
+	}
+	if status.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%T has no status struct", obj)
+	}
+
+	field := status.FieldByName("ObservedGeneration")
+	if field.Kind() != reflect.Int64 || !field.CanSet() {
+		return reflect.Value{}, fmt.Errorf("%T has no int64 status.observedGeneration field", obj)
+	}
+	return field, nil
+}
@@ -29,9 +29,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
@@ -212,6 +214,58 @@ var _ = Describe("Controllerutil", func() {
 			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultNone))
 		})
 
+		It("skips the update when the custom comparison reports the objects as equal", func() {
+			var scale int32 = 2
+			op, err := controllerutil.CreateOrUpdate(context.TODO(), c, deploy, specr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultCreated))
+
+			op, err = controllerutil.CreateOrUpdate(context.TODO(), c, deploy, deploymentScaler(deploy, scale),
+				controllerutil.WithComparison(func(existing, mutated runtime.Object) bool {
+					return true
+				}))
+			By("returning no error")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("returning OperationResultNone")
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultNone))
+
+			By("leaving the deployment unscaled")
+			fetched := &appsv1.Deployment{}
+			Expect(c.Get(context.TODO(), deplKey, fetched)).To(Succeed())
+			Expect(fetched.Spec.Replicas).NotTo(Equal(&scale))
+		})
+
+		It("does not update when only ignored fields changed", func() {
+			var scale int32 = 2
+			op, err := controllerutil.CreateOrUpdate(context.TODO(), c, deploy, specr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultCreated))
+
+			op, err = controllerutil.CreateOrUpdate(context.TODO(), c, deploy, deploymentScaler(deploy, scale),
+				controllerutil.IgnoringFields("spec.replicas"))
+			By("returning no error")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("returning OperationResultNone")
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultNone))
+		})
+
+		It("updates when fields other than the ignored ones changed", func() {
+			var scale int32 = 2
+			op, err := controllerutil.CreateOrUpdate(context.TODO(), c, deploy, specr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultCreated))
+
+			op, err = controllerutil.CreateOrUpdate(context.TODO(), c, deploy, deploymentScaler(deploy, scale),
+				controllerutil.IgnoringFields("metadata.annotations"))
+			By("returning no error")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("returning OperationResultUpdated")
+			Expect(op).To(BeEquivalentTo(controllerutil.OperationResultUpdated))
+		})
+
 		It("errors when MutateFn changes objct name on creation", func() {
 			op, err := controllerutil.CreateOrUpdate(context.TODO(), c, deploy, func() error {
 				Expect(specr()).To(Succeed())
@@ -265,6 +319,57 @@ var _ = Describe("Controllerutil", func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Describe("SetObservedGeneration", func() {
+		It("should set the observedGeneration of a typed object to its generation", func() {
+			deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+			deploy.Status.ObservedGeneration = 2
+
+			changed, err := controllerutil.SetObservedGeneration(deploy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(deploy.Status.ObservedGeneration).To(Equal(int64(3)))
+		})
+
+		It("should report no change if the observedGeneration is up to date", func() {
+			deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+			deploy.Status.ObservedGeneration = 3
+
+			changed, err := controllerutil.SetObservedGeneration(deploy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeFalse())
+		})
+
+		It("should leave objects without a generation alone", func() {
+			deploy := &appsv1.Deployment{}
+
+			changed, err := controllerutil.SetObservedGeneration(deploy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(deploy.Status.ObservedGeneration).To(BeZero())
+		})
+
+		It("should set the observedGeneration of an unstructured object", func() {
+			u := &unstructured.Unstructured{}
+			u.SetGeneration(5)
+
+			changed, err := controllerutil.SetObservedGeneration(u)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			observed, found, err := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(observed).To(Equal(int64(5)))
+		})
+
+		It("should return an error if the object has no observedGeneration field", func() {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+
+			_, err := controllerutil.SetObservedGeneration(pod)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no int64 status.observedGeneration field"))
+		})
+	})
 })
 
 var _ metav1.Object = &errMetaObj{}
@@ -314,3 +419,63 @@ type errorReader struct {
 func (e errorReader) Get(ctx context.Context, key client.ObjectKey, into runtime.Object) error {
 	return fmt.Errorf("unexpected error")
 }
+
+var _ = Describe("MergeStatus", func() {
+	var c client.Client
+	var key client.ObjectKey
+
+	BeforeEach(func() {
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+		c = fake.NewFakeClient(deploy)
+		key = client.ObjectKey{Namespace: "default", Name: "foo"}
+	})
+
+	It("should not overwrite the status fields written concurrently by others", func() {
+		By("reading the same version of the object twice")
+		first, second := &appsv1.Deployment{}, &appsv1.Deployment{}
+		Expect(c.Get(context.TODO(), key, first)).To(Succeed())
+		Expect(c.Get(context.TODO(), key, second)).To(Succeed())
+
+		By("writing different status fields from both copies concurrently")
+		errs := make(chan error, 2)
+		go func() {
+			_, err := controllerutil.MergeStatus(context.TODO(), c, first, func() error {
+				first.Status.Replicas = 3
+				return nil
+			})
+			errs <- err
+		}()
+		go func() {
+			_, err := controllerutil.MergeStatus(context.TODO(), c, second, func() error {
+				second.Status.ObservedGeneration = 2
+				return nil
+			})
+			errs <- err
+		}()
+		Expect(<-errs).NotTo(HaveOccurred())
+		Expect(<-errs).NotTo(HaveOccurred())
+
+		By("keeping both fields")
+		actual := &appsv1.Deployment{}
+		Expect(c.Get(context.TODO(), key, actual)).To(Succeed())
+		Expect(actual.Status.Replicas).To(Equal(int32(3)))
+		Expect(actual.Status.ObservedGeneration).To(Equal(int64(2)))
+	})
+
+	It("should not write anything if the status didn't change", func() {
+		deploy := &appsv1.Deployment{}
+		Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+
+		op, err := controllerutil.MergeStatus(context.TODO(), c, deploy, func() error { return nil })
+		Expect(err).NotTo(HaveOccurred())
+		Expect(op).To(Equal(controllerutil.OperationResultNone))
+	})
+
+	It("should return the error of the MutateFn", func() {
+		deploy := &appsv1.Deployment{}
+		Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+
+		_, err := controllerutil.MergeStatus(context.TODO(), c, deploy, func() error { return fmt.Errorf("test error") })
+		Expect(err).To(MatchError("test error"))
+	})
+})
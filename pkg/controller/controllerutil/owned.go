@@ -0,0 +1,140 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
+)
+
+var log = logf.RuntimeLog.WithName("controllerutil")
+
+// OwnerUIDField is the field under which IndexOwners indexes objects by the UIDs of their owners.
+const OwnerUIDField = "metadata.ownerReferences.uid"
+
+// IndexOwners indexes objects of the type of obj by the UIDs of their owners, so that ListOwned can
+// list them from the cache without going through all objects of the type, e.g.
+//
+//	err := controllerutil.IndexOwners(mgr.GetFieldIndexer(), &corev1.Pod{})
+func IndexOwners(indexer client.FieldIndexer, obj runtime.Object) error {
+	return indexer.IndexField(obj, OwnerUIDField, func(obj runtime.Object) []string {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return nil
+		}
+		var uids []string
+		for _, ref := range m.GetOwnerReferences() {
+			uids = append(uids, string(ref.UID))
+		}
+		return uids
+	})
+}
+
+// ListOwned lists the objects owned by owner into list, e.g. the Pods of a ReplicaSet.  Objects owned by a
+// namespaced owner are only looked up in its namespace.
+//
+// The objects are looked up in the OwnerUIDField index of the cache, which has to be added with
+// IndexOwners.  If it is missing, all objects of the type are listed and filtered instead.
+func ListOwned(ctx context.Context, c client.Reader, owner metav1.Object, list runtime.Object) error {
+	err := c.List(ctx, list, client.InNamespace(owner.GetNamespace()), client.MatchingField(OwnerUIDField, string(owner.GetUID())))
+	if _, isIndexNotFound := err.(*client.ErrIndexNotFound); !isIndexNotFound {
+		return err
+	}
+
+	log.Info("Warning: objects aren't indexed by owner, listing all objects instead; use IndexOwners to index them",
+		"list", fmt.Sprintf("%T", list))
+	if err := c.List(ctx, list, client.InNamespace(owner.GetNamespace())); err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	owned := items[:0]
+	for _, item := range items {
+		m, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		for _, ref := range m.GetOwnerReferences() {
+			if ref.UID == owner.GetUID() {
+				owned = append(owned, item)
+				break
+			}
+		}
+	}
+	return meta.SetList(list, owned)
+}
+
+// CountByPhase counts the objects in list by their status.phase, e.g. the Pods of a ReplicaSet which are
+// Running.  Objects without a phase are counted under "".
+func CountByPhase(list runtime.Object) (map[string]int, error) {
+	return countBy(list, func(content map[string]interface{}) (string, error) {
+		phase, _, err := unstructured.NestedString(content, "status", "phase")
+		return phase, err
+	})
+}
+
+// CountByCondition counts the objects in list by the status of their condition of the given type, e.g. the
+// Pods of a ReplicaSet which are Ready.  Objects without the condition are counted under "".
+func CountByCondition(list runtime.Object, conditionType string) (map[string]int, error) {
+	return countBy(list, func(content map[string]interface{}) (string, error) {
+		conditions, _, err := unstructured.NestedSlice(content, "status", "conditions")
+		if err != nil {
+			return "", err
+		}
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok || condition["type"] != conditionType {
+				continue
+			}
+			status, _ := condition["status"].(string)
+			return status, nil
+		}
+		return "", nil
+	})
+}
+
+// countBy counts the objects in list by the value key returns for their unstructured content.
+func countBy(list runtime.Object, key func(map[string]interface{}) (string, error)) (map[string]int, error) {
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, item := range items {
+		var content map[string]interface{}
+		if u, isUnstructured := item.(runtime.Unstructured); isUnstructured {
+			content = u.UnstructuredContent()
+		} else if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(item); err != nil {
+			return nil, err
+		}
+		k, err := key(content)
+		if err != nil {
+			return nil, err
+		}
+		counts[k]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// unindexedReader fails to list by field like a cache without the field index.
+type unindexedReader struct {
+	client.Reader
+}
+
+func (r unindexedReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOptionFunc) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector != nil {
+		return &client.ErrIndexNotFound{Field: controllerutil.OwnerUIDField}
+	}
+	return r.Reader.List(ctx, list, opts...)
+}
+
+// recordingIndexer records the index added to it.
+type recordingIndexer struct {
+	field   string
+	extract client.IndexerFunc
+}
+
+func (i *recordingIndexer) IndexField(_ runtime.Object, field string, extract client.IndexerFunc) error {
+	i.field, i.extract = field, extract
+	return nil
+}
+
+func ownedPod(name string, owner metav1.Object, phase corev1.PodPhase, ready corev1.ConditionStatus) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{{Name: owner.GetName(), UID: owner.GetUID()}}
+	}
+	if ready != "" {
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}
+	}
+	return pod
+}
+
+var _ = Describe("ListOwned", func() {
+	var rs *appsv1.ReplicaSet
+	var reader client.Reader
+
+	BeforeEach(func() {
+		rs = &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "rs", Namespace: "default", UID: "rs-uid"}}
+		other := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other-uid"}}
+		reader = fake.NewFakeClient(
+			ownedPod("rs-1", rs, corev1.PodRunning, corev1.ConditionTrue),
+			ownedPod("rs-2", rs, corev1.PodRunning, corev1.ConditionFalse),
+			ownedPod("rs-3", rs, corev1.PodPending, ""),
+			ownedPod("other-1", other, corev1.PodRunning, corev1.ConditionTrue),
+			ownedPod("orphan", nil, corev1.PodRunning, corev1.ConditionTrue),
+		)
+	})
+
+	It("should index objects by the UIDs of their owners", func() {
+		indexer := &recordingIndexer{}
+		Expect(controllerutil.IndexOwners(indexer, &corev1.Pod{})).To(Succeed())
+		Expect(indexer.field).To(Equal(controllerutil.OwnerUIDField))
+
+		pod := ownedPod("rs-1", rs, corev1.PodRunning, "")
+		pod.OwnerReferences = append(pod.OwnerReferences, metav1.OwnerReference{Name: "other", UID: "other-uid"})
+		Expect(indexer.extract(pod)).To(Equal([]string{"rs-uid", "other-uid"}))
+	})
+
+	It("should fall back to filtering all objects if they aren't indexed by owner", func() {
+		pods := &corev1.PodList{}
+		Expect(controllerutil.ListOwned(context.TODO(), unindexedReader{Reader: reader}, rs, pods)).To(Succeed())
+
+		var names []string
+		for _, pod := range pods.Items {
+			names = append(names, pod.Name)
+		}
+		Expect(names).To(ConsistOf("rs-1", "rs-2", "rs-3"))
+	})
+
+	It("should count the owned objects by phase and condition", func() {
+		pods := &corev1.PodList{}
+		Expect(controllerutil.ListOwned(context.TODO(), unindexedReader{Reader: reader}, rs, pods)).To(Succeed())
+
+		Expect(controllerutil.CountByPhase(pods)).To(Equal(map[string]int{"Running": 2, "Pending": 1}))
+		Expect(controllerutil.CountByCondition(pods, string(corev1.PodReady))).To(Equal(map[string]int{"True": 1, "False": 1, "": 1}))
+	})
+})
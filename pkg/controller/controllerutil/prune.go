@@ -0,0 +1,187 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// PreventPruneAnnotation is the annotation which protects an object from being deleted by Prune when it is
+// set to "true", e.g. to keep a PersistentVolumeClaim around after it has been removed from the desired set.
+const PreventPruneAnnotation = "controller-runtime.sigs.k8s.io/prevent-prune"
+
+// Prune deletes the objects carrying managementLabel which aren't part of desired, i.e. the objects a
+// controller created earlier but doesn't want anymore.  managementLabel is a label selector, e.g.
+// "app.kubernetes.io/managed-by=my-controller".  lists are the lists of the types to prune, e.g.
+//
+//	pruned, err := controllerutil.Prune(ctx, c, "app.kubernetes.io/managed-by=my-controller", desired,
+//		[]runtime.Object{&corev1.ConfigMapList{}, &corev1.SecretList{}}, controllerutil.PruneInNamespace(ns))
+//
+// Objects match desired ones by their group, kind, namespace and name.  Objects annotated with
+// PreventPruneAnnotation, or protected with PruneProtecting, are never deleted.  Every object is deleted
+// with a precondition on its UID, so that an object which has been recreated in the meantime is kept.
+//
+// Prune returns the objects it deleted, or would have deleted with PruneDryRun.  It stops at the first
+// error, having deleted some objects already.
+func Prune(ctx context.Context, c client.Client, managementLabel string, desired []runtime.Object, lists []runtime.Object, opts ...PruneOptionFunc) ([]runtime.Object, error) {
+	o := (&PruneOptions{}).ApplyOptions(opts)
+	if o.Scheme == nil {
+		o.Scheme = scheme.Scheme
+	}
+	selector, err := labels.Parse(managementLabel)
+	if err != nil {
+		return nil, err
+	}
+	if selector.Empty() {
+		// An empty selector would prune everything which isn't desired.
+		return nil, fmt.Errorf("must specify a management label to prune by")
+	}
+
+	keep := map[pruneKey]bool{}
+	for _, obj := range desired {
+		key, err := o.keyFor(obj)
+		if err != nil {
+			return nil, err
+		}
+		keep[key] = true
+	}
+
+	var pruned []runtime.Object
+	for _, list := range lists {
+		listOpts := &client.ListOptions{LabelSelector: selector, Namespace: o.Namespace}
+		if err := c.List(ctx, list, client.UseListOptions(listOpts)); err != nil {
+			return pruned, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return pruned, err
+		}
+		for _, item := range items {
+			key, err := o.keyFor(item)
+			if err != nil {
+				return pruned, err
+			}
+			if keep[key] {
+				continue
+			}
+			m, err := meta.Accessor(item)
+			if err != nil {
+				return pruned, err
+			}
+			if m.GetAnnotations()[PreventPruneAnnotation] == "true" || (o.Protected != nil && o.Protected(item)) {
+				continue
+			}
+			if !o.DryRun {
+				uid := m.GetUID()
+				err := c.Delete(ctx, item, client.Preconditions(&metav1.Preconditions{UID: &uid}))
+				if client.IgnoreNotFound(err) != nil {
+					return pruned, err
+				}
+			}
+			log.V(1).Info("Pruned object", "kind", key.GroupKind, "namespace", key.Namespace, "name", key.Name, "dryRun", o.DryRun)
+			pruned = append(pruned, item)
+		}
+	}
+	return pruned, nil
+}
+
+// pruneKey identifies an object across versions of its type.
+type pruneKey struct {
+	schema.GroupKind
+	client.ObjectKey
+}
+
+// keyFor returns the pruneKey of obj.
+func (o *PruneOptions) keyFor(obj runtime.Object) (pruneKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, o.Scheme)
+	if err != nil {
+		return pruneKey{}, err
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return pruneKey{}, err
+	}
+	return pruneKey{
+		GroupKind: gvk.GroupKind(),
+		ObjectKey: client.ObjectKey{Namespace: m.GetNamespace(), Name: m.GetName()},
+	}, nil
+}
+
+// PruneOptions contains options for Prune.
+type PruneOptions struct {
+	// Namespace restricts pruning to the objects in the namespace.  Defaults to all namespaces.
+	Namespace string
+
+	// DryRun, if true, only reports the objects which would be deleted.
+	DryRun bool
+
+	// Protected, if set, reports whether an object must be kept even though it isn't desired.
+	Protected func(runtime.Object) bool
+
+	// Scheme is used to look up the kinds of typed objects.  Defaults to the Kubernetes client-go scheme.
+	Scheme *runtime.Scheme
+}
+
+// ApplyOptions executes the given PruneOptionFuncs and returns the mutated PruneOptions.
+func (o *PruneOptions) ApplyOptions(optFuncs []PruneOptionFunc) *PruneOptions {
+	for _, optFunc := range optFuncs {
+		optFunc(o)
+	}
+	return o
+}
+
+// PruneOptionFunc is a function that mutates a PruneOptions struct.
+// It implements the functional options pattern.
+type PruneOptionFunc func(*PruneOptions)
+
+// PruneInNamespace is a functional option that restricts Prune to the objects in the namespace.
+func PruneInNamespace(ns string) PruneOptionFunc {
+	return func(opts *PruneOptions) {
+		opts.Namespace = ns
+	}
+}
+
+// PruneDryRun is a functional option that makes Prune report the objects it would delete without
+// deleting them.
+var PruneDryRun PruneOptionFunc = func(opts *PruneOptions) {
+	opts.DryRun = true
+}
+
+// PruneProtecting is a functional option that keeps the objects for which protected returns true.
+func PruneProtecting(protected func(runtime.Object) bool) PruneOptionFunc {
+	return func(opts *PruneOptions) {
+		opts.Protected = protected
+	}
+}
+
+// PruneWithScheme is a functional option that sets the scheme Prune looks up the kinds of typed
+// objects with.
+func PruneWithScheme(s *runtime.Scheme) PruneOptionFunc {
+	return func(opts *PruneOptions) {
+		opts.Scheme = s
+	}
+}
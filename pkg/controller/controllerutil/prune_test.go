@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const managementLabel = "app.kubernetes.io/managed-by=test"
+
+func managedConfigMap(name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "default",
+		Labels:    map[string]string{"app.kubernetes.io/managed-by": "test"},
+	}}
+}
+
+var _ = Describe("Prune", func() {
+	var c client.Client
+
+	BeforeEach(func() {
+		protected := managedConfigMap("protected")
+		protected.Annotations = map[string]string{controllerutil.PreventPruneAnnotation: "true"}
+		unmanaged := managedConfigMap("unmanaged")
+		unmanaged.Labels = nil
+		c = fake.NewFakeClient(managedConfigMap("desired"), managedConfigMap("extra"), protected, unmanaged)
+	})
+
+	names := func() []string {
+		list := &corev1.ConfigMapList{}
+		Expect(c.List(context.TODO(), list)).To(Succeed())
+		var names []string
+		for _, cm := range list.Items {
+			names = append(names, cm.Name)
+		}
+		return names
+	}
+
+	It("should delete the managed objects which aren't desired", func() {
+		desired := &unstructured.Unstructured{}
+		desired.SetAPIVersion("v1")
+		desired.SetKind("ConfigMap")
+		desired.SetNamespace("default")
+		desired.SetName("desired")
+
+		pruned, err := controllerutil.Prune(context.TODO(), c, managementLabel,
+			[]runtime.Object{desired}, []runtime.Object{&corev1.ConfigMapList{}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(HaveLen(1))
+		Expect(pruned[0].(*corev1.ConfigMap).Name).To(Equal("extra"))
+		Expect(names()).To(ConsistOf("desired", "protected", "unmanaged"))
+	})
+
+	It("should only report the objects to delete with PruneDryRun", func() {
+		pruned, err := controllerutil.Prune(context.TODO(), c, managementLabel,
+			[]runtime.Object{managedConfigMap("desired")}, []runtime.Object{&corev1.ConfigMapList{}},
+			controllerutil.PruneDryRun)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(HaveLen(1))
+		Expect(names()).To(ConsistOf("desired", "extra", "protected", "unmanaged"))
+	})
+
+	It("should keep the objects protected with PruneProtecting", func() {
+		pruned, err := controllerutil.Prune(context.TODO(), c, managementLabel,
+			nil, []runtime.Object{&corev1.ConfigMapList{}},
+			controllerutil.PruneProtecting(func(obj runtime.Object) bool {
+				return obj.(*corev1.ConfigMap).Name == "extra"
+			}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(HaveLen(1))
+		Expect(names()).To(ConsistOf("extra", "protected", "unmanaged"))
+	})
+
+	It("should refuse to prune without a management label", func() {
+		_, err := controllerutil.Prune(context.TODO(), c, "", nil, []runtime.Object{&corev1.ConfigMapList{}})
+		Expect(err).To(HaveOccurred())
+		Expect(names()).To(HaveLen(4))
+	})
+})
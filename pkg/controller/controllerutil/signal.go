@@ -0,0 +1,149 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Signals tracks the objects whose reconciles are suspended until an external signal, e.g. a callback from
+// a system outside the cluster, instead of polling for it with RequeueAfter.  Reconcilers suspend with
+// SuspendUntilSignal, and whatever observes the external event calls Signal to reconcile the object again.
+//
+// Signals re-enqueues objects through a channel, which has to be watched with a source.Channel and
+// handler.EnqueueRequestForObject.  Reconcilers have to be wrapped with Reconciler, which also ends the
+// suspension of an object whenever it is reconciled for another reason, e.g. because it was deleted:
+//
+//	signals := controllerutil.NewSignals(1000)
+//	c, err := controller.New("foo-controller", mgr, controller.Options{Reconciler: signals.Reconciler(r)})
+//	err = c.Watch(&source.Channel{Source: signals.Source()}, &handler.EnqueueRequestForObject{})
+type Signals struct {
+	maxSuspended int
+	source       chan event.GenericEvent
+
+	mu        sync.Mutex
+	suspended map[client.ObjectKey]bool
+	// signalled are the objects signalled while not suspended, e.g. during their reconcile.
+	signalled map[client.ObjectKey]bool
+}
+
+// NewSignals returns Signals which track at most maxSuspended suspended objects at once, and remember at
+// most maxSuspended signals for objects which weren't suspended yet.
+func NewSignals(maxSuspended int) *Signals {
+	return &Signals{
+		maxSuspended: maxSuspended,
+		// The buffer holds a signal for every suspended object, as long as the signals are received.
+		source:    make(chan event.GenericEvent, maxSuspended),
+		suspended: map[client.ObjectKey]bool{},
+		signalled: map[client.ObjectKey]bool{},
+	}
+}
+
+// Source returns the channel on which the signalled objects are delivered, to be watched with a
+// source.Channel.  The GenericEvents only carry the name and namespace of the objects.
+func (s *Signals) Source() <-chan event.GenericEvent {
+	return s.source
+}
+
+// Reconciler wraps r so that SuspendUntilSignal can be used from it.  The dependencies injected into the
+// returned Reconciler, e.g. by the manager, are passed on to r.
+func (s *Signals) Reconciler(r reconcile.Reconciler) reconcile.ContextReconciler {
+	return &wrappingReconciler{reconciler: r, prepare: func(ctx context.Context, req reconcile.Request) context.Context {
+		// Whatever the reason for this reconcile, it decides anew whether to wait for a signal.
+		s.reset(req.NamespacedName)
+		return context.WithValue(ctx, signalsKey{}, s)
+	}}
+}
+
+// Signal reconciles the object with the given key again if its reconcile is suspended, and reports
+// whether it was.  It never blocks: if the Source isn't drained, e.g. because the controller hasn't
+// started yet, the object stays suspended and Signal returns false, so that it can be signalled again.
+// Signals for an object which isn't suspended, e.g. because it is still being reconciled, are remembered
+// for its next SuspendUntilSignal, which then requeues it right away.
+func (s *Signals) Signal(key client.ObjectKey) bool {
+	s.mu.Lock()
+	if !s.suspended[key] {
+		if len(s.signalled) < s.maxSuspended {
+			s.signalled[key] = true
+		}
+		s.mu.Unlock()
+		return false
+	}
+	delete(s.suspended, key)
+	s.mu.Unlock()
+
+	select {
+	case s.source <- event.GenericEvent{Meta: &metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}:
+		return true
+	default:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.suspended[key] = true
+		return false
+	}
+}
+
+// suspend starts tracking key, failing if too many objects are suspended, unless key has been signalled
+// already, which it reports.
+func (s *Signals) suspend(key client.ObjectKey) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.signalled[key] {
+		delete(s.signalled, key)
+		return true, nil
+	}
+	if len(s.suspended) >= s.maxSuspended && !s.suspended[key] {
+		return false, fmt.Errorf("unable to suspend %v until a signal, %d objects are already suspended", key, len(s.suspended))
+	}
+	s.suspended[key] = true
+	return false, nil
+}
+
+// reset stops tracking key, forgetting its signals.
+func (s *Signals) reset(key client.ObjectKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.suspended, key)
+	delete(s.signalled, key)
+}
+
+// signalsKey is the context key of the Signals of a reconcile.
+type signalsKey struct{}
+
+// SuspendUntilSignal suspends the reconciles of the object with the given key until Signals.Signal is
+// called for it, and returns the Result to return from the reconcile.  ctx must be the context passed to
+// a Reconciler wrapped by Signals.Reconciler.  If the object has been signalled since its reconcile started,
+// it is requeued right away instead.  An error is returned if too many objects are suspended already, so
+// that the Controller falls back to requeueing the object with a backoff.
+func SuspendUntilSignal(ctx context.Context, key client.ObjectKey) (reconcile.Result, error) {
+	s, ok := ctx.Value(signalsKey{}).(*Signals)
+	if !ok {
+		return reconcile.Result{}, fmt.Errorf("unable to suspend %v until a signal, the Reconciler isn't wrapped by Signals", key)
+	}
+	signalled, err := s.suspend(key)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{Requeue: signalled}, nil
+}
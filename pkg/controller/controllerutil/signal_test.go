@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+)
+
+var _ = Describe("Signals", func() {
+	var signals *controllerutil.Signals
+	var suspending reconcile.ContextReconciler
+	foo := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "foo"}}
+	bar := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "bar"}}
+
+	BeforeEach(func() {
+		signals = controllerutil.NewSignals(1)
+		suspending = signals.Reconciler(reconcile.ContextFunc(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+			return controllerutil.SuspendUntilSignal(ctx, req.NamespacedName)
+		}))
+	})
+
+	It("should re-enqueue suspended objects when they are signalled", func() {
+		Expect(suspending.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{}))
+
+		Expect(signals.Signal(foo.NamespacedName)).To(BeTrue())
+		var evt event.GenericEvent
+		Expect(signals.Source()).To(Receive(&evt))
+		Expect(evt.Meta.GetNamespace()).To(Equal("default"))
+		Expect(evt.Meta.GetName()).To(Equal("foo"))
+
+		By("ignoring further signals until the object is suspended again")
+		Expect(signals.Signal(foo.NamespacedName)).To(BeFalse())
+		Expect(signals.Source()).NotTo(Receive())
+	})
+
+	It("should keep objects suspended rather than block if the signals aren't received", func() {
+		Expect(suspending.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{}))
+		Expect(signals.Signal(foo.NamespacedName)).To(BeTrue())
+		Expect(suspending.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{}))
+
+		Expect(signals.Signal(foo.NamespacedName)).To(BeFalse())
+		Expect(signals.Source()).To(Receive())
+		Expect(signals.Signal(foo.NamespacedName)).To(BeTrue())
+	})
+
+	It("should requeue objects signalled during their reconcile right away", func() {
+		signalling := signals.Reconciler(reconcile.ContextFunc(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+			// E.g. the external work started by the reconcile completes before it suspends.
+			Expect(signals.Signal(req.NamespacedName)).To(BeFalse())
+			return controllerutil.SuspendUntilSignal(ctx, req.NamespacedName)
+		}))
+		Expect(signalling.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{Requeue: true}))
+
+		By("only requeueing once per signal")
+		Expect(suspending.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{}))
+	})
+
+	It("should forget the signals of an object once it is reconciled again", func() {
+		Expect(signals.Signal(foo.NamespacedName)).To(BeFalse())
+		Expect(suspending.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{}))
+	})
+
+	It("should pass the injected dependencies on to the wrapped Reconciler", func() {
+		inner := &injectedReconciler{}
+		wrapped := signals.Reconciler(inner)
+		Expect(inject.InjectorInto(func(i interface{}) error {
+			_, err := inject.ClientInto(fake.NewFakeClient(), i)
+			return err
+		}, wrapped)).To(BeTrue())
+		Expect(inner.client).NotTo(BeNil())
+	})
+
+	It("should ignore signals for objects which aren't suspended", func() {
+		Expect(signals.Signal(foo.NamespacedName)).To(BeFalse())
+		Expect(signals.Source()).NotTo(Receive())
+	})
+
+	It("should end the suspension of an object when it is reconciled for another reason", func() {
+		Expect(suspending.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{}))
+
+		notSuspending := signals.Reconciler(reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, nil
+		}))
+		Expect(notSuspending.ReconcileContext(context.Background(), foo)).To(Equal(reconcile.Result{}))
+		Expect(signals.Signal(foo.NamespacedName)).To(BeFalse())
+	})
+
+	It("should fail to suspend more than the maximum number of objects", func() {
+		_, err := suspending.ReconcileContext(context.Background(), foo)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = suspending.ReconcileContext(context.Background(), bar)
+		Expect(err).To(MatchError(ContainSubstring("1 objects are already suspended")))
+	})
+
+	It("should fail to suspend from a Reconciler which isn't wrapped", func() {
+		_, err := controllerutil.SuspendUntilSignal(context.Background(), foo.NamespacedName)
+		Expect(err).To(MatchError(ContainSubstring("isn't wrapped by Signals")))
+	})
+})
+
+// injectedReconciler is a Reconciler which gets a Client injected.
+type injectedReconciler struct {
+	client client.Client
+}
+
+func (r *injectedReconciler) Reconcile(reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, nil
+}
+
+func (r *injectedReconciler) InjectClient(c client.Client) error {
+	r.client = c
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllerutil
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+)
+
+var _ inject.Injector = &wrappingReconciler{}
+
+// wrappingReconciler is a ContextReconciler which prepares the context of the reconciles of a wrapped
+// Reconciler, and passes the dependencies injected into it on to the wrapped Reconciler.
+type wrappingReconciler struct {
+	reconciler reconcile.Reconciler
+	prepare    func(context.Context, reconcile.Request) context.Context
+}
+
+// Reconcile implements reconcile.Reconciler
+func (w *wrappingReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	return w.ReconcileContext(context.Background(), req)
+}
+
+// ReconcileContext implements reconcile.ContextReconciler
+func (w *wrappingReconciler) ReconcileContext(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ctx = w.prepare(ctx, req)
+	if cr, ok := w.reconciler.(reconcile.ContextReconciler); ok {
+		return cr.ReconcileContext(ctx, req)
+	}
+	return w.reconciler.Reconcile(req)
+}
+
+// InjectFunc implements inject.Injector, passing the dependencies on to the wrapped Reconciler.
+func (w *wrappingReconciler) InjectFunc(f inject.Func) error {
+	return f(w.reconciler)
+}
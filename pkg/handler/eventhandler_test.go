@@ -951,4 +951,67 @@ var _ = Describe("Eventhandler", func() {
 			close(done)
 		})
 	})
+
+	Describe("WithPriorityFromLabel", func() {
+		var pq *priorityRecordingQueue
+		mapping := map[string]int{"high": 10, "low": -10}
+
+		BeforeEach(func() {
+			pq = &priorityRecordingQueue{Queue: controllertest.Queue{Interface: workqueue.New()}, priorities: map[interface{}]int{}}
+		})
+
+		It("should enqueue Requests with the priority mapped from the label of the object", func() {
+			instance := handler.WithPriorityFromLabel("priority", mapping, &handler.EnqueueRequestForObject{})
+			pod.Labels = map[string]string{"priority": "high"}
+			instance.Create(event.CreateEvent{Object: pod, Meta: pod.GetObjectMeta()}, pq)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "biz", Name: "baz"}}
+			Expect(pq.Len()).To(Equal(1))
+			Expect(pq.priorities).To(Equal(map[interface{}]int{req: 10}))
+		})
+
+		It("should enqueue Requests with the default priority if the label is absent or unknown", func() {
+			instance := handler.WithPriorityFromLabel("priority", mapping, &handler.EnqueueRequestForObject{})
+			instance.Create(event.CreateEvent{Object: pod, Meta: pod.GetObjectMeta()}, pq)
+
+			other := pod.DeepCopy()
+			other.Name = "other"
+			other.Labels = map[string]string{"priority": "urgent"}
+			instance.Update(event.UpdateEvent{ObjectOld: pod, MetaOld: pod.GetObjectMeta(), ObjectNew: other, MetaNew: other.GetObjectMeta()}, pq)
+
+			Expect(pq.priorities).To(Equal(map[interface{}]int{
+				reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "biz", Name: "baz"}}:   handler.DefaultPriority,
+				reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "biz", Name: "other"}}: handler.DefaultPriority,
+			}))
+		})
+
+		It("should read the priority from an annotation with WithPriorityFromAnnotation", func() {
+			instance := handler.WithPriorityFromAnnotation("priority", mapping, &handler.EnqueueRequestForObject{})
+			pod.Annotations = map[string]string{"priority": "low"}
+			instance.Delete(event.DeleteEvent{Object: pod, Meta: pod.GetObjectMeta()}, pq)
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "biz", Name: "baz"}}
+			Expect(pq.priorities).To(Equal(map[interface{}]int{req: -10}))
+		})
+
+		It("should enqueue Requests plainly if the queue isn't a PriorityQueue", func() {
+			instance := handler.WithPriorityFromLabel("priority", mapping, &handler.EnqueueRequestForObject{})
+			pod.Labels = map[string]string{"priority": "high"}
+			instance.Generic(event.GenericEvent{Object: pod, Meta: pod.GetObjectMeta()}, q)
+
+			Expect(q.Len()).To(Equal(1))
+		})
+	})
 })
+
+// priorityRecordingQueue is a handler.PriorityQueue which records the priorities of the items added to it.
+type priorityRecordingQueue struct {
+	controllertest.Queue
+
+	priorities map[interface{}]int
+}
+
+func (q *priorityRecordingQueue) AddWithPriority(item interface{}, priority int) {
+	q.priorities[item] = priority
+	q.Add(item)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+)
+
+// DefaultPriority is the priority of Requests which are enqueued without one.
+const DefaultPriority = 0
+
+// PriorityQueue is a queue which hands out the items with the highest priority first, such as the queue of a
+// Controller created with controller.Options.PriorityQueue.
+type PriorityQueue interface {
+	workqueue.RateLimitingInterface
+
+	// AddWithPriority adds item with the given priority.  If the item is already waiting, its priority is
+	// raised to the given one if that is higher.
+	AddWithPriority(item interface{}, priority int)
+}
+
+// WithPriorityFromLabel wraps h so that the Requests it enqueues get the priority mapping assigns to the value
+// of the label key of the object of the event, e.g.
+//
+//	handler.WithPriorityFromLabel("priority", map[string]int{"high": 10}, &handler.EnqueueRequestForObject{})
+//
+// Requests get DefaultPriority if the label is absent or its value isn't in mapping.  Priorities only have an
+// effect if the queue of the Controller is a PriorityQueue.
+func WithPriorityFromLabel(key string, mapping map[string]int, h EventHandler) EventHandler {
+	return &priorityHandler{EventHandler: h, priority: func(m metav1.Object) int {
+		return lookUpPriority(m.GetLabels(), key, mapping)
+	}}
+}
+
+// WithPriorityFromAnnotation is like WithPriorityFromLabel, but reads the annotation key instead of a label.
+func WithPriorityFromAnnotation(key string, mapping map[string]int, h EventHandler) EventHandler {
+	return &priorityHandler{EventHandler: h, priority: func(m metav1.Object) int {
+		return lookUpPriority(m.GetAnnotations(), key, mapping)
+	}}
+}
+
+func lookUpPriority(values map[string]string, key string, mapping map[string]int) int {
+	value, found := values[key]
+	if !found {
+		return DefaultPriority
+	}
+	priority, found := mapping[value]
+	if !found {
+		return DefaultPriority
+	}
+	return priority
+}
+
+var _ EventHandler = &priorityHandler{}
+var _ inject.Injector = &priorityHandler{}
+
+// priorityHandler enqueues the Requests of the wrapped EventHandler with the priority of the object of
+// the event.
+type priorityHandler struct {
+	EventHandler
+
+	priority func(metav1.Object) int
+}
+
+// Create implements EventHandler
+func (h *priorityHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Create(evt, h.queueFor(evt.Meta, q))
+}
+
+// Update implements EventHandler
+func (h *priorityHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Update(evt, h.queueFor(evt.MetaNew, q))
+}
+
+// Delete implements EventHandler
+func (h *priorityHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Delete(evt, h.queueFor(evt.Meta, q))
+}
+
+// Generic implements EventHandler
+func (h *priorityHandler) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Generic(evt, h.queueFor(evt.Meta, q))
+}
+
+// InjectFunc implements inject.Injector, passing the dependencies on to the wrapped EventHandler.
+func (h *priorityHandler) InjectFunc(f inject.Func) error {
+	return f(h.EventHandler)
+}
+
+// queueFor returns a queue adding items to q with the priority of m, if q is a PriorityQueue.
+func (h *priorityHandler) queueFor(m metav1.Object, q workqueue.RateLimitingInterface) workqueue.RateLimitingInterface {
+	pq, ok := q.(PriorityQueue)
+	if !ok || m == nil {
+		return q
+	}
+	return &prioritizingQueue{PriorityQueue: pq, priority: h.priority(m)}
+}
+
+// prioritizingQueue adds items to a PriorityQueue with a fixed priority.
+type prioritizingQueue struct {
+	PriorityQueue
+
+	priority int
+}
+
+// Add implements workqueue.Interface
+func (q *prioritizingQueue) Add(item interface{}) {
+	q.AddWithPriority(item, q.priority)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// The parameters of workqueue.DefaultControllerRateLimiter.
+const (
+	defaultBaseBackoff = 5 * time.Millisecond
+	defaultMaxBackoff  = 1000 * time.Second
+	defaultQPS         = 10
+	defaultBurst       = 100
+)
+
+// NewDefaultRateLimiter returns a RateLimiter like workqueue.DefaultControllerRateLimiter, whose per-item
+// exponential backoff never exceeds maxBackoff.  The overall rate limit of its bucket is left unchanged.
+func NewDefaultRateLimiter(maxBackoff time.Duration) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		NewCappedRateLimiter(workqueue.NewItemExponentialFailureRateLimiter(defaultBaseBackoff, defaultMaxBackoff), maxBackoff),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(defaultQPS), defaultBurst)},
+	)
+}
+
+// NewCappedRateLimiter returns a RateLimiter which delays items like rateLimiter, but never by more
+// than maxDelay.
+func NewCappedRateLimiter(rateLimiter workqueue.RateLimiter, maxDelay time.Duration) workqueue.RateLimiter {
+	return &cappedRateLimiter{RateLimiter: rateLimiter, maxDelay: maxDelay}
+}
+
+// cappedRateLimiter caps the delays of a RateLimiter.
+type cappedRateLimiter struct {
+	workqueue.RateLimiter
+
+	maxDelay time.Duration
+}
+
+// When implements workqueue.RateLimiter
+func (r *cappedRateLimiter) When(item interface{}) time.Duration {
+	if d := r.RateLimiter.When(item); d < r.maxDelay {
+		return d
+	}
+	return r.maxDelay
+}
+
+var _ handler.EventHandler = resetBackoffOnUpdateHandler{}
+
+// resetBackoffOnUpdateHandler wraps an EventHandler so that any Request it enqueues in response to an
+// update carrying a new resourceVersion starts over with the shortest backoff, and is processed right
+// away rather than after the rest of its current backoff.
+type resetBackoffOnUpdateHandler struct {
+	handler.EventHandler
+}
+
+// Update implements handler.EventHandler
+func (h resetBackoffOnUpdateHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if evt.MetaOld == nil || evt.MetaNew == nil ||
+		evt.MetaOld.GetResourceVersion() == evt.MetaNew.GetResourceVersion() {
+		h.EventHandler.Update(evt, q)
+		return
+	}
+	h.EventHandler.Update(evt, &forgettingQueue{RateLimitingInterface: q})
+}
+
+// forgettingQueue forgets the failures of every item added to it.
+type forgettingQueue struct {
+	workqueue.RateLimitingInterface
+}
+
+// Add implements workqueue.Interface
+func (q *forgettingQueue) Add(item interface{}) {
+	q.Forget(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *forgettingQueue) AddWithPriority(item interface{}, priority int) {
+	q.Forget(item)
+	addWithPriority(q.RateLimitingInterface, item, priority)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// batchItem is the queue item under which all Requests sharing a batch key are processed.
+type batchItem struct {
+	key string
+}
+
+// batchingQueue groups every Request added to it by batch key.  The Request is recorded as a member of
+// its batch, and the batch itself is added to the underlying queue, so that the queue deduplicates
+// enqueues per batch rather than per Request.  A batch waits with the highest priority any of its
+// Requests was added with.
+type batchingQueue struct {
+	workqueue.RateLimitingInterface
+
+	add func(reconcile.Request) batchItem
+}
+
+// Add implements workqueue.Interface
+func (q *batchingQueue) Add(item interface{}) {
+	if req, ok := item.(reconcile.Request); ok {
+		item = q.add(req)
+	}
+	q.RateLimitingInterface.Add(item)
+}
+
+// AddRateLimited implements workqueue.RateLimitingInterface
+func (q *batchingQueue) AddRateLimited(item interface{}) {
+	if req, ok := item.(reconcile.Request); ok {
+		item = q.add(req)
+	}
+	q.RateLimitingInterface.AddRateLimited(item)
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *batchingQueue) AddWithPriority(item interface{}, priority int) {
+	if req, ok := item.(reconcile.Request); ok {
+		item = q.add(req)
+	}
+	addWithPriority(q.RateLimitingInterface, item, priority)
+}
+
+// AddAfter implements workqueue.DelayingInterface
+func (q *batchingQueue) AddAfter(item interface{}, duration time.Duration) {
+	req, ok := item.(reconcile.Request)
+	if !ok || duration <= 0 {
+		q.Add(item)
+		return
+	}
+	// Only join the batch once the delay has passed, so that the Request
+	// isn't reconciled early along with the rest of the batch.
+	time.AfterFunc(duration, func() { q.Add(req) })
+}
+
+// addToBatch records req as a member of its batch and returns the batch's queue item.
+func (c *Controller) addToBatch(req reconcile.Request) batchItem {
+	key := c.BatchKey(req)
+
+	c.batchesMu.Lock()
+	defer c.batchesMu.Unlock()
+	if c.batches == nil {
+		c.batches = map[string]map[reconcile.Request]struct{}{}
+	}
+	if c.batches[key] == nil {
+		c.batches[key] = map[reconcile.Request]struct{}{}
+	}
+	c.batches[key][req] = struct{}{}
+	return batchItem{key: key}
+}
+
+// takeBatch removes the pending members of the batch and returns them as a BatchRequest.
+func (c *Controller) takeBatch(item batchItem) reconcile.BatchRequest {
+	c.batchesMu.Lock()
+	members := c.batches[item.key]
+	delete(c.batches, item.key)
+	c.batchesMu.Unlock()
+
+	batch := reconcile.BatchRequest{Key: item.key, Requests: make([]reconcile.Request, 0, len(members))}
+	for req := range members {
+		batch.Requests = append(batch.Requests, req)
+	}
+	sort.Slice(batch.Requests, func(i, j int) bool {
+		if batch.Requests[i].Namespace != batch.Requests[j].Namespace {
+			return batch.Requests[i].Namespace < batch.Requests[j].Namespace
+		}
+		return batch.Requests[i].Name < batch.Requests[j].Name
+	})
+	return batch
+}
+
+// returnBatch puts the members of a batch that needs to be processed again back into the batch.
+func (c *Controller) returnBatch(batch reconcile.BatchRequest) {
+	for _, req := range batch.Requests {
+		c.addToBatch(req)
+	}
+}
+
+// requeueBatchAfter puts the members of batch back into the batch, and adds its item to the queue, once d has
+// passed.  The Requests only join the batch then, so that they aren't reconciled early along with it.  The
+// batch is dropped if the timers are stopped first.
+func (c *Controller) requeueBatchAfter(batch reconcile.BatchRequest, item batchItem, d time.Duration) {
+	c.batchTimersMu.Lock()
+	defer c.batchTimersMu.Unlock()
+	if c.batchTimersStopped {
+		return
+	}
+	if c.batchTimers == nil {
+		c.batchTimers = map[*time.Timer]struct{}{}
+	}
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		c.batchTimersMu.Lock()
+		defer c.batchTimersMu.Unlock()
+		if _, pending := c.batchTimers[t]; !pending {
+			return
+		}
+		delete(c.batchTimers, t)
+		c.returnBatch(batch)
+		c.Queue.Add(item)
+	})
+	c.batchTimers[t] = struct{}{}
+}
+
+// stopBatchTimers stops the pending timers of requeueBatchAfter, and keeps it from starting new ones.
+func (c *Controller) stopBatchTimers() {
+	c.batchTimersMu.Lock()
+	defer c.batchTimersMu.Unlock()
+	c.batchTimersStopped = true
+	for t := range c.batchTimers {
+		t.Stop()
+	}
+	c.batchTimers = nil
+}
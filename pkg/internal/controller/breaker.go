@@ -0,0 +1,79 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/internal/controller/metrics"
+)
+
+// healthBreaker pauses the workers of a Controller while its HealthCheck fails.
+type healthBreaker struct {
+	name  string
+	check func() error
+	stop  <-chan struct{}
+
+	mu sync.Mutex
+
+	// healthy is closed while the check succeeds, and open while it fails.  It starts
+	// open, so that no work is done before the first check succeeded.
+	healthy chan struct{}
+	paused  bool
+}
+
+func newHealthBreaker(name string, check func() error, stop <-chan struct{}) *healthBreaker {
+	return &healthBreaker{name: name, check: check, stop: stop, healthy: make(chan struct{}), paused: true}
+}
+
+// probe runs the check, and pauses or resumes the workers according to its result.
+func (b *healthBreaker) probe() {
+	err := b.check()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		if !b.paused {
+			b.healthy = make(chan struct{})
+			b.paused = true
+		}
+		log.Error(err, "Health check failed, workers are paused", "controller", b.name)
+		ctrlmetrics.RecordReconcilePaused(b.name, true)
+		return
+	}
+
+	if b.paused {
+		close(b.healthy)
+		b.paused = false
+		log.Info("Health check succeeded, resuming workers", "controller", b.name)
+	}
+	ctrlmetrics.RecordReconcilePaused(b.name, false)
+}
+
+// waitUntilHealthy blocks until the check succeeds, and returns false if the Controller is stopped before.
+func (b *healthBreaker) waitUntilHealthy() bool {
+	b.mu.Lock()
+	healthy := b.healthy
+	b.mu.Unlock()
+
+	select {
+	case <-healthy:
+		return true
+	case <-b.stop:
+		return false
+	}
+}
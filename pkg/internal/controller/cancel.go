@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ handler.EventHandler = cancelOnNewerVersionHandler{}
+
+// cancelOnNewerVersionHandler wraps an EventHandler so that any Request it enqueues in response to
+// an update carrying a new resourceVersion cancels the in-flight reconcile for that Request.
+type cancelOnNewerVersionHandler struct {
+	handler.EventHandler
+
+	cancel func(reconcile.Request)
+}
+
+// Update implements handler.EventHandler
+func (h cancelOnNewerVersionHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if evt.MetaOld == nil || evt.MetaNew == nil ||
+		evt.MetaOld.GetResourceVersion() == evt.MetaNew.GetResourceVersion() {
+		h.EventHandler.Update(evt, q)
+		return
+	}
+	h.EventHandler.Update(evt, &cancellingQueue{RateLimitingInterface: q, cancel: h.cancel})
+}
+
+// cancellingQueue cancels the in-flight reconcile of every Request added to it.
+type cancellingQueue struct {
+	workqueue.RateLimitingInterface
+
+	cancel func(reconcile.Request)
+}
+
+// Add implements workqueue.Interface
+func (q *cancellingQueue) Add(item interface{}) {
+	// Add before cancelling so that the queue already knows the item is dirty
+	// by the time the worker marks the cancelled item as Done.
+	q.RateLimitingInterface.Add(item)
+	if req, ok := item.(reconcile.Request); ok {
+		q.cancel(req)
+	}
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *cancellingQueue) AddWithPriority(item interface{}, priority int) {
+	addWithPriority(q.RateLimitingInterface, item, priority)
+	if req, ok := item.(reconcile.Request); ok {
+		q.cancel(req)
+	}
+}
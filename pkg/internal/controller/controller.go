@@ -17,18 +17,28 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/audit"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/internal/controller/metrics"
 	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
@@ -92,6 +102,138 @@ type Controller struct {
 	// Kubernetes API.
 	Recorder record.EventRecorder
 
+	// CancelOnNewerVersion, if true, cancels the context of an in-flight reconcile when its Request is
+	// enqueued again in response to an update carrying a newer resourceVersion.  The Request is then
+	// reprocessed with fresh data.  Only Reconcilers implementing reconcile.ContextReconciler observe
+	// the cancellation.
+	CancelOnNewerVersion bool
+
+	// ResetBackoffOnUpdate, if true, forgets the failures of a Request when it is enqueued again in
+	// response to an update carrying a newer resourceVersion, so that it is processed right away and
+	// backs off from the start if it fails again.
+	ResetBackoffOnUpdate bool
+
+	// RecordTriggerEvents, if true, records the event which enqueued each Request and passes it to
+	// Reconcilers implementing reconcile.ContextReconciler with reconcile.WithTriggerEvent.  It has no
+	// effect on batches.
+	RecordTriggerEvents bool
+
+	// RecordQueueWait, if true, records when EventHandlers enqueue each Request, observes how long it waited
+	// until its reconcile started, and passes the time it was enqueued to Reconcilers implementing
+	// reconcile.ContextReconciler with reconcile.WithEnqueueTime.  It has no effect on batches.
+	RecordQueueWait bool
+
+	// DefaultRequeueAfter, if positive, requeues every Request after this duration when its reconcile
+	// returned an empty Result and no error, as long as the object of the For type still exists in Cache.
+	DefaultRequeueAfter time.Duration
+
+	// ReadYourWrites, if true, passes a client.NewOverlayClient of Client to every reconcile of Reconcilers
+	// implementing reconcile.ContextReconciler with reconcile.WithClient.
+	ReadYourWrites bool
+
+	// StatusWriter, if set, is passed to every reconcile of Reconcilers implementing
+	// reconcile.ContextReconciler with reconcile.WithStatusWriter.
+	StatusWriter client.StatusWriter
+
+	// KeyParser, if set, parses every Request before it is reconciled, and passes the key to Reconcilers
+	// implementing reconcile.ContextReconciler with reconcile.WithKey instead of the NamespacedName.
+	// Requests which fail to parse are dropped without being reconciled.
+	KeyParser reconcile.KeyParser
+
+	// Audit, if set, is called with the audit record of every reconcile of a Request.  It must not block.
+	// Reconciles of batches aren't audited.
+	Audit func(audit.Record)
+
+	// enqueued are the times the Requests waiting to be reconciled were enqueued by EventHandlers.
+	enqueued   map[reconcile.Request]time.Time
+	enqueuedMu sync.Mutex
+
+	// triggers are the recorded events of the Requests waiting to be reconciled.
+	triggers   map[reconcile.Request]reconcile.TriggerEvent
+	triggersMu sync.Mutex
+
+	// inFlight tracks the reconciles currently being processed when CancelOnNewerVersion is set.
+	inFlight   map[reconcile.Request]*inFlightReconcile
+	inFlightMu sync.Mutex
+
+	// processing counts the queue items currently being processed by the workers, for ShutdownDiagnostics.
+	processing   map[interface{}]int
+	processingMu sync.Mutex
+
+	// BatchKey, if set, groups enqueued Requests by the key it computes for them, and the Requests sharing
+	// a key are reconciled together with a single call to ReconcileBatch.  Do must implement
+	// reconcile.BatchReconciler.  CancelOnNewerVersion has no effect on batches.
+	BatchKey func(reconcile.Request) string
+
+	// For is the type of the objects reconciled by Do.  It is required by EnqueueAll, and the updates of the
+	// objects of this type watched by the Controller start their reconcile.ProgressiveRequeues over.
+	For runtime.Object
+
+	// ObservedGenerationFor, if set, is the type of the objects reconciled by Do.  The Controller reads the
+	// object of this type with Client before every reconcile, and after a successful one reads it again and
+	// patches its status.observedGeneration to the metadata.generation it read first, if it changed.  The
+	// patch is skipped if the generation changed during the reconcile.
+	ObservedGenerationFor runtime.Object
+
+	// HealthCheck, if set, is polled every HealthCheckPeriod to check the dependencies of Do.  While it
+	// returns an error, workers stop dequeuing items, leaving them queued, until it succeeds again.
+	HealthCheck func() error
+
+	// HealthCheckPeriod is how often HealthCheck is polled.  Defaults to 10 seconds.
+	HealthCheckPeriod time.Duration
+
+	// MetricsClass, if set, returns the metrics class of the object of a Request, under which its
+	// reconciles are additionally counted and timed.  It must only return a bounded set of values, e.g.
+	// by being created with NewMetricsClass.
+	MetricsClass func(reconcile.Request) string
+
+	// MinReconcileInterval, if positive, is how long a Request has to wait after the start of its last
+	// reconcile before it is reconciled again.  Requests dequeued earlier are queued again for when the
+	// interval has passed, coalescing the events received meanwhile.  It has no effect on batches.
+	MinReconcileInterval time.Duration
+
+	// RequeueReasons are the reasons of requeues which are counted under their own name.  Requeues for
+	// other reasons are counted under OtherMetricsClass, bounding the number of metrics series.
+	RequeueReasons []string
+
+	// CancelOnShutdown, if true, cancels the context of the reconciles in flight once the Controller stops,
+	// and makes Start wait for them to return, running the save-points they registered with
+	// reconcile.RegisterSavePoint.  Items dequeued afterwards aren't reconciled.  Reconciles of batches
+	// aren't cancelled.
+	CancelOnShutdown bool
+
+	// MaxProgressiveRequeueAfter, if positive, caps the delays of the requeues of reconcile.ProgressiveRequeue
+	// Results, whatever their own Max.
+	MaxProgressiveRequeueAfter time.Duration
+
+	// progressions tracks the Progressions of the Requests requeued by reconcile.ProgressiveRequeue.
+	progressions progressions
+
+	// shutdown cancels the reconciles on shutdown if CancelOnShutdown is set.
+	shutdown *shutdownCanceller
+
+	// waitForWorkers makes Start wait for the workers to return once stopped.
+	waitForWorkers bool
+
+	// breaker pauses the workers while HealthCheck fails.
+	breaker *healthBreaker
+
+	// intervals enforces MinReconcileInterval.
+	intervals *reconcileIntervals
+
+	// watches describe the watches of the Controller, for DescribeConfig.
+	watches   []WatchDescription
+	watchesMu sync.Mutex
+
+	// batches holds the Requests waiting to be processed for each batch key when BatchKey is set.
+	batches   map[string]map[reconcile.Request]struct{}
+	batchesMu sync.Mutex
+
+	// batchTimers requeue the batches which asked for RequeueAfter, until they are stopped along with the queue.
+	batchTimers        map[*time.Timer]struct{}
+	batchTimersStopped bool
+	batchTimersMu      sync.Mutex
+
 	// TODO(community): Consider initializing a logger with the Controller Name as the tag
 }
 
@@ -100,6 +242,62 @@ func (c *Controller) Reconcile(r reconcile.Request) (reconcile.Result, error) {
 	return c.Do.Reconcile(r)
 }
 
+// enqueueAllQPS and enqueueAllBurst pace the Requests of EnqueueAll like the default rate limiter of
+// controllers.
+const (
+	enqueueAllQPS   = rate.Limit(10)
+	enqueueAllBurst = 100
+)
+
+// EnqueueAll implements controller.AllEnqueuer
+func (c *Controller) EnqueueAll() error {
+	if c.For == nil {
+		return fmt.Errorf("must specify For to enqueue all objects of controller %s", c.Name)
+	}
+
+	gvk, err := apiutil.GVKForObject(c.For, c.Scheme)
+	if err != nil {
+		return err
+	}
+	var list runtime.Object
+	if _, isUnstructured := c.For.(*unstructured.Unstructured); isUnstructured {
+		ul := &unstructured.UnstructuredList{}
+		ul.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		list = ul
+	} else if list, err = c.Scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List")); err != nil {
+		return err
+	}
+
+	if err := c.Cache.List(context.TODO(), list); err != nil {
+		return err
+	}
+	// Pace the Requests, so that all objects aren't reconciled at once.  This uses its own limiter rather
+	// than the one of the queue, which would count the Requests as failures and back off later retries.
+	pacer := &workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(enqueueAllQPS, enqueueAllBurst)}
+	queue := c.eventQueue()
+	if c.RecordTriggerEvents && c.BatchKey == nil {
+		queue = &recordingQueue{RateLimitingInterface: queue, record: func(req reconcile.Request) {
+			c.recordTrigger(req, reconcile.TriggerEvent{Verb: reconcile.TriggerUnknown})
+		}}
+	}
+	return meta.EachListItem(list, func(obj runtime.Object) error {
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		req := reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: objMeta.GetNamespace(),
+			Name:      objMeta.GetName(),
+		}}
+		if delay := pacer.When(req); delay > 0 {
+			queue.AddAfter(req, delay)
+		} else {
+			queue.Add(req)
+		}
+		return nil
+	})
+}
+
 // Watch implements controller.Controller
 func (c *Controller) Watch(src source.Source, evthdler handler.EventHandler, prct ...predicate.Predicate) error {
 	c.mu.Lock()
@@ -117,18 +315,52 @@ func (c *Controller) Watch(src source.Source, evthdler handler.EventHandler, prc
 			return err
 		}
 	}
+	c.recordWatch(src, evthdler, prct)
+
+	if c.CancelOnNewerVersion {
+		evthdler = cancelOnNewerVersionHandler{EventHandler: evthdler, cancel: c.cancelInFlight}
+	}
+	if c.ResetBackoffOnUpdate {
+		evthdler = resetBackoffOnUpdateHandler{EventHandler: evthdler}
+	}
+	if c.watchesFor(src) {
+		evthdler = resetProgressionOnUpdateHandler{EventHandler: evthdler, reset: c.progressions.reset}
+	}
+	if c.RecordTriggerEvents && c.BatchKey == nil {
+		evthdler = triggerEventHandler{EventHandler: evthdler, record: c.recordTrigger}
+	}
 
 	log.Info("Starting EventSource", "controller", c.Name, "source", src)
-	return src.Start(evthdler, c.Queue, prct...)
+	return src.Start(evthdler, c.eventQueue(), prct...)
+}
+
+// eventQueue returns the queue through which the Requests of events are enqueued.
+func (c *Controller) eventQueue() workqueue.RateLimitingInterface {
+	if c.BatchKey != nil {
+		return &batchingQueue{RateLimitingInterface: c.Queue, add: c.addToBatch}
+	}
+	if c.RecordQueueWait {
+		return &enqueueTimingQueue{RateLimitingInterface: c.Queue, record: c.recordEnqueue}
+	}
+	return c.Queue
 }
 
 // Start implements controller.Controller
 func (c *Controller) Start(stop <-chan struct{}) error {
 	c.mu.Lock()
 
+	var workers sync.WaitGroup
+	if c.waitForWorkers {
+		// Deferred first, so that the workers are waited for once the queue is shut down, which makes
+		// them return after draining it.
+		defer workers.Wait()
+	}
+
 	// TODO(pwittrock): Reconsider HandleCrash
 	defer utilruntime.HandleCrash()
 	defer c.Queue.ShutDown()
+	// Stop the batch timers before the queue is shut down, so that they never add to it afterwards.
+	defer c.stopBatchTimers()
 
 	// Start the SharedIndexInformer factories to begin populating the SharedIndexInformer caches
 	log.Info("Starting Controller", "controller", c.Name)
@@ -150,11 +382,31 @@ func (c *Controller) Start(stop <-chan struct{}) error {
 		c.JitterPeriod = 1 * time.Second
 	}
 
+	if c.HealthCheck != nil {
+		if c.HealthCheckPeriod == 0 {
+			c.HealthCheckPeriod = 10 * time.Second
+		}
+		c.breaker = newHealthBreaker(c.Name, c.HealthCheck, stop)
+		go wait.Until(c.breaker.probe, c.HealthCheckPeriod, stop)
+	}
+
+	if c.MinReconcileInterval > 0 {
+		c.intervals = newReconcileIntervals(c.MinReconcileInterval)
+	}
+
+	if c.CancelOnShutdown {
+		c.shutdown = newShutdownCanceller()
+	}
+
 	// Launch workers to process resources
 	log.Info("Starting workers", "controller", c.Name, "worker count", c.MaxConcurrentReconciles)
 	for i := 0; i < c.MaxConcurrentReconciles; i++ {
-		// Process work items
-		go wait.Until(c.worker, c.JitterPeriod, stop)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			// Process work items
+			wait.Until(c.worker, c.JitterPeriod, stop)
+		}()
 	}
 
 	c.Started = true
@@ -162,9 +414,21 @@ func (c *Controller) Start(stop <-chan struct{}) error {
 
 	<-stop
 	log.Info("Stopping workers", "controller", c.Name)
+	if c.shutdown != nil {
+		c.shutdown.stop()
+	}
 	return nil
 }
 
+// WaitForWorkers makes Start wait for the workers to return once stopped, so that the reconciles in flight
+// keep the Controller running, and show up in its ShutdownDiagnostics if they don't return in time.  The
+// Manager calls it if it has a graceful shutdown timeout.
+func (c *Controller) WaitForWorkers() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waitForWorkers = true
+}
+
 // worker runs a worker thread that just dequeues items, processes them, and marks them done.
 // It enforces that the reconcileHandler is never invoked concurrently with the same object.
 func (c *Controller) worker() {
@@ -175,6 +439,12 @@ func (c *Controller) worker() {
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the reconcileHandler.
 func (c *Controller) processNextWorkItem() bool {
+	// Don't take items off the queue while the HealthCheck fails.
+	if c.breaker != nil && !c.breaker.waitUntilHealthy() {
+		// Stop working
+		return false
+	}
+
 	obj, shutdown := c.Queue.Get()
 	if shutdown {
 		// Stop working
@@ -188,6 +458,30 @@ func (c *Controller) processNextWorkItem() bool {
 	// put back on the workqueue and attempted again after a back-off
 	// period.
 	defer c.Queue.Done(obj)
+	defer c.trackProcessing(obj)()
+
+	if c.shutdown != nil {
+		if !c.shutdown.begin() {
+			// Stopping, so leave the item to be reconciled again after a restart.
+			return false
+		}
+		defer c.shutdown.end()
+	}
+
+	// The HealthCheck may have started failing while waiting for the item, so hold
+	// on to the item until it succeeds again.
+	if c.breaker != nil && !c.breaker.waitUntilHealthy() {
+		return false
+	}
+
+	if req, ok := obj.(reconcile.Request); ok && c.intervals != nil {
+		if delay := c.intervals.wait(req, time.Now()); delay > 0 {
+			// Reconciled too recently, so hold the Request back without counting it as a failure.
+			// Events received until then are coalesced into the queued Request.
+			c.Queue.AddAfter(req, delay)
+			return true
+		}
+	}
 
 	return c.reconcileHandler(obj)
 }
@@ -195,11 +489,22 @@ func (c *Controller) processNextWorkItem() bool {
 func (c *Controller) reconcileHandler(obj interface{}) bool {
 	// Update metrics after processing each item
 	reconcileStartTS := time.Now()
+	var req reconcile.Request
+	var class, outcome, correlationID string
+	var reconcileErr error
 	defer func() {
-		c.updateMetrics(time.Now().Sub(reconcileStartTS))
+		duration := time.Now().Sub(reconcileStartTS)
+		c.updateMetrics(class, duration)
+		if len(outcome) > 0 {
+			c.recordReconcile(class, outcome)
+			c.audit(req, outcome, reconcileErr, correlationID, duration)
+		}
 	}()
 
-	var req reconcile.Request
+	if item, ok := obj.(batchItem); ok {
+		return c.reconcileBatchHandler(item)
+	}
+
 	var ok bool
 	if req, ok = obj.(reconcile.Request); !ok {
 		// As the item in the workqueue is actually invalid, we call
@@ -211,48 +516,350 @@ func (c *Controller) reconcileHandler(obj interface{}) bool {
 		// Return true, don't take a break
 		return true
 	}
+	if c.MetricsClass != nil {
+		class = c.MetricsClass(req)
+	}
+	enqueued, wasEnqueued := c.takeEnqueueTime(req)
+	if wasEnqueued {
+		ctrlmetrics.RecordReconcileQueueWait(c.Name, queueWait(enqueued, reconcileStartTS))
+	}
+	// Take the trigger right away, so that it isn't left behind if the Request is dropped below.
+	var trigger reconcile.TriggerEvent
+	if c.RecordTriggerEvents {
+		trigger = c.takeTrigger(req)
+	}
+
+	var key interface{} = req.NamespacedName
+	if c.KeyParser != nil {
+		var err error
+		if key, err = c.KeyParser(req); err != nil {
+			// Retrying won't make the name of the object parse, so don't requeue it.
+			c.Queue.Forget(obj)
+			log.Error(err, "Unable to parse the key of the Request, dropping it", "controller", c.Name, "request", req)
+			outcome, reconcileErr = "invalid_key", err
+			return true
+		}
+	}
+
+	// Remember which generation of the object is about to be reconciled, so that only that one is
+	// reported as observed afterwards.
+	observed, err := c.observedObject(req)
+	if err != nil {
+		c.Queue.AddRateLimited(req)
+		log.Error(err, "Failed to read the object to reconcile", "controller", c.Name, "request", req)
+		outcome, reconcileErr = "error", err
+		return false
+	}
+
 	// RunInformersAndControllers the syncHandler, passing it the namespace/Name string of the
 	// resource to be synced.
-	if result, err := c.Do.Reconcile(req); err != nil {
+	ctx, finish := c.trackReconcile(req)
+	ctx = reconcile.WithKey(ctx, key)
+	if c.RecordTriggerEvents {
+		ctx = reconcile.WithTriggerEvent(ctx, trigger)
+	}
+	if wasEnqueued {
+		ctx = reconcile.WithEnqueueTime(ctx, enqueued)
+	}
+	if c.Audit != nil {
+		correlationID = string(uuid.NewUUID())
+		ctx = reconcile.WithCorrelationID(ctx, correlationID)
+	}
+	var savePoints *reconcile.SavePoints
+	if c.shutdown != nil {
+		savePoints = &reconcile.SavePoints{}
+		ctx = reconcile.WithSavePoints(ctx, savePoints)
+	}
+	result, err := c.doReconcile(ctx, req)
+	if savePoints != nil && c.shutdown.stopping() {
+		c.runSavePoints(req, savePoints)
+	}
+	if superseded := finish(); superseded {
+		// A newer version of the object has already been enqueued, so drop
+		// this result and let the queue reprocess the Request with fresh data.
+		c.Queue.Forget(obj)
+		log.V(1).Info("Reconcile cancelled by a newer version of the object", "controller", c.Name, "request", req)
+		outcome = "cancelled"
+		return true
+	}
+
+	if err != nil {
 		c.Queue.AddRateLimited(req)
 		log.Error(err, "Reconciler error", "controller", c.Name, "request", req)
-		ctrlmetrics.ReconcileErrors.WithLabelValues(c.Name).Inc()
-		ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, "error").Inc()
+		outcome, reconcileErr = "error", err
 		return false
-	} else if result.RequeueAfter > 0 {
+	} else if result.Progression != nil {
+		c.Queue.Forget(obj)
+		delay := c.progressions.next(req, *result.Progression, c.MaxProgressiveRequeueAfter)
+		c.Queue.AddAfter(req, delay)
+		result.RequeueAfter = delay
+		c.recordRequeue(req, result)
+		outcome = "requeue_after"
+		return true
+	}
+	c.progressions.reset(req)
+	if result.RequeueAfter > 0 {
 		// The result.RequeueAfter request will be lost, if it is returned
 		// along with a non-nil error. But this is intended as
 		// We need to drive to stable reconcile loops before queuing due
 		// to result.RequestAfter
 		c.Queue.Forget(obj)
 		c.Queue.AddAfter(req, result.RequeueAfter)
-		ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, "requeue_after").Inc()
+		c.recordRequeue(req, result)
+		outcome = "requeue_after"
 		return true
 	} else if result.Requeue {
 		c.Queue.AddRateLimited(req)
-		ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, "requeue").Inc()
+		c.recordRequeue(req, result)
+		outcome = "requeue"
 		return true
 	}
 
+	if err := c.updateObservedGeneration(req, observed); err != nil {
+		c.Queue.AddRateLimited(req)
+		log.Error(err, "Failed to update observedGeneration", "controller", c.Name, "request", req)
+		outcome, reconcileErr = "error", err
+		return false
+	}
+
 	// Finally, if no error occurs we Forget this item so it does not
 	// get queued again until another change happens.
 	c.Queue.Forget(obj)
+	c.requeueByDefault(req)
 
 	// TODO(directxman12): What does 1 mean?  Do we want level constants?  Do we want levels at all?
 	log.V(1).Info("Successfully Reconciled", "controller", c.Name, "request", req)
 
-	ctrlmetrics.ReconcileTotal.WithLabelValues(c.Name, "success").Inc()
+	outcome = "success"
 	// Return true, don't take a break
 	return true
 }
 
+func (c *Controller) reconcileBatchHandler(item batchItem) bool {
+	batch := c.takeBatch(item)
+	if len(batch.Requests) == 0 {
+		// All members have already been reconciled as part of an earlier pass.
+		c.Queue.Forget(item)
+		return true
+	}
+
+	br, ok := c.Do.(reconcile.BatchReconciler)
+	if !ok {
+		c.Queue.Forget(item)
+		log.Error(nil, "Reconciler does not implement reconcile.BatchReconciler",
+			"controller", c.Name, "type", fmt.Sprintf("%T", c.Do))
+		return true
+	}
+
+	result, err := br.ReconcileBatch(batch)
+	if err != nil {
+		c.returnBatch(batch)
+		c.Queue.AddRateLimited(item)
+		log.Error(err, "Reconciler error", "controller", c.Name, "batch", batch.Key, "size", len(batch.Requests))
+		ctrlmetrics.RecordReconcile(c.Name, "error")
+		return false
+	} else if result.RequeueAfter > 0 {
+		c.Queue.Forget(item)
+		c.requeueBatchAfter(batch, item, result.RequeueAfter)
+		ctrlmetrics.RecordReconcile(c.Name, "requeue_after")
+		return true
+	} else if result.Requeue {
+		c.returnBatch(batch)
+		c.Queue.AddRateLimited(item)
+		ctrlmetrics.RecordReconcile(c.Name, "requeue")
+		return true
+	}
+
+	c.Queue.Forget(item)
+	log.V(1).Info("Successfully Reconciled", "controller", c.Name, "batch", batch.Key, "size", len(batch.Requests))
+	ctrlmetrics.RecordReconcile(c.Name, "success")
+	return true
+}
+
+// requeueByDefault requeues req after DefaultRequeueAfter, unless its object is gone.
+func (c *Controller) requeueByDefault(req reconcile.Request) {
+	if c.DefaultRequeueAfter <= 0 || c.For == nil {
+		return
+	}
+	obj := c.For.DeepCopyObject()
+	if err := c.Cache.Get(context.TODO(), req.NamespacedName, obj); apierrors.IsNotFound(err) {
+		// Don't keep reconciling deleted objects.
+		return
+	}
+	c.Queue.AddAfter(req, c.DefaultRequeueAfter)
+}
+
+// observedObject returns the object of req as it is about to be reconciled, if the Controller has been
+// configured to set its observedGeneration, or nil otherwise or if it doesn't exist.
+func (c *Controller) observedObject(req reconcile.Request) (runtime.Object, error) {
+	if c.ObservedGenerationFor == nil {
+		return nil, nil
+	}
+
+	obj := c.ObservedGenerationFor.DeepCopyObject()
+	if err := c.Client.Get(context.TODO(), req.NamespacedName, obj); err != nil {
+		// The object is gone, so there is nothing to report on.
+		return nil, client.IgnoreNotFound(err)
+	}
+	return obj, nil
+}
+
+// updateObservedGeneration sets the observedGeneration of the object of req to the generation of observed,
+// the object as it was before its reconcile.  The object is read again, since the reconcile may have
+// updated it, and only its status.observedGeneration is patched, so that this doesn't conflict with the
+// status written by the reconcile.  If the generation changed during the reconcile, the object is left to
+// the reconcile of the new generation.
+func (c *Controller) updateObservedGeneration(req reconcile.Request, observed runtime.Object) error {
+	if observed == nil {
+		return nil
+	}
+
+	current := c.ObservedGenerationFor.DeepCopyObject()
+	if err := c.Client.Get(context.TODO(), req.NamespacedName, current); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	observedMeta, err := meta.Accessor(observed)
+	if err != nil {
+		return err
+	}
+	currentMeta, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+	if currentMeta.GetGeneration() != observedMeta.GetGeneration() {
+		log.V(1).Info("Not updating the observedGeneration of an object whose generation changed during its reconcile",
+			"controller", c.Name, "request", req)
+		return nil
+	}
+
+	patch := client.MergeFrom(current.DeepCopyObject())
+	changed, err := controllerutil.SetObservedGeneration(current)
+	if err != nil || !changed {
+		return err
+	}
+	return c.Client.Status().Patch(context.TODO(), current, patch)
+}
+
+// doReconcile calls the Reconciler, passing ctx along if the Reconciler accepts a context.
+func (c *Controller) doReconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	if c.For != nil {
+		ctx = reconcile.WithEnqueueAll(ctx, c.EnqueueAll)
+	}
+	if c.ReadYourWrites {
+		ctx = reconcile.WithClient(ctx, client.NewOverlayClient(c.Client, c.Scheme))
+	}
+	if c.StatusWriter != nil {
+		ctx = reconcile.WithStatusWriter(ctx, c.StatusWriter)
+	}
+	if cr, ok := c.Do.(reconcile.ContextReconciler); ok {
+		return cr.ReconcileContext(ctx, req)
+	}
+	return c.Do.Reconcile(req)
+}
+
+// inFlightReconcile is a reconcile that is currently being processed.
+type inFlightReconcile struct {
+	cancel     context.CancelFunc
+	superseded bool
+}
+
+// trackReconcile returns the context to reconcile req with, and a function to be called once the
+// reconcile has finished.  That function reports whether the reconcile was cancelled because a
+// newer version of the object was enqueued in the meantime.
+func (c *Controller) trackReconcile(req reconcile.Request) (context.Context, func() bool) {
+	base := context.Background()
+	if c.shutdown != nil {
+		base = c.shutdown.ctx
+	}
+	if !c.CancelOnNewerVersion {
+		return base, func() bool { return false }
+	}
+
+	ctx, cancel := context.WithCancel(base)
+	r := &inFlightReconcile{cancel: cancel}
+
+	c.inFlightMu.Lock()
+	if c.inFlight == nil {
+		c.inFlight = map[reconcile.Request]*inFlightReconcile{}
+	}
+	c.inFlight[req] = r
+	c.inFlightMu.Unlock()
+
+	return ctx, func() bool {
+		c.inFlightMu.Lock()
+		defer c.inFlightMu.Unlock()
+		delete(c.inFlight, req)
+		cancel()
+		return r.superseded
+	}
+}
+
+// cancelInFlight cancels the in-flight reconcile for req, if there is one.
+func (c *Controller) cancelInFlight(req reconcile.Request) {
+	c.inFlightMu.Lock()
+	defer c.inFlightMu.Unlock()
+	if r, ok := c.inFlight[req]; ok {
+		r.superseded = true
+		r.cancel()
+	}
+}
+
 // InjectFunc implement SetFields.Injector
 func (c *Controller) InjectFunc(f inject.Func) error {
 	c.SetFields = f
 	return nil
 }
 
-// updateMetrics updates prometheus metrics within the controller
-func (c *Controller) updateMetrics(reconcileTime time.Duration) {
-	ctrlmetrics.ReconcileTime.WithLabelValues(c.Name).Observe(reconcileTime.Seconds())
+// updateMetrics updates the metrics within the controller
+func (c *Controller) updateMetrics(class string, reconcileTime time.Duration) {
+	ctrlmetrics.RecordReconcileTime(c.Name, reconcileTime)
+	if len(class) > 0 {
+		ctrlmetrics.RecordReconcileClassTime(c.Name, class, reconcileTime)
+	}
+}
+
+// audit passes the audit record of a reconcile to Audit, if set.
+func (c *Controller) audit(req reconcile.Request, result string, err error, correlationID string, duration time.Duration) {
+	if c.Audit == nil {
+		return
+	}
+	r := audit.Record{
+		Controller:    c.Name,
+		Namespace:     req.Namespace,
+		Name:          req.Name,
+		Result:        result,
+		Duration:      duration,
+		CorrelationID: correlationID,
+		Time:          time.Now(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	c.Audit(r)
+}
+
+// recordReconcile counts a reconcile with the given result, also under its metrics class if it has one.
+func (c *Controller) recordReconcile(class, result string) {
+	ctrlmetrics.RecordReconcile(c.Name, result)
+	if len(class) > 0 {
+		ctrlmetrics.RecordReconcileClass(c.Name, class, result)
+	}
+}
+
+// recordRequeue logs and counts the requeue of req, if its Result tells the reason.
+func (c *Controller) recordRequeue(req reconcile.Request, result reconcile.Result) {
+	if len(result.RequeueReason) == 0 {
+		return
+	}
+	log.V(1).Info("Requeuing", "controller", c.Name, "request", req,
+		"reason", result.RequeueReason, "after", result.RequeueAfter)
+
+	reason := OtherMetricsClass
+	for _, r := range c.RequeueReasons {
+		if r == result.RequeueReason {
+			reason = r
+			break
+		}
+	}
+	ctrlmetrics.RecordRequeue(c.Name, reason)
 }
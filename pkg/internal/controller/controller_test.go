@@ -17,7 +17,11 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -26,13 +30,21 @@ import (
 	dto "github.com/prometheus/client_model/go"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/audit"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/internal/controller/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile/reconciletest"
@@ -85,6 +97,83 @@ var _ = Describe("controller", func() {
 		})
 	})
 
+	Describe("EnqueueAll", func() {
+		BeforeEach(func() {
+			ctrl.Scheme = scheme.Scheme
+			ctrl.For = &appsv1.Deployment{}
+			ctrl.Cache = &clientBackedCache{
+				FakeInformers: informers,
+				Reader: fake.NewFakeClient(
+					&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"}},
+					&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "baz"}},
+				),
+			}
+		})
+
+		It("should enqueue a Request for every object of the For type", func() {
+			Expect(ctrl.EnqueueAll()).To(Succeed())
+
+			Expect(ctrl.Queue.Len()).To(Equal(2))
+			first, _ := ctrl.Queue.Get()
+			second, _ := ctrl.Queue.Get()
+			Expect([]interface{}{first, second}).To(ConsistOf(
+				reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "bar"}},
+				reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "baz"}},
+			))
+		})
+
+		It("should not count the Requests as failures", func() {
+			Expect(ctrl.EnqueueAll()).To(Succeed())
+			Expect(ctrl.Queue.NumRequeues(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "bar"}})).To(Equal(0))
+		})
+
+		It("should batch the Requests with BatchKey", func() {
+			ctrl.BatchKey = func(r reconcile.Request) string { return r.Namespace }
+			Expect(ctrl.EnqueueAll()).To(Succeed())
+
+			Expect(ctrl.Queue.Len()).To(Equal(1))
+			item, _ := ctrl.Queue.Get()
+			Expect(item).To(BeAssignableToTypeOf(batchItem{}))
+		})
+
+		It("should record an unknown trigger for the Requests with RecordTriggerEvents", func() {
+			ctrl.RecordTriggerEvents = true
+			ctrl.recordTrigger(request, reconcile.TriggerEvent{Verb: reconcile.TriggerCreate})
+			Expect(ctrl.EnqueueAll()).To(Succeed())
+			Expect(ctrl.takeTrigger(request).Verb).To(Equal(reconcile.TriggerUnknown))
+		})
+
+		It("should return an error if For is not specified", func() {
+			ctrl.For = nil
+			err := ctrl.EnqueueAll()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must specify For"))
+		})
+
+		It("should be available to the Reconciler from the reconcile context", func(done Done) {
+			ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+				defer GinkgoRecover()
+				enqueueAll := reconcile.EnqueueAllFromContext(ctx)
+				Expect(enqueueAll).NotTo(BeNil())
+				Expect(enqueueAll()).To(Succeed())
+				reconciled <- r
+				return reconcile.Result{}, nil
+			})
+			go func() {
+				defer GinkgoRecover()
+				Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+			}()
+			ctrl.Queue.Add(request)
+			Expect(<-reconciled).To(Equal(request))
+
+			By("reconciling the other object enqueued from the context")
+			Expect(<-reconciled).To(Equal(reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: "foo", Name: "baz"}}))
+
+			close(done)
+		})
+	})
+
 	Describe("Start", func() {
 		It("should return an error if there is an error waiting for the informers", func(done Done) {
 			ctrl.WaitForCacheSync = func(<-chan struct{}) bool { return false }
@@ -445,228 +534,1559 @@ var _ = Describe("controller", func() {
 			// TODO(community): write this test
 		})
 
-		Context("prometheus metric reconcile_total", func() {
-			var reconcileTotal dto.Metric
+		Context("with CancelOnNewerVersion", func() {
+			var evthdler handler.EventHandler
+			var evtQueue workqueue.RateLimitingInterface
+
+			updateEvent := func(oldVersion, newVersion string) event.UpdateEvent {
+				return event.UpdateEvent{
+					MetaOld:   &metav1.ObjectMeta{Namespace: "foo", Name: "bar", ResourceVersion: oldVersion},
+					ObjectOld: &corev1.Pod{},
+					MetaNew:   &metav1.ObjectMeta{Namespace: "foo", Name: "bar", ResourceVersion: newVersion},
+					ObjectNew: &corev1.Pod{},
+				}
+			}
 
 			BeforeEach(func() {
-				ctrlmetrics.ReconcileTotal.Reset()
-				reconcileTotal.Reset()
+				ctrl.CancelOnNewerVersion = true
+				src := source.Func(func(h handler.EventHandler, q workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+					evthdler, evtQueue = h, q
+					return nil
+				})
+				Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{})).To(Succeed())
 			})
 
-			It("should get updated on successful reconciliation", func(done Done) {
-				Expect(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "success").Write(&reconcileTotal)).To(Succeed())
-					if reconcileTotal.GetCounter().GetValue() != 0.0 {
-						return fmt.Errorf("metric reconcile total not reset")
+			It("should cancel an in-flight reconcile when a newer version is enqueued", func(done Done) {
+				started := make(chan struct{})
+				var calls int32
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					if atomic.AddInt32(&calls, 1) == 1 {
+						close(started)
+						<-ctx.Done()
+						return reconcile.Result{}, ctx.Err()
 					}
-					return nil
-				}()).Should(Succeed())
-
+					reconciled <- r
+					return reconcile.Result{}, nil
+				})
 				go func() {
 					defer GinkgoRecover()
 					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
 				}()
-				By("Invoking Reconciler which will succeed")
 				ctrl.Queue.Add(request)
+				<-started
 
+				By("enqueuing an update with a newer resourceVersion")
+				evthdler.Update(updateEvent("1", "2"), evtQueue)
+
+				By("reprocessing the Request after the cancellation")
 				Expect(<-reconciled).To(Equal(request))
-				Eventually(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "success").Write(&reconcileTotal)).To(Succeed())
-					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
-						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
-					}
-					return nil
-				}, 2.0).Should(Succeed())
+				Eventually(ctrl.Queue.Len).Should(Equal(0))
+				Expect(ctrl.Queue.NumRequeues(request)).To(Equal(0))
 
 				close(done)
-			}, 2.0)
-
-			It("should get updated on reconcile errors", func(done Done) {
-				Expect(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "error").Write(&reconcileTotal)).To(Succeed())
-					if reconcileTotal.GetCounter().GetValue() != 0.0 {
-						return fmt.Errorf("metric reconcile total not reset")
-					}
-					return nil
-				}()).Should(Succeed())
+			})
 
-				fakeReconcile.Err = fmt.Errorf("expected error: reconcile")
+			It("should not cancel an in-flight reconcile if the resourceVersion is unchanged", func(done Done) {
+				started := make(chan struct{})
+				release := make(chan struct{})
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					close(started)
+					<-release
+					Expect(ctx.Err()).NotTo(HaveOccurred())
+					reconciled <- r
+					return reconcile.Result{}, nil
+				})
 				go func() {
 					defer GinkgoRecover()
 					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
 				}()
-				By("Invoking Reconciler which will give an error")
 				ctrl.Queue.Add(request)
+				<-started
+
+				By("enqueuing a resync with the same resourceVersion")
+				evthdler.Update(updateEvent("1", "1"), &controllertest.Queue{Interface: workqueue.New()})
+				close(release)
 
 				Expect(<-reconciled).To(Equal(request))
-				Eventually(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "error").Write(&reconcileTotal)).To(Succeed())
-					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
-						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
-					}
-					return nil
-				}, 2.0).Should(Succeed())
 
 				close(done)
-			}, 2.0)
+			})
+		})
 
-			It("should get updated when reconcile returns with retry enabled", func(done Done) {
-				Expect(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "retry").Write(&reconcileTotal)).To(Succeed())
-					if reconcileTotal.GetCounter().GetValue() != 0.0 {
-						return fmt.Errorf("metric reconcile total not reset")
-					}
-					return nil
-				}()).Should(Succeed())
+		Context("with ResetBackoffOnUpdate", func() {
+			var evthdler handler.EventHandler
+			var q workqueue.RateLimitingInterface
 
-				fakeReconcile.Result.Requeue = true
-				go func() {
-					defer GinkgoRecover()
-					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
-				}()
-				By("Invoking Reconciler which will return result with Requeue enabled")
-				ctrl.Queue.Add(request)
+			updateEvent := func(oldVersion, newVersion string) event.UpdateEvent {
+				return event.UpdateEvent{
+					MetaOld:   &metav1.ObjectMeta{Namespace: "foo", Name: "bar", ResourceVersion: oldVersion},
+					ObjectOld: &corev1.Pod{},
+					MetaNew:   &metav1.ObjectMeta{Namespace: "foo", Name: "bar", ResourceVersion: newVersion},
+					ObjectNew: &corev1.Pod{},
+				}
+			}
 
-				Expect(<-reconciled).To(Equal(request))
-				Eventually(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "requeue").Write(&reconcileTotal)).To(Succeed())
-					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
-						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
-					}
+			BeforeEach(func() {
+				ctrl.ResetBackoffOnUpdate = true
+				src := source.Func(func(h handler.EventHandler, _ workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+					evthdler = h
 					return nil
-				}, 2.0).Should(Succeed())
+				})
+				Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{})).To(Succeed())
 
-				close(done)
-			}, 2.0)
+				q = workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Hour, time.Hour))
+				q.AddRateLimited(request)
+				q.AddRateLimited(request)
+				Expect(q.NumRequeues(request)).To(Equal(2))
+			})
 
-			It("should get updated when reconcile returns with retryAfter enabled", func(done Done) {
-				Expect(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "retry_after").Write(&reconcileTotal)).To(Succeed())
-					if reconcileTotal.GetCounter().GetValue() != 0.0 {
-						return fmt.Errorf("metric reconcile total not reset")
-					}
+			AfterEach(func() {
+				q.ShutDown()
+			})
+
+			It("should end the backoff of a Request when a newer version is enqueued", func() {
+				evthdler.Update(updateEvent("1", "2"), q)
+				Expect(q.NumRequeues(request)).To(Equal(0))
+				Expect(q.Len()).To(Equal(1))
+			})
+
+			It("should keep the backoff of a Request if the resourceVersion is unchanged", func() {
+				evthdler.Update(updateEvent("1", "1"), q)
+				Expect(q.NumRequeues(request)).To(Equal(2))
+			})
+		})
+
+		Context("with RecordTriggerEvents", func() {
+			var evthdler handler.EventHandler
+			var triggers chan reconcile.TriggerEvent
+
+			BeforeEach(func() {
+				ctrl.RecordTriggerEvents = true
+				src := source.Func(func(h handler.EventHandler, _ workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+					evthdler = h
 					return nil
-				}()).Should(Succeed())
+				})
+				Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{})).To(Succeed())
+
+				triggers = make(chan reconcile.TriggerEvent, 1)
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					trigger, ok := reconcile.TriggerEventFromContext(ctx)
+					Expect(ok).To(BeTrue())
+					triggers <- trigger
+					return reconcile.Result{}, nil
+				})
+			})
 
-				fakeReconcile.Result.RequeueAfter = 5 * time.Hour
-				go func() {
-					defer GinkgoRecover()
-					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
-				}()
-				By("Invoking Reconciler which will return result with requeueAfter enabled")
+			It("should pass the verb, old resourceVersion and changed fields of an update", func() {
+				oldPod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar", ResourceVersion: "1"},
+					Spec:       corev1.PodSpec{NodeName: "node-1"},
+				}
+				newPod := oldPod.DeepCopy()
+				newPod.ResourceVersion = "2"
+				newPod.Status.Phase = corev1.PodRunning
+				evthdler.Update(event.UpdateEvent{MetaOld: oldPod, ObjectOld: oldPod, MetaNew: newPod, ObjectNew: newPod}, ctrl.Queue)
+
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(<-triggers).To(Equal(reconcile.TriggerEvent{
+					Verb:               reconcile.TriggerUpdate,
+					OldResourceVersion: "1",
+					ChangedFields:      []string{"status"},
+				}))
+			})
+
+			It("should pass an unknown trigger for coalesced events and requeues", func() {
+				pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"}}
+				evthdler.Create(event.CreateEvent{Meta: pod, Object: pod}, ctrl.Queue)
+				evthdler.Generic(event.GenericEvent{Meta: pod, Object: pod}, ctrl.Queue)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect((<-triggers).Verb).To(Equal(reconcile.TriggerUnknown))
+
+				By("requeueing the Request without an event")
 				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect((<-triggers).Verb).To(Equal(reconcile.TriggerUnknown))
 
-				Expect(<-reconciled).To(Equal(request))
-				Eventually(func() error {
-					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "requeue_after").Write(&reconcileTotal)).To(Succeed())
-					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
-						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
-					}
+				By("enqueuing a single event")
+				evthdler.Delete(event.DeleteEvent{Meta: pod, Object: pod}, ctrl.Queue)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(<-triggers).To(Equal(reconcile.TriggerEvent{Verb: reconcile.TriggerDelete}))
+			})
+
+			It("should pass the events of EventHandlers enqueuing with a delay or rate limit", func() {
+				src := source.Func(func(h handler.EventHandler, _ workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+					evthdler = h
 					return nil
-				}, 2.0).Should(Succeed())
+				})
+				Expect(ctrl.Watch(src, handler.Funcs{
+					CreateFunc: func(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+						q.AddAfter(request, 0)
+					},
+					DeleteFunc: func(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+						q.AddRateLimited(request)
+					},
+				})).To(Succeed())
+				pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"}}
+
+				evthdler.Create(event.CreateEvent{Meta: pod, Object: pod}, ctrl.Queue)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(<-triggers).To(Equal(reconcile.TriggerEvent{Verb: reconcile.TriggerCreate}))
+
+				evthdler.Delete(event.DeleteEvent{Meta: pod, Object: pod}, ctrl.Queue)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(<-triggers).To(Equal(reconcile.TriggerEvent{Verb: reconcile.TriggerDelete}))
+			})
 
-				close(done)
-			}, 2.0)
+			It("should not keep the events of Requests which are dropped", func() {
+				ctrl.KeyParser = func(reconcile.Request) (interface{}, error) {
+					return nil, fmt.Errorf("invalid key")
+				}
+				pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"}}
+				evthdler.Create(event.CreateEvent{Meta: pod, Object: pod}, ctrl.Queue)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(ctrl.triggers).To(BeEmpty())
+			})
 		})
 
-		Context("should update prometheus metrics", func() {
-			It("should requeue a Request if there is an error and continue processing items", func(done Done) {
-				var reconcileErrs dto.Metric
-				ctrlmetrics.ReconcileErrors.Reset()
-				Expect(func() error {
-					Expect(ctrlmetrics.ReconcileErrors.WithLabelValues(ctrl.Name).Write(&reconcileErrs)).To(Succeed())
-					if reconcileErrs.GetCounter().GetValue() != 0.0 {
-						return fmt.Errorf("metric reconcile errors not reset")
-					}
+		Context("with RecordQueueWait", func() {
+			var watchQueue workqueue.RateLimitingInterface
+			var enqueueTimes chan time.Time
+
+			BeforeEach(func() {
+				ctrl.RecordQueueWait = true
+				ctrlmetrics.ReconcileQueueWait.Reset()
+				src := source.Func(func(_ handler.EventHandler, q workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+					watchQueue = q
 					return nil
-				}()).Should(Succeed())
+				})
+				Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{})).To(Succeed())
+
+				enqueueTimes = make(chan time.Time, 1)
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					enqueued, _ := reconcile.EnqueueTimeFromContext(ctx)
+					enqueueTimes <- enqueued
+					return reconcile.Result{}, nil
+				})
+			})
 
-				fakeReconcile.Err = fmt.Errorf("expected error: reconcile")
-				go func() {
-					defer GinkgoRecover()
-					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
-				}()
-				ctrl.Queue.Add(request)
+			queueWaits := func() uint64 {
+				var m dto.Metric
+				hist := ctrlmetrics.ReconcileQueueWait.WithLabelValues(ctrl.Name).(prometheus.Histogram)
+				Expect(hist.Write(&m)).To(Succeed())
+				return m.GetHistogram().GetSampleCount()
+			}
 
-				// Reduce the jitterperiod so we don't have to wait a second before the reconcile function is rerun.
-				ctrl.JitterPeriod = time.Millisecond
+			It("should pass the time of the first event enqueueing the Request and observe the wait", func() {
+				before := time.Now()
+				watchQueue.Add(request)
+				watchQueue.Add(request)
+				time.Sleep(10 * time.Millisecond)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
 
-				By("Invoking Reconciler which will give an error")
-				Expect(<-reconciled).To(Equal(request))
-				Eventually(func() error {
-					Expect(ctrlmetrics.ReconcileErrors.WithLabelValues(ctrl.Name).Write(&reconcileErrs)).To(Succeed())
-					if reconcileErrs.GetCounter().GetValue() != 1.0 {
-						return fmt.Errorf("metrics not updated")
-					}
-					return nil
-				}, 2.0).Should(Succeed())
+				enqueued := <-enqueueTimes
+				Expect(enqueued).To(BeTemporally("~", before, 5*time.Millisecond))
+				Expect(queueWaits()).To(Equal(uint64(1)))
+			})
 
-				By("Invoking Reconciler a second time without error")
-				fakeReconcile.Err = nil
-				Expect(<-reconciled).To(Equal(request))
+			It("should not measure the wait of requeued Requests", func() {
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
 
-				By("Removing the item from the queue")
-				Eventually(ctrl.Queue.Len).Should(Equal(0))
-				Eventually(func() int { return ctrl.Queue.NumRequeues(request) }).Should(Equal(0))
+				Expect((<-enqueueTimes).IsZero()).To(BeTrue())
+				Expect(queueWaits()).To(Equal(uint64(0)))
+			})
+		})
 
-				close(done)
-			}, 2.0)
+		Context("with ReadYourWrites", func() {
+			It("should pass a Client reading the writes of the same reconcile", func() {
+				ctrl.ReadYourWrites = true
+				ctrl.Scheme = scheme.Scheme
+				ctrl.Client = fake.NewFakeClient()
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					c := reconcile.ClientFromContext(ctx, nil)
+					Expect(c).NotTo(BeNil())
+					Expect(c).NotTo(BeIdenticalTo(ctrl.Client))
+					return reconcile.Result{}, nil
+				})
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(ctrl.Queue.Len()).To(Equal(0))
+			})
+		})
 
-			It("should add a reconcile time to the reconcile time histogram", func(done Done) {
-				var reconcileTime dto.Metric
-				ctrlmetrics.ReconcileTime.Reset()
+		Context("with CancelOnShutdown", func() {
+			var stopCtrl chan struct{}
+			var stopped chan error
 
-				Expect(func() error {
-					histObserver := ctrlmetrics.ReconcileTime.WithLabelValues(ctrl.Name)
-					hist := histObserver.(prometheus.Histogram)
-					Expect(hist.Write(&reconcileTime)).To(Succeed())
-					if reconcileTime.GetHistogram().GetSampleCount() != uint64(0) {
-						return fmt.Errorf("metrics not reset")
-					}
-					return nil
-				}()).Should(Succeed())
+			BeforeEach(func() {
+				ctrl.CancelOnShutdown = true
+				ctrl.WaitForCacheSync = func(<-chan struct{}) bool { return true }
+				stopCtrl = make(chan struct{})
+				stopped = make(chan error)
+			})
 
-				go func() {
-					defer GinkgoRecover()
-					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
-				}()
+			It("should cancel the reconciles in flight and run their save-points before stopping", func(done Done) {
+				var registered bool
+				saved := make(chan error, 1)
+				reconciling := make(chan struct{})
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					registered = reconcile.RegisterSavePoint(ctx, func(ctx context.Context) error {
+						saved <- ctx.Err()
+						return nil
+					})
+					close(reconciling)
+					<-ctx.Done()
+					return reconcile.Result{}, ctx.Err()
+				})
+				go func() { stopped <- ctrl.Start(stopCtrl) }()
 				ctrl.Queue.Add(request)
+				<-reconciling
 
-				By("Invoking Reconciler")
-				Expect(<-reconciled).To(Equal(request))
-
-				By("Removing the item from the queue")
-				Eventually(ctrl.Queue.Len).Should(Equal(0))
-				Eventually(func() int { return ctrl.Queue.NumRequeues(request) }).Should(Equal(0))
+				close(stopCtrl)
+				Expect(<-stopped).NotTo(HaveOccurred())
+				Expect(registered).To(BeTrue())
+				Expect(saved).To(Receive(BeNil()))
+				close(done)
+			})
 
-				Eventually(func() error {
-					histObserver := ctrlmetrics.ReconcileTime.WithLabelValues(ctrl.Name)
-					hist := histObserver.(prometheus.Histogram)
-					Expect(hist.Write(&reconcileTime)).To(Succeed())
-					if reconcileTime.GetHistogram().GetSampleCount() == uint64(0) {
-						return fmt.Errorf("metrics not updated")
-					}
-					return nil
-				}, 2.0).Should(Succeed())
+			It("should not run the save-points of reconciles which returned before the shutdown", func(done Done) {
+				saved := make(chan error, 1)
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					reconcile.RegisterSavePoint(ctx, func(ctx context.Context) error {
+						saved <- ctx.Err()
+						return nil
+					})
+					reconciled <- r
+					return reconcile.Result{}, nil
+				})
+				go func() { stopped <- ctrl.Start(stopCtrl) }()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(func() int { return ctrl.Queue.NumRequeues(request) + ctrl.Queue.Len() }).Should(Equal(0))
 
+				close(stopCtrl)
+				Expect(<-stopped).NotTo(HaveOccurred())
+				Consistently(saved).ShouldNot(Receive())
 				close(done)
-			}, 4.0)
+			})
+
+			It("should not register save-points without CancelOnShutdown", func() {
+				ctrl.CancelOnShutdown = false
+				var registered bool
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					registered = reconcile.RegisterSavePoint(ctx, func(context.Context) error { return nil })
+					return reconcile.Result{}, nil
+				})
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(registered).To(BeFalse())
+			})
 		})
-	})
-})
 
-type DelegatingQueue struct {
-	workqueue.RateLimitingInterface
+		Context("with a StatusWriter", func() {
+			It("should pass the StatusWriter to the reconciles", func() {
+				c := fake.NewFakeClient()
+				writer := client.NewBatchingStatusWriter(c, c, scheme.Scheme, time.Second)
+				ctrl.StatusWriter = writer
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					Expect(reconcile.StatusWriterFromContext(ctx, nil)).To(BeIdenticalTo(writer))
+					return reconcile.Result{}, nil
+				})
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(ctrl.Queue.Len()).To(Equal(0))
+			})
+		})
 
-	countAddRateLimited int
-	countAdd            int
-	countAddAfter       int
-}
+		Context("with Audit", func() {
+			It("should pass the audit record of every reconcile, with its correlation ID", func() {
+				var records []audit.Record
+				ctrl.Audit = func(r audit.Record) { records = append(records, r) }
+				var correlationID string
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					correlationID = reconcile.CorrelationIDFromContext(ctx)
+					return reconcile.Result{}, fmt.Errorf("expected error: reconcile")
+				})
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeFalse())
+
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].Controller).To(Equal(ctrl.Name))
+				Expect(records[0].Namespace).To(Equal("foo"))
+				Expect(records[0].Name).To(Equal("bar"))
+				Expect(records[0].Result).To(Equal("error"))
+				Expect(records[0].Error).To(Equal("expected error: reconcile"))
+				Expect(correlationID).NotTo(BeEmpty())
+				Expect(records[0].CorrelationID).To(Equal(correlationID))
+			})
+		})
 
-func (q *DelegatingQueue) AddRateLimited(item interface{}) {
-	q.countAddRateLimited++
-	q.RateLimitingInterface.AddRateLimited(item)
-}
+		Context("with a KeyParser", func() {
+			var dq *DelegatingQueue
+			type tenantKey struct{ Tenant, Resource string }
+
+			BeforeEach(func() {
+				dq = &DelegatingQueue{RateLimitingInterface: ctrl.Queue}
+				ctrl.Queue = dq
+				ctrl.KeyParser = func(r reconcile.Request) (interface{}, error) {
+					parts := strings.SplitN(r.Name, "--", 2)
+					if len(parts) != 2 {
+						return nil, fmt.Errorf("name %q isn't of the form tenant--resource", r.Name)
+					}
+					return tenantKey{Tenant: parts[0], Resource: parts[1]}, nil
+				}
+			})
+
+			It("should pass the parsed key to the reconcile", func() {
+				var key interface{}
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					key = reconcile.KeyFromContext(ctx)
+					return reconcile.Result{}, nil
+				})
+				ctrl.Queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "acme--db"}})
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(key).To(Equal(tenantKey{Tenant: "acme", Resource: "db"}))
+			})
+
+			It("should drop Requests whose key doesn't parse without reconciling them", func() {
+				ctrl.Do = reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+					defer GinkgoRecover()
+					Fail("Reconcile should not have been called")
+					return reconcile.Result{}, nil
+				})
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(ctrl.Queue.Len()).To(Equal(0))
+				Expect(dq.countAddRateLimited).To(Equal(0))
+				Expect(ctrl.Queue.NumRequeues(request)).To(Equal(0))
+			})
+
+			It("should default the key to the NamespacedName without a KeyParser", func() {
+				ctrl.KeyParser = nil
+				var key interface{}
+				ctrl.Do = reconcile.ContextFunc(func(ctx context.Context, r reconcile.Request) (reconcile.Result, error) {
+					key = reconcile.KeyFromContext(ctx)
+					return reconcile.Result{}, nil
+				})
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(key).To(Equal(request.NamespacedName))
+			})
+		})
+
+		Context("with DefaultRequeueAfter", func() {
+			var dq *DelegatingQueue
+
+			BeforeEach(func() {
+				ctrl.DefaultRequeueAfter = time.Hour
+				ctrl.For = &corev1.Pod{}
+				ctrl.Cache = &clientBackedCache{
+					FakeInformers: informers,
+					Reader:        fake.NewFakeClient(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"}}),
+				}
+				dq = &DelegatingQueue{RateLimitingInterface: ctrl.Queue}
+				ctrl.Queue = dq
+				ctrl.Do = reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{}, nil
+				})
+			})
+
+			It("should requeue a Request after DefaultRequeueAfter if the Result is empty", func() {
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(dq.countAddAfter).To(Equal(1))
+			})
+
+			It("should let the Result take precedence over DefaultRequeueAfter", func() {
+				ctrl.Do = reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{Requeue: true}, nil
+				})
+				ctrl.Queue.Add(request)
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(dq.countAddAfter).To(Equal(0))
+				Expect(dq.countAddRateLimited).To(Equal(1))
+			})
+
+			It("should not requeue a Request whose object is gone", func() {
+				ctrl.Queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "gone"}})
+				Expect(ctrl.processNextWorkItem()).To(BeTrue())
+				Expect(dq.countAddAfter).To(Equal(0))
+			})
+		})
+
+		Context("with ObservedGenerationFor", func() {
+			var deploy *appsv1.Deployment
+
+			BeforeEach(func() {
+				deploy = &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar", Generation: 2},
+				}
+				ctrl.Client = fake.NewFakeClient(deploy)
+				ctrl.ObservedGenerationFor = &appsv1.Deployment{}
+			})
+
+			It("should set the observedGeneration after a successful reconcile", func(done Done) {
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				Eventually(func() (int64, error) {
+					fetched := &appsv1.Deployment{}
+					err := ctrl.Client.Get(context.TODO(), request.NamespacedName, fetched)
+					return fetched.Status.ObservedGeneration, err
+				}).Should(Equal(int64(2)))
+
+				close(done)
+			})
+
+			It("should set the observedGeneration after a reconcile which updated the status itself", func(done Done) {
+				ctrl.Do = reconcile.Func(func(r reconcile.Request) (reconcile.Result, error) {
+					defer func() { reconciled <- r }()
+					fetched := &appsv1.Deployment{}
+					if err := ctrl.Client.Get(context.TODO(), r.NamespacedName, fetched); err != nil {
+						return reconcile.Result{}, err
+					}
+					fetched.Status.Replicas = 1
+					return reconcile.Result{}, ctrl.Client.Status().Update(context.TODO(), fetched)
+				})
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				fetched := &appsv1.Deployment{}
+				Eventually(func() (int64, error) {
+					err := ctrl.Client.Get(context.TODO(), request.NamespacedName, fetched)
+					return fetched.Status.ObservedGeneration, err
+				}).Should(Equal(int64(2)))
+				Expect(fetched.Status.Replicas).To(Equal(int32(1)))
+
+				close(done)
+			})
+
+			It("should only report the generation which was reconciled as observed", func(done Done) {
+				ctrl.Do = reconcile.Func(func(r reconcile.Request) (reconcile.Result, error) {
+					defer func() { reconciled <- r }()
+					fetched := &appsv1.Deployment{}
+					if err := ctrl.Client.Get(context.TODO(), r.NamespacedName, fetched); err != nil {
+						return reconcile.Result{}, err
+					}
+					fetched.Generation = 3
+					return reconcile.Result{}, ctrl.Client.Update(context.TODO(), fetched)
+				})
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				Consistently(func() (int64, error) {
+					fetched := &appsv1.Deployment{}
+					err := ctrl.Client.Get(context.TODO(), request.NamespacedName, fetched)
+					return fetched.Status.ObservedGeneration, err
+				}, 100*time.Millisecond).ShouldNot(Equal(int64(3)))
+
+				close(done)
+			})
+
+			It("should not set the observedGeneration if the reconcile fails", func(done Done) {
+				fakeReconcile.Err = fmt.Errorf("expected error: reconcile")
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				Consistently(func() (int64, error) {
+					fetched := &appsv1.Deployment{}
+					err := ctrl.Client.Get(context.TODO(), request.NamespacedName, fetched)
+					return fetched.Status.ObservedGeneration, err
+				}, 100*time.Millisecond).Should(BeZero())
+
+				close(done)
+			})
+
+			It("should ignore objects which no longer exist", func(done Done) {
+				ctrl.Client = fake.NewFakeClient()
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				Eventually(ctrl.Queue.Len).Should(Equal(0))
+				Expect(ctrl.Queue.NumRequeues(request)).To(Equal(0))
+
+				close(done)
+			})
+		})
+
+		Context("with BatchKey", func() {
+			var evtQueue workqueue.RateLimitingInterface
+			var batches chan reconcile.BatchRequest
+			var br *fakeBatchReconciler
+
+			req := func(namespace, name string) reconcile.Request {
+				return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+			}
+
+			BeforeEach(func() {
+				batches = make(chan reconcile.BatchRequest)
+				br = &fakeBatchReconciler{batches: batches}
+				ctrl.Do = br
+				ctrl.BatchKey = func(r reconcile.Request) string { return r.Namespace }
+				src := source.Func(func(_ handler.EventHandler, q workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+					evtQueue = q
+					return nil
+				})
+				Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{})).To(Succeed())
+			})
+
+			It("should reconcile the Requests sharing a key in a single deduplicated and ordered batch", func(done Done) {
+				evtQueue.Add(req("foo", "b"))
+				evtQueue.Add(req("foo", "a"))
+				evtQueue.Add(req("foo", "b"))
+				evtQueue.Add(req("baz", "c"))
+				Expect(ctrl.Queue.Len()).To(Equal(2))
+
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+
+				received := map[string]reconcile.BatchRequest{}
+				for i := 0; i < 2; i++ {
+					batch := <-batches
+					received[batch.Key] = batch
+				}
+				Expect(received).To(Equal(map[string]reconcile.BatchRequest{
+					"foo": {Key: "foo", Requests: []reconcile.Request{req("foo", "a"), req("foo", "b")}},
+					"baz": {Key: "baz", Requests: []reconcile.Request{req("baz", "c")}},
+				}))
+				Eventually(ctrl.Queue.Len).Should(Equal(0))
+
+				close(done)
+			})
+
+			It("should redeliver the whole batch if the batch fails", func(done Done) {
+				ctrl.JitterPeriod = time.Millisecond
+				br.errs = []error{fmt.Errorf("expected error: reconcile")}
+				evtQueue.Add(req("foo", "a"))
+				evtQueue.Add(req("foo", "b"))
+
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+
+				expected := reconcile.BatchRequest{Key: "foo", Requests: []reconcile.Request{req("foo", "a"), req("foo", "b")}}
+				Expect(<-batches).To(Equal(expected))
+				Expect(<-batches).To(Equal(expected))
+				Eventually(ctrl.Queue.Len).Should(Equal(0))
+
+				close(done)
+			})
+
+			It("should not requeue a batch which asked for RequeueAfter once stopped", func(done Done) {
+				br.result = reconcile.Result{RequeueAfter: 50 * time.Millisecond}
+				evtQueue.Add(req("foo", "a"))
+
+				ctrlStop, stopped := make(chan struct{}), make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(stopped)
+					Expect(ctrl.Start(ctrlStop)).NotTo(HaveOccurred())
+				}()
+				Expect(<-batches).To(Equal(reconcile.BatchRequest{Key: "foo", Requests: []reconcile.Request{req("foo", "a")}}))
+
+				close(ctrlStop)
+				<-stopped
+				Consistently(func() int {
+					ctrl.batchesMu.Lock()
+					defer ctrl.batchesMu.Unlock()
+					return len(ctrl.batches)
+				}, 200*time.Millisecond).Should(Equal(0))
+
+				close(done)
+			})
+
+			It("should reconcile the batches by the highest priority of their Requests", func(done Done) {
+				ctrl.Queue = NewPriorityQueue(workqueue.DefaultControllerRateLimiter())
+				pq := ctrl.eventQueue().(handler.PriorityQueue)
+				pq.Add(req("baz", "c"))
+				pq.Add(req("foo", "a"))
+				pq.AddWithPriority(req("foo", "b"), 10)
+
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+
+				Expect(<-batches).To(Equal(reconcile.BatchRequest{Key: "foo", Requests: []reconcile.Request{req("foo", "a"), req("foo", "b")}}))
+				Expect(<-batches).To(Equal(reconcile.BatchRequest{Key: "baz", Requests: []reconcile.Request{req("baz", "c")}}))
+
+				close(done)
+			})
+
+			It("should add Requests enqueued with a delay to the batch once the delay has passed", func(done Done) {
+				evtQueue.AddAfter(req("foo", "a"), 10*time.Millisecond)
+				Expect(ctrl.Queue.Len()).To(Equal(0))
+
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+
+				Expect(<-batches).To(Equal(reconcile.BatchRequest{Key: "foo", Requests: []reconcile.Request{req("foo", "a")}}))
+
+				close(done)
+			})
+		})
+
+		Context("with a delay deduplicating queue", func() {
+			It("should not reconcile again after RequeueAfter if a fresh event was received meanwhile", func(done Done) {
+				ctrl.Queue = NewDelayDedupingQueue(workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()))
+				var count int32
+				ctrl.Do = reconcile.Func(func(r reconcile.Request) (reconcile.Result, error) {
+					reconciled <- r
+					if atomic.AddInt32(&count, 1) == 1 {
+						return reconcile.Result{RequeueAfter: 200 * time.Millisecond}, nil
+					}
+					return reconcile.Result{}, nil
+				})
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+
+				By("Invoking Reconciler which will ask for requeue after a duration")
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(ctrl.Queue.(*delayDedupingQueue).pendingLen).Should(Equal(1))
+
+				By("Invoking Reconciler for a fresh event before the duration elapsed")
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				By("Not invoking Reconciler again once the duration elapsed")
+				Consistently(reconciled, 400*time.Millisecond).ShouldNot(Receive())
+
+				close(done)
+			})
+		})
+
+		Context("with MinReconcileInterval", func() {
+			BeforeEach(func() {
+				ctrl.Queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			})
+
+			It("should delay reconciling a Request again until the interval has passed, coalescing its events", func(done Done) {
+				ctrl.MinReconcileInterval = 300 * time.Millisecond
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				By("Holding back the events received within the interval")
+				ctrl.Queue.Add(request)
+				Consistently(reconciled, 100*time.Millisecond).ShouldNot(Receive())
+				ctrl.Queue.Add(request)
+
+				By("Reconciling the Request once when the interval has passed")
+				Eventually(reconciled).Should(Receive(Equal(request)))
+				Consistently(reconciled, 400*time.Millisecond).ShouldNot(Receive())
+
+				close(done)
+			})
+
+			It("should not delay other Requests", func(done Done) {
+				ctrl.MinReconcileInterval = time.Hour
+				other := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "baz"}}
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+				ctrl.Queue.Add(other)
+				Expect(<-reconciled).To(Equal(other))
+
+				close(done)
+			})
+		})
+
+		Context("with a ProgressiveRequeue", func() {
+			BeforeEach(func() {
+				ctrl.Queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			})
+
+			attempts := func() int {
+				ctrl.progressions.mu.Lock()
+				defer ctrl.progressions.mu.Unlock()
+				return ctrl.progressions.states[request].attempts
+			}
+
+			It("should requeue with a growing delay up to MaxProgressiveRequeueAfter", func(done Done) {
+				ctrl.MaxProgressiveRequeueAfter = 150 * time.Millisecond
+				times := make(chan time.Time, 4)
+				var count int32
+				ctrl.Do = reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+					times <- time.Now()
+					if atomic.AddInt32(&count, 1) == 4 {
+						return reconcile.Result{}, nil
+					}
+					return reconcile.ProgressiveRequeue("poll", 50*time.Millisecond, 2, time.Hour), nil
+				})
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+
+				var last time.Time
+				for i, want := range []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond, 150 * time.Millisecond} {
+					var t time.Time
+					Eventually(times).Should(Receive(&t))
+					if i > 0 {
+						Expect(t.Sub(last)).To(BeNumerically(">=", want))
+						Expect(t.Sub(last)).To(BeNumerically("<", want+100*time.Millisecond))
+					}
+					last = t
+				}
+
+				By("Forgetting the progression once the reconcile is done")
+				Eventually(attempts).Should(Equal(0))
+				Consistently(times, 300*time.Millisecond).ShouldNot(Receive())
+
+				close(done)
+			})
+
+			It("should not start over when the Request is enqueued for another reason", func(done Done) {
+				ctrl.Do = reconcile.Func(func(r reconcile.Request) (reconcile.Result, error) {
+					reconciled <- r
+					return reconcile.ProgressiveRequeue("poll", time.Hour, 2, 0), nil
+				})
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(attempts).Should(Equal(1))
+
+				// E.g. an event of an owned object
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(attempts).Should(Equal(2))
+
+				close(done)
+			})
+
+			It("should start over when the object of the For type is updated", func() {
+				ctrl.For = &corev1.Pod{}
+				prog := reconcile.Progression{Key: "poll", Base: time.Second, Factor: 2}
+				Expect(ctrl.progressions.next(request, prog, 0)).To(Equal(time.Second))
+				Expect(ctrl.progressions.next(request, prog, 0)).To(Equal(2 * time.Second))
+
+				src := &source.Kind{Type: &corev1.Pod{}}
+				Expect(src.InjectCache(ctrl.Cache)).To(Succeed())
+				Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{})).To(Succeed())
+				pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar", ResourceVersion: "1"}}
+				newPod := pod.DeepCopy()
+				newPod.ResourceVersion = "2"
+				fakeInformer, err := informers.FakeInformerFor(&corev1.Pod{})
+				Expect(err).NotTo(HaveOccurred())
+				fakeInformer.Update(pod, newPod)
+
+				Expect(attempts()).To(Equal(0))
+				Expect(ctrl.progressions.next(request, prog, 0)).To(Equal(time.Second))
+			})
+
+			It("should not start over when an object of another type is updated", func() {
+				ctrl.For = &appsv1.Deployment{}
+				prog := reconcile.Progression{Key: "poll", Base: time.Second, Factor: 2}
+				Expect(ctrl.progressions.next(request, prog, 0)).To(Equal(time.Second))
+
+				src := &source.Kind{Type: &corev1.Pod{}}
+				Expect(src.InjectCache(ctrl.Cache)).To(Succeed())
+				Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{})).To(Succeed())
+				pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar", ResourceVersion: "1"}}
+				newPod := pod.DeepCopy()
+				newPod.ResourceVersion = "2"
+				fakeInformer, err := informers.FakeInformerFor(&corev1.Pod{})
+				Expect(err).NotTo(HaveOccurred())
+				fakeInformer.Update(pod, newPod)
+
+				Expect(attempts()).To(Equal(1))
+			})
+
+			It("should start over when the key of the Progression changes", func() {
+				prog := reconcile.Progression{Key: "a", Base: time.Second, Factor: 2}
+				Expect(ctrl.progressions.next(request, prog, 0)).To(Equal(time.Second))
+				Expect(ctrl.progressions.next(request, prog, 0)).To(Equal(2 * time.Second))
+				prog.Key = "b"
+				Expect(ctrl.progressions.next(request, prog, 0)).To(Equal(time.Second))
+			})
+		})
+
+		Context("with HealthCheck", func() {
+			var healthy int32
+
+			paused := func() float64 {
+				var m dto.Metric
+				Expect(ctrlmetrics.ReconcilePaused.WithLabelValues(ctrl.Name).Write(&m)).To(Succeed())
+				return m.GetGauge().GetValue()
+			}
+
+			BeforeEach(func() {
+				ctrlmetrics.ReconcilePaused.Reset()
+				atomic.StoreInt32(&healthy, 0)
+				ctrl.HealthCheck = func() error {
+					if atomic.LoadInt32(&healthy) == 0 {
+						return fmt.Errorf("expected error: dependency unavailable")
+					}
+					return nil
+				}
+				ctrl.HealthCheckPeriod = 10 * time.Millisecond
+			})
+
+			It("should not reconcile while the health check fails, and resume once it succeeds", func(done Done) {
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+
+				By("keeping the Request queued while the health check fails")
+				Consistently(reconciled, 100*time.Millisecond).ShouldNot(Receive())
+				Expect(ctrl.Queue.Len()).To(Equal(1))
+				Expect(paused()).To(Equal(1.0))
+
+				By("reconciling the Request once the health check succeeds")
+				atomic.StoreInt32(&healthy, 1)
+				Expect(<-reconciled).To(Equal(request))
+				Expect(paused()).To(Equal(0.0))
+
+				close(done)
+			})
+
+			It("should pause again when the health check starts failing", func(done Done) {
+				atomic.StoreInt32(&healthy, 1)
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				By("failing the health check")
+				atomic.StoreInt32(&healthy, 0)
+				Eventually(paused).Should(Equal(1.0))
+
+				By("not reconciling Requests until it succeeds again")
+				ctrl.Queue.Add(request)
+				Consistently(reconciled, 100*time.Millisecond).ShouldNot(Receive())
+				atomic.StoreInt32(&healthy, 1)
+				Expect(<-reconciled).To(Equal(request))
+
+				close(done)
+			})
+		})
+
+		Context("prometheus metric reconcile_total", func() {
+			var reconcileTotal dto.Metric
+
+			BeforeEach(func() {
+				ctrlmetrics.ReconcileTotal.Reset()
+				reconcileTotal.Reset()
+			})
+
+			It("should get updated on successful reconciliation", func(done Done) {
+				Expect(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "success").Write(&reconcileTotal)).To(Succeed())
+					if reconcileTotal.GetCounter().GetValue() != 0.0 {
+						return fmt.Errorf("metric reconcile total not reset")
+					}
+					return nil
+				}()).Should(Succeed())
+
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				By("Invoking Reconciler which will succeed")
+				ctrl.Queue.Add(request)
+
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "success").Write(&reconcileTotal)).To(Succeed())
+					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
+						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
+					}
+					return nil
+				}, 2.0).Should(Succeed())
+
+				close(done)
+			}, 2.0)
+
+			It("should get updated on reconcile errors", func(done Done) {
+				Expect(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "error").Write(&reconcileTotal)).To(Succeed())
+					if reconcileTotal.GetCounter().GetValue() != 0.0 {
+						return fmt.Errorf("metric reconcile total not reset")
+					}
+					return nil
+				}()).Should(Succeed())
+
+				fakeReconcile.Err = fmt.Errorf("expected error: reconcile")
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				By("Invoking Reconciler which will give an error")
+				ctrl.Queue.Add(request)
+
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "error").Write(&reconcileTotal)).To(Succeed())
+					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
+						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
+					}
+					return nil
+				}, 2.0).Should(Succeed())
+
+				close(done)
+			}, 2.0)
+
+			It("should get updated when reconcile returns with retry enabled", func(done Done) {
+				Expect(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "retry").Write(&reconcileTotal)).To(Succeed())
+					if reconcileTotal.GetCounter().GetValue() != 0.0 {
+						return fmt.Errorf("metric reconcile total not reset")
+					}
+					return nil
+				}()).Should(Succeed())
+
+				fakeReconcile.Result.Requeue = true
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				By("Invoking Reconciler which will return result with Requeue enabled")
+				ctrl.Queue.Add(request)
+
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "requeue").Write(&reconcileTotal)).To(Succeed())
+					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
+						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
+					}
+					return nil
+				}, 2.0).Should(Succeed())
+
+				close(done)
+			}, 2.0)
+
+			It("should get updated when reconcile returns with retryAfter enabled", func(done Done) {
+				Expect(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "retry_after").Write(&reconcileTotal)).To(Succeed())
+					if reconcileTotal.GetCounter().GetValue() != 0.0 {
+						return fmt.Errorf("metric reconcile total not reset")
+					}
+					return nil
+				}()).Should(Succeed())
+
+				fakeReconcile.Result.RequeueAfter = 5 * time.Hour
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				By("Invoking Reconciler which will return result with requeueAfter enabled")
+				ctrl.Queue.Add(request)
+
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(func() error {
+					Expect(ctrlmetrics.ReconcileTotal.WithLabelValues(ctrl.Name, "requeue_after").Write(&reconcileTotal)).To(Succeed())
+					if actual := reconcileTotal.GetCounter().GetValue(); actual != 1.0 {
+						return fmt.Errorf("metric reconcile total expected: %v and got: %v", 1.0, actual)
+					}
+					return nil
+				}, 2.0).Should(Succeed())
+
+				close(done)
+			}, 2.0)
+		})
+
+		Context("should update prometheus metrics", func() {
+			It("should requeue a Request if there is an error and continue processing items", func(done Done) {
+				var reconcileErrs dto.Metric
+				ctrlmetrics.ReconcileErrors.Reset()
+				Expect(func() error {
+					Expect(ctrlmetrics.ReconcileErrors.WithLabelValues(ctrl.Name).Write(&reconcileErrs)).To(Succeed())
+					if reconcileErrs.GetCounter().GetValue() != 0.0 {
+						return fmt.Errorf("metric reconcile errors not reset")
+					}
+					return nil
+				}()).Should(Succeed())
+
+				fakeReconcile.Err = fmt.Errorf("expected error: reconcile")
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+
+				// Reduce the jitterperiod so we don't have to wait a second before the reconcile function is rerun.
+				ctrl.JitterPeriod = time.Millisecond
+
+				By("Invoking Reconciler which will give an error")
+				Expect(<-reconciled).To(Equal(request))
+				Eventually(func() error {
+					Expect(ctrlmetrics.ReconcileErrors.WithLabelValues(ctrl.Name).Write(&reconcileErrs)).To(Succeed())
+					if reconcileErrs.GetCounter().GetValue() != 1.0 {
+						return fmt.Errorf("metrics not updated")
+					}
+					return nil
+				}, 2.0).Should(Succeed())
+
+				By("Invoking Reconciler a second time without error")
+				fakeReconcile.Err = nil
+				Expect(<-reconciled).To(Equal(request))
+
+				By("Removing the item from the queue")
+				Eventually(ctrl.Queue.Len).Should(Equal(0))
+				Eventually(func() int { return ctrl.Queue.NumRequeues(request) }).Should(Equal(0))
+
+				close(done)
+			}, 2.0)
+
+			It("should add a reconcile time to the reconcile time histogram", func(done Done) {
+				var reconcileTime dto.Metric
+				ctrlmetrics.ReconcileTime.Reset()
+
+				Expect(func() error {
+					histObserver := ctrlmetrics.ReconcileTime.WithLabelValues(ctrl.Name)
+					hist := histObserver.(prometheus.Histogram)
+					Expect(hist.Write(&reconcileTime)).To(Succeed())
+					if reconcileTime.GetHistogram().GetSampleCount() != uint64(0) {
+						return fmt.Errorf("metrics not reset")
+					}
+					return nil
+				}()).Should(Succeed())
+
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+
+				By("Invoking Reconciler")
+				Expect(<-reconciled).To(Equal(request))
+
+				By("Removing the item from the queue")
+				Eventually(ctrl.Queue.Len).Should(Equal(0))
+				Eventually(func() int { return ctrl.Queue.NumRequeues(request) }).Should(Equal(0))
+
+				Eventually(func() error {
+					histObserver := ctrlmetrics.ReconcileTime.WithLabelValues(ctrl.Name)
+					hist := histObserver.(prometheus.Histogram)
+					Expect(hist.Write(&reconcileTime)).To(Succeed())
+					if reconcileTime.GetHistogram().GetSampleCount() == uint64(0) {
+						return fmt.Errorf("metrics not updated")
+					}
+					return nil
+				}, 2.0).Should(Succeed())
+
+				close(done)
+			}, 4.0)
+		})
+
+		Context("with a MetricsClass", func() {
+			BeforeEach(func() {
+				ctrlmetrics.ReconcileClassTotal.Reset()
+				ctrlmetrics.ReconcileClassTime.Reset()
+				ctrl.MetricsClass = NewMetricsClass(func(req reconcile.Request) string {
+					return req.Namespace
+				}, []string{"foo"})
+			})
+
+			classTotal := func(class string) func() float64 {
+				return func() float64 {
+					var m dto.Metric
+					Expect(ctrlmetrics.ReconcileClassTotal.WithLabelValues(ctrl.Name, class, "success").Write(&m)).To(Succeed())
+					return m.GetCounter().GetValue()
+				}
+			}
+
+			It("should count the reconciles per class", func(done Done) {
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				other := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "baz", Name: "bar"}}
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+				ctrl.Queue.Add(other)
+				Expect(<-reconciled).To(Equal(other))
+
+				Eventually(classTotal("foo")).Should(Equal(1.0))
+				Eventually(classTotal(OtherMetricsClass)).Should(Equal(1.0))
+				Expect(classTotal("baz")()).To(Equal(0.0))
+
+				Eventually(func() uint64 {
+					var m dto.Metric
+					hist := ctrlmetrics.ReconcileClassTime.WithLabelValues(ctrl.Name, "foo").(prometheus.Histogram)
+					Expect(hist.Write(&m)).To(Succeed())
+					return m.GetHistogram().GetSampleCount()
+				}).Should(Equal(uint64(1)))
+
+				close(done)
+			})
+		})
+
+		Context("with RequeueReasons", func() {
+			BeforeEach(func() {
+				ctrlmetrics.ReconcileRequeueTotal.Reset()
+				ctrl.Queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+				ctrl.RequeueReasons = []string{"waiting-for-dependency"}
+			})
+
+			requeueTotal := func(reason string) func() float64 {
+				return func() float64 {
+					var m dto.Metric
+					Expect(ctrlmetrics.ReconcileRequeueTotal.WithLabelValues(ctrl.Name, reason).Write(&m)).To(Succeed())
+					return m.GetCounter().GetValue()
+				}
+			}
+
+			It("should count the requeues per reason", func(done Done) {
+				ctrl.Do = reconcile.Func(func(r reconcile.Request) (reconcile.Result, error) {
+					reconciled <- r
+					if r == request {
+						return reconcile.RequeueAfterWithReason(time.Hour, "waiting-for-dependency"), nil
+					}
+					return reconcile.RequeueAfterWithReason(time.Hour, r.Name), nil
+				})
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				other := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "baz"}}
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+				ctrl.Queue.Add(other)
+				Expect(<-reconciled).To(Equal(other))
+
+				Eventually(requeueTotal("waiting-for-dependency")).Should(Equal(1.0))
+				Eventually(requeueTotal(OtherMetricsClass)).Should(Equal(1.0))
+				Expect(requeueTotal("baz")()).To(Equal(0.0))
+
+				close(done)
+			})
+
+			It("should not count requeues without a reason", func(done Done) {
+				ctrl.Do = reconcile.Func(func(r reconcile.Request) (reconcile.Result, error) {
+					reconciled <- r
+					return reconcile.Result{RequeueAfter: time.Hour}, nil
+				})
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				Consistently(requeueTotal(OtherMetricsClass), 100*time.Millisecond).Should(Equal(0.0))
+
+				close(done)
+			})
+		})
+
+		Context("with a metrics Recorder", func() {
+			var recorder *fakeRecorder
+
+			BeforeEach(func() {
+				recorder = &fakeRecorder{}
+				metrics.SetOptions(metrics.Options{Recorder: recorder})
+			})
+
+			AfterEach(func() {
+				metrics.SetOptions(metrics.Options{})
+			})
+
+			It("should record the reconciles in the Recorder too", func(done Done) {
+				go func() {
+					defer GinkgoRecover()
+					Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+				}()
+				ctrl.Queue.Add(request)
+				Expect(<-reconciled).To(Equal(request))
+
+				Eventually(recorder.results).Should(Equal([]string{ctrl.Name + "/success"}))
+				Eventually(recorder.timed).Should(Equal(1))
+
+				close(done)
+			})
+		})
+	})
+
+	Describe("ShutdownDiagnostics", func() {
+		It("should report the reconciles in flight", func() {
+			ctrl.Name = "foo-controller"
+			go func() {
+				defer GinkgoRecover()
+				Expect(ctrl.Start(stop)).NotTo(HaveOccurred())
+			}()
+			ctrl.Queue.Add(request)
+
+			Eventually(ctrl.ShutdownDiagnostics).Should(Equal([]interface{}{
+				"controller", "foo-controller", "reconcilesInFlight", 1, "requests", []string{"foo/bar"},
+			}))
+			Expect(<-reconciled).To(Equal(request))
+			Eventually(ctrl.ShutdownDiagnostics).Should(Equal([]interface{}{
+				"controller", "foo-controller", "reconcilesInFlight", 0, "requests", []string{},
+			}))
+		})
+
+		It("should keep Start from returning until the reconciles in flight have returned with WaitForWorkers", func(done Done) {
+			ctrl.WaitForWorkers()
+			stopCtrl := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				Expect(ctrl.Start(stopCtrl)).NotTo(HaveOccurred())
+				close(stopped)
+			}()
+			ctrl.Queue.Add(request)
+			Eventually(func() int { return ctrl.ShutdownDiagnostics()[3].(int) }).Should(Equal(1))
+
+			close(stopCtrl)
+			Consistently(stopped).ShouldNot(BeClosed())
+			Expect(<-reconciled).To(Equal(request))
+			Eventually(stopped).Should(BeClosed())
+
+			close(done)
+		})
+	})
+
+	Describe("DescribeConfig", func() {
+		It("should describe the watches", func() {
+			ctrl.Name = "foo-controller"
+			src := &source.Kind{Type: &corev1.Pod{}}
+			Expect(src.InjectCache(ctrl.Cache)).To(Succeed())
+			Expect(ctrl.Watch(src, &handler.EnqueueRequestForObject{}, predicate.ResourceVersionChangedPredicate{})).To(Succeed())
+			ctrl.Queue.Add(request)
+
+			Expect(ctrl.DescribeConfig()).To(Equal(ConfigDescription{
+				Name:                    "foo-controller",
+				MaxConcurrentReconciles: 1,
+				QueueLength:             1,
+				Watches: []WatchDescription{{
+					Source:       "kind source: *v1.Pod",
+					EventHandler: "*handler.EnqueueRequestForObject",
+					Predicates:   []string{"predicate.ResourceVersionChangedPredicate"},
+				}},
+			}))
+		})
+	})
+
+	Describe("PriorityQueue", func() {
+		var q handler.PriorityQueue
+		var limiter workqueue.RateLimiter
+		newRequest := func(name string) reconcile.Request {
+			return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+		}
+		getAll := func() []interface{} {
+			var items []interface{}
+			for q.Len() > 0 {
+				item, _ := q.Get()
+				q.Done(item)
+				items = append(items, item)
+			}
+			return items
+		}
+
+		BeforeEach(func() {
+			limiter = workqueue.DefaultControllerRateLimiter()
+			q = NewPriorityQueue(limiter)
+		})
+
+		AfterEach(func() {
+			q.ShutDown()
+		})
+
+		It("should hand out items by priority, and in the order they were added", func() {
+			q.Add(newRequest("a"))
+			q.AddWithPriority(newRequest("b"), 10)
+			q.AddWithPriority(newRequest("c"), -1)
+			q.Add(newRequest("d"))
+			q.AddWithPriority(newRequest("e"), 10)
+
+			Expect(getAll()).To(Equal([]interface{}{
+				newRequest("b"), newRequest("e"), newRequest("a"), newRequest("d"), newRequest("c"),
+			}))
+		})
+
+		It("should deduplicate waiting items, keeping the highest priority", func() {
+			q.Add(newRequest("a"))
+			q.Add(newRequest("b"))
+			q.AddWithPriority(newRequest("b"), 10)
+			q.AddWithPriority(newRequest("b"), 5)
+
+			Expect(getAll()).To(Equal([]interface{}{newRequest("b"), newRequest("a")}))
+		})
+
+		It("should hand out an item added while it is processed once it is done", func() {
+			q.Add(newRequest("a"))
+			item, _ := q.Get()
+			q.AddWithPriority(newRequest("a"), 10)
+			Expect(q.Len()).To(Equal(0))
+
+			q.Done(item)
+			Expect(q.Len()).To(Equal(1))
+		})
+
+		It("should add items after a delay", func() {
+			q.AddAfter(newRequest("a"), 50*time.Millisecond)
+			Expect(q.Len()).To(Equal(0))
+			Eventually(q.Len).Should(Equal(1))
+		})
+
+		It("should keep the priorities of items added through a DelayDedupingQueue", func() {
+			dq := NewDelayDedupingQueue(q).(handler.PriorityQueue)
+			dq.Add(newRequest("a"))
+			dq.AddWithPriority(newRequest("b"), 10)
+
+			Expect(getAll()).To(Equal([]interface{}{newRequest("b"), newRequest("a")}))
+		})
+
+		It("should unblock Get when shut down", func() {
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(50 * time.Millisecond)
+				q.ShutDown()
+			}()
+			_, shutdown := q.Get()
+			Expect(shutdown).To(BeTrue())
+		})
+	})
+
+	Describe("CappedRateLimiter", func() {
+		It("should not delay items by more than the maximum delay", func() {
+			limiter := NewCappedRateLimiter(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Hour), 4*time.Millisecond)
+			var delays []time.Duration
+			for i := 0; i < 5; i++ {
+				delays = append(delays, limiter.When(request))
+			}
+			Expect(delays).To(Equal([]time.Duration{
+				time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond,
+			}))
+			Expect(limiter.NumRequeues(request)).To(Equal(5))
+
+			limiter.Forget(request)
+			Expect(limiter.When(request)).To(Equal(time.Millisecond))
+		})
+
+		It("should only cap the per-item backoff of the default rate limiter", func() {
+			limiter := NewDefaultRateLimiter(time.Millisecond)
+			for i := 0; i < 20; i++ {
+				limiter.When(request)
+			}
+			Expect(limiter.When(request)).To(BeNumerically("<=", time.Millisecond))
+
+			By("still limiting the overall rate beyond the burst")
+			var last time.Duration
+			for i := 0; i < 150; i++ {
+				last = limiter.When(reconcile.Request{NamespacedName: types.NamespacedName{Name: fmt.Sprintf("item-%d", i)}})
+			}
+			Expect(last).To(BeNumerically(">", time.Millisecond))
+		})
+	})
+
+	Describe("DelayDedupingQueue", func() {
+		var q workqueue.RateLimitingInterface
+		var inner *DelegatingQueue
+
+		BeforeEach(func() {
+			limiter := workqueue.NewItemExponentialFailureRateLimiter(10*time.Millisecond, time.Hour)
+			inner = &DelegatingQueue{RateLimitingInterface: workqueue.NewRateLimitingQueue(limiter)}
+			q = NewDelayDedupingQueue(inner)
+		})
+
+		AfterEach(func() {
+			q.ShutDown()
+		})
+
+		It("should add items without a delay immediately", func() {
+			q.AddAfter(request, 0)
+			Expect(q.Len()).To(Equal(1))
+		})
+
+		It("should drop a pending delayed add when the item is added", func() {
+			q.AddAfter(request, 50*time.Millisecond)
+			q.Add(request)
+			Expect(q.Len()).To(Equal(1))
+
+			item, _ := q.Get()
+			Expect(item).To(Equal(request))
+			q.Done(item)
+			Consistently(q.Len, 150*time.Millisecond).Should(Equal(0))
+		})
+
+		It("should keep the earliest of the pending delayed adds of an item", func() {
+			other := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "baz"}}
+			q.AddAfter(request, time.Hour)
+			q.AddAfter(request, 10*time.Millisecond)
+			q.AddAfter(other, 10*time.Millisecond)
+			q.AddAfter(other, time.Hour)
+
+			Eventually(q.Len).Should(Equal(2))
+			Expect(q.(*delayDedupingQueue).pendingLen()).To(Equal(0))
+		})
+
+		It("should add delayed items through the AddAfter of the wrapped queue", func() {
+			q.AddAfter(request, 10*time.Millisecond)
+			Eventually(q.Len).Should(Equal(1))
+			Expect(inner.countAddAfter).To(Equal(1))
+		})
+
+		It("should leave the rate limited adds of an item to the wrapped queue", func() {
+			q.AddRateLimited(request)
+			Expect(inner.countAddRateLimited).To(Equal(1))
+			Expect(q.(*delayDedupingQueue).pendingLen()).To(Equal(0))
+			Expect(q.NumRequeues(request)).To(Equal(1))
+
+			By("not superseding the rate limited add with a fresh one")
+			q.Add(request)
+			item, _ := q.Get()
+			q.Done(item)
+			Eventually(q.Len).Should(Equal(1))
+		})
+	})
+})
+
+type DelegatingQueue struct {
+	workqueue.RateLimitingInterface
+
+	countAddRateLimited int
+	countAdd            int
+	countAddAfter       int
+}
+
+func (q *DelegatingQueue) AddRateLimited(item interface{}) {
+	q.countAddRateLimited++
+	q.RateLimitingInterface.AddRateLimited(item)
+}
 
 func (q *DelegatingQueue) AddAfter(item interface{}, d time.Duration) {
 	q.countAddAfter++
@@ -682,3 +2102,79 @@ func (q *DelegatingQueue) Forget(item interface{}) {
 	q.countAdd--
 	q.RateLimitingInterface.Forget(item)
 }
+
+func (q *delayDedupingQueue) pendingLen() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// fakeBatchReconciler sends every batch it reconciles to batches and fails with errs in order.
+type fakeBatchReconciler struct {
+	batches chan reconcile.BatchRequest
+	errs    []error
+	result  reconcile.Result
+}
+
+func (r *fakeBatchReconciler) Reconcile(reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, fmt.Errorf("unexpected call to Reconcile")
+}
+
+func (r *fakeBatchReconciler) ReconcileBatch(batch reconcile.BatchRequest) (reconcile.Result, error) {
+	r.batches <- batch
+	if len(r.errs) == 0 {
+		return r.result, nil
+	}
+	err := r.errs[0]
+	r.errs = r.errs[1:]
+	return reconcile.Result{}, err
+}
+
+// clientBackedCache serves reads from a client.Reader.
+type clientBackedCache struct {
+	*informertest.FakeInformers
+	client.Reader
+}
+
+// Get implements client.Reader
+func (c *clientBackedCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return c.Reader.Get(ctx, key, obj)
+}
+
+// List implements client.Reader
+func (c *clientBackedCache) List(ctx context.Context, list runtime.Object, opts ...client.ListOptionFunc) error {
+	return c.Reader.List(ctx, list, opts...)
+}
+
+// fakeRecorder is a metrics.Recorder which records the reconciles.
+type fakeRecorder struct {
+	mu         sync.Mutex
+	reconciles []string
+	timings    int
+}
+
+func (r *fakeRecorder) RecordReconcile(controller, result string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reconciles = append(r.reconciles, controller+"/"+result)
+}
+
+func (r *fakeRecorder) RecordReconcileTime(string, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timings++
+}
+
+func (r *fakeRecorder) RecordReconcilePaused(string, bool) {}
+
+func (r *fakeRecorder) results() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.reconciles...)
+}
+
+func (r *fakeRecorder) timed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.timings
+}
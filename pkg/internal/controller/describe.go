@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ConfigDescription describes the configuration of a Controller, for manager.ConfigDescriber.
+type ConfigDescription struct {
+	Name                    string `json:"name"`
+	MaxConcurrentReconciles int    `json:"maxConcurrentReconciles"`
+
+	// QueueLength is the number of Requests waiting to be reconciled.
+	QueueLength int `json:"queueLength"`
+
+	// Watches describe the watches of the Controller, in the order they were added.
+	Watches []WatchDescription `json:"watches"`
+}
+
+// WatchDescription describes a watch of a Controller.
+type WatchDescription struct {
+	Source       string   `json:"source"`
+	EventHandler string   `json:"eventHandler"`
+	Predicates   []string `json:"predicates,omitempty"`
+}
+
+// recordWatch records the description of a watch.
+func (c *Controller) recordWatch(src source.Source, evthdler handler.EventHandler, prct []predicate.Predicate) {
+	w := WatchDescription{Source: describeSource(src), EventHandler: fmt.Sprintf("%T", evthdler)}
+	for _, p := range prct {
+		w.Predicates = append(w.Predicates, fmt.Sprintf("%T", p))
+	}
+
+	c.watchesMu.Lock()
+	defer c.watchesMu.Unlock()
+	c.watches = append(c.watches, w)
+}
+
+// describeSource returns the String of src, falling back to its type.
+func describeSource(src source.Source) string {
+	if ks, ok := src.(*source.Kind); ok && ks.Type != nil {
+		// The objects of typed Kinds usually don't carry their GroupVersionKind, so name their type.
+		return fmt.Sprintf("kind source: %T", ks.Type)
+	}
+	if s, ok := src.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", src)
+}
+
+// DescribeConfig returns the ConfigDescription of the Controller.
+func (c *Controller) DescribeConfig() interface{} {
+	c.watchesMu.Lock()
+	watches := append([]WatchDescription{}, c.watches...)
+	c.watchesMu.Unlock()
+
+	d := ConfigDescription{
+		Name:                    c.Name,
+		MaxConcurrentReconciles: c.MaxConcurrentReconciles,
+		Watches:                 watches,
+	}
+	if c.Queue != nil {
+		d.QueueLength = c.Queue.Len()
+	}
+	return d
+}
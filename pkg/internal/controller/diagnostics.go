@@ -0,0 +1,54 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+)
+
+// trackProcessing records obj as being processed by a worker, and returns the function to call once it is done.
+func (c *Controller) trackProcessing(obj interface{}) func() {
+	c.processingMu.Lock()
+	defer c.processingMu.Unlock()
+	if c.processing == nil {
+		c.processing = map[interface{}]int{}
+	}
+	c.processing[obj]++
+
+	return func() {
+		c.processingMu.Lock()
+		defer c.processingMu.Unlock()
+		if c.processing[obj]--; c.processing[obj] <= 0 {
+			delete(c.processing, obj)
+		}
+	}
+}
+
+// ShutdownDiagnostics returns key/value pairs describing the reconciles currently in flight, to be logged
+// when the Controller takes too long to stop.
+func (c *Controller) ShutdownDiagnostics() []interface{} {
+	c.processingMu.Lock()
+	keys := make([]string, 0, len(c.processing))
+	for obj := range c.processing {
+		keys = append(keys, fmt.Sprintf("%v", obj))
+	}
+	c.processingMu.Unlock()
+	sort.Strings(keys)
+
+	return []interface{}{"controller", c.Name, "reconcilesInFlight", len(keys), "requests", keys}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reconcileIntervals enforces the MinReconcileInterval of a Controller.  It remembers when each Request
+// was last reconciled, as long as that matters.
+type reconcileIntervals struct {
+	min time.Duration
+
+	mu        sync.Mutex
+	last      map[reconcile.Request]time.Time
+	lastSweep time.Time
+}
+
+func newReconcileIntervals(min time.Duration) *reconcileIntervals {
+	return &reconcileIntervals{min: min, last: map[reconcile.Request]time.Time{}}
+}
+
+// wait returns how long req has to wait before it may be reconciled again.  If it may be reconciled now,
+// it returns 0 and counts the reconcile as starting now.
+func (r *reconcileIntervals) wait(req reconcile.Request, now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(now)
+	if last, found := r.last[req]; found {
+		if elapsed := now.Sub(last); elapsed < r.min {
+			return r.min - elapsed
+		}
+	}
+	r.last[req] = now
+	return 0
+}
+
+// sweep forgets the reconciles which are too old to delay further ones, at most once per interval.
+func (r *reconcileIntervals) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.min {
+		return
+	}
+	r.lastSweep = now
+	for req, last := range r.last {
+		if now.Sub(last) >= r.min {
+			delete(r.last, req)
+		}
+	}
+}
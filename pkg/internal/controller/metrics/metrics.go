@@ -17,6 +17,8 @@ limitations under the License.
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -25,7 +27,7 @@ var (
 	// ReconcileTotal is a prometheus counter metrics which holds the total
 	// number of reconciliations per controller. It has two labels. controller label refers
 	// to the controller name and result label refers to the reconcile result i.e
-	// success, error, requeue, requeue_after
+	// success, error, requeue, requeue_after, cancelled, invalid_key
 	ReconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "controller_runtime_reconcile_total",
 		Help: "Total number of reconciliations per controller",
@@ -44,6 +46,46 @@ var (
 		Name: "controller_runtime_reconcile_time_seconds",
 		Help: "Length of time per reconciliation per controller",
 	}, []string{"controller"})
+
+	// ReconcileQueueWait is a prometheus metric which keeps track of how long
+	// Requests enqueued by events wait in the queue until their reconciliation
+	// starts, separately from the duration of reconciliations
+	ReconcileQueueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "controller_runtime_reconcile_queue_wait_seconds",
+		Help: "Length of time per controller from the event enqueueing a request until its reconciliation starts",
+	}, []string{"controller"})
+
+	// ReconcilePaused is a prometheus gauge metric which is 1 while the
+	// workers of a controller are paused because its health check fails
+	ReconcilePaused = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "controller_runtime_reconcile_paused",
+		Help: "Whether reconciliations are paused per controller because its health check fails",
+	}, []string{"controller"})
+
+	// ReconcileClassTotal is a prometheus counter metrics which holds the total
+	// number of reconciliations per controller and metrics class of the reconciled
+	// objects, for controllers configured with a metrics class.  Next to the labels
+	// of ReconcileTotal, it has a class label.
+	ReconcileClassTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_reconcile_class_total",
+		Help: "Total number of reconciliations per controller and class of the reconciled objects",
+	}, []string{"controller", "class", "result"})
+
+	// ReconcileRequeueTotal is a prometheus counter metrics which holds the total
+	// number of requeues per controller and reason, for requeues whose Result
+	// carries a reason.  Reasons which the controller hasn't been configured with
+	// are counted as "other".
+	ReconcileRequeueTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_reconcile_requeue_total",
+		Help: "Total number of requeues per controller and reason",
+	}, []string{"controller", "reason"})
+
+	// ReconcileClassTime is a prometheus metric which keeps track of the duration
+	// of reconciliations per controller and metrics class of the reconciled objects
+	ReconcileClassTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "controller_runtime_reconcile_class_time_seconds",
+		Help: "Length of time per reconciliation per controller and class of the reconciled objects",
+	}, []string{"controller", "class"})
 )
 
 func init() {
@@ -51,9 +93,71 @@ func init() {
 		ReconcileTotal,
 		ReconcileErrors,
 		ReconcileTime,
+		ReconcileQueueWait,
+		ReconcilePaused,
+		ReconcileClassTotal,
+		ReconcileClassTime,
+		ReconcileRequeueTotal,
 		// expose process metrics like CPU, Memory, file descriptor usage etc.
 		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 		// expose Go runtime metrics like GC stats, memory stats etc.
 		prometheus.NewGoCollector(),
 	)
 }
+
+// RecordReconcile counts a reconcile of controller with the given result, and a reconcile error if the
+// result is "error".
+func RecordReconcile(controller, result string) {
+	if result == "error" {
+		ReconcileErrors.WithLabelValues(controller).Inc()
+	}
+	ReconcileTotal.WithLabelValues(controller, result).Inc()
+	if r := metrics.ConfiguredRecorder(); r != nil {
+		r.RecordReconcile(controller, result)
+	}
+}
+
+// RecordReconcileTime observes the duration of a reconcile of controller.
+func RecordReconcileTime(controller string, duration time.Duration) {
+	ReconcileTime.WithLabelValues(controller).Observe(duration.Seconds())
+	if r := metrics.ConfiguredRecorder(); r != nil {
+		r.RecordReconcileTime(controller, duration)
+	}
+}
+
+// RecordReconcileQueueWait observes how long a Request of controller waited in the queue.
+func RecordReconcileQueueWait(controller string, wait time.Duration) {
+	ReconcileQueueWait.WithLabelValues(controller).Observe(wait.Seconds())
+	if r, ok := metrics.ConfiguredRecorder().(metrics.QueueWaitRecorder); ok {
+		r.RecordReconcileQueueWait(controller, wait)
+	}
+}
+
+// RecordReconcileClass counts a reconcile of an object of the given metrics class by controller with the
+// given result.
+func RecordReconcileClass(controller, class, result string) {
+	ReconcileClassTotal.WithLabelValues(controller, class, result).Inc()
+}
+
+// RecordReconcileClassTime observes the duration of a reconcile of an object of the given metrics class by
+// controller.
+func RecordReconcileClassTime(controller, class string, duration time.Duration) {
+	ReconcileClassTime.WithLabelValues(controller, class).Observe(duration.Seconds())
+}
+
+// RecordRequeue counts a requeue of controller for the given reason.
+func RecordRequeue(controller, reason string) {
+	ReconcileRequeueTotal.WithLabelValues(controller, reason).Inc()
+}
+
+// RecordReconcilePaused sets whether the workers of controller are paused.
+func RecordReconcilePaused(controller string, paused bool) {
+	value := 0.0
+	if paused {
+		value = 1
+	}
+	ReconcilePaused.WithLabelValues(controller).Set(value)
+	if r := metrics.ConfiguredRecorder(); r != nil {
+		r.RecordReconcilePaused(controller, paused)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// OtherMetricsClass is the metrics class of the objects whose class isn't allowed.
+const OtherMetricsClass = "other"
+
+// NewMetricsClass returns a function returning the metrics class class computes for a Request if it is
+// one of allowed, and OtherMetricsClass otherwise, bounding the number of metrics series.
+func NewMetricsClass(class func(reconcile.Request) string, allowed []string) func(reconcile.Request) string {
+	isAllowed := make(map[string]bool, len(allowed))
+	for _, c := range allowed {
+		isAllowed[c] = true
+	}
+	return func(req reconcile.Request) string {
+		if c := class(req); isAllowed[c] {
+			return c
+		}
+		return OtherMetricsClass
+	}
+}
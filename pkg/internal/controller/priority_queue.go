@@ -0,0 +1,221 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+var _ handler.PriorityQueue = &priorityQueue{}
+
+// NewPriorityQueue returns a rate limited queue which hands out the items with the highest priority first,
+// and items of the same priority in the order they were added.  Items added with Add, AddAfter or
+// AddRateLimited get handler.DefaultPriority.  Like the workqueue, it processes an item at most once at a
+// time and deduplicates items which are waiting.
+//
+// Unlike the workqueue, it doesn't expose workqueue metrics.
+func NewPriorityQueue(rateLimiter workqueue.RateLimiter) handler.PriorityQueue {
+	q := &priorityQueue{
+		rateLimiter: rateLimiter,
+		dirty:       map[interface{}]*priorityItem{},
+		processing:  map[interface{}]bool{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// priorityQueue implements handler.PriorityQueue with a heap of the waiting items.
+type priorityQueue struct {
+	rateLimiter workqueue.RateLimiter
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// waiting are the items which can be handed out, ordered by priority.
+	waiting priorityHeap
+
+	// dirty are the items which need to be processed, either waiting or waiting for their
+	// processing to finish.
+	dirty map[interface{}]*priorityItem
+
+	// processing are the items which have been handed out and aren't done yet.
+	processing map[interface{}]bool
+
+	// added counts the items added, to keep the order of items of the same priority.
+	added uint64
+
+	shuttingDown bool
+}
+
+// priorityItem is a dirty item of a priorityQueue.
+type priorityItem struct {
+	item     interface{}
+	priority int
+	seq      uint64
+	// index is the index of the item in the heap, or -1 if it isn't waiting.
+	index int
+}
+
+// Add implements workqueue.Interface
+func (q *priorityQueue) Add(item interface{}) {
+	q.AddWithPriority(item, handler.DefaultPriority)
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *priorityQueue) AddWithPriority(item interface{}, priority int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shuttingDown {
+		return
+	}
+
+	if d, found := q.dirty[item]; found {
+		if priority > d.priority {
+			d.priority = priority
+			if d.index >= 0 {
+				heap.Fix(&q.waiting, d.index)
+			}
+		}
+		return
+	}
+
+	q.added++
+	d := &priorityItem{item: item, priority: priority, seq: q.added, index: -1}
+	q.dirty[item] = d
+	if q.processing[item] {
+		// Queued again by Done.
+		return
+	}
+	heap.Push(&q.waiting, d)
+	q.cond.Signal()
+}
+
+// Len implements workqueue.Interface
+func (q *priorityQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiting.Len()
+}
+
+// Get implements workqueue.Interface
+func (q *priorityQueue) Get() (interface{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.waiting.Len() == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if q.waiting.Len() == 0 {
+		return nil, true
+	}
+
+	d := heap.Pop(&q.waiting).(*priorityItem)
+	delete(q.dirty, d.item)
+	q.processing[d.item] = true
+	return d.item, false
+}
+
+// Done implements workqueue.Interface
+func (q *priorityQueue) Done(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, item)
+	if d, found := q.dirty[item]; found {
+		heap.Push(&q.waiting, d)
+		q.cond.Signal()
+	}
+}
+
+// ShutDown implements workqueue.Interface
+func (q *priorityQueue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShuttingDown implements workqueue.Interface
+func (q *priorityQueue) ShuttingDown() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.shuttingDown
+}
+
+// AddAfter implements workqueue.DelayingInterface
+func (q *priorityQueue) AddAfter(item interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(duration, func() { q.Add(item) })
+}
+
+// AddRateLimited implements workqueue.RateLimitingInterface
+func (q *priorityQueue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+// Forget implements workqueue.RateLimitingInterface
+func (q *priorityQueue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+// NumRequeues implements workqueue.RateLimitingInterface
+func (q *priorityQueue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+// priorityHeap implements heap.Interface, ordering items by descending priority, then by the order they
+// were added.
+type priorityHeap []*priorityItem
+
+func (h priorityHeap) Len() int { return len(h) }
+
+func (h priorityHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h priorityHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *priorityHeap) Push(x interface{}) {
+	d := x.(*priorityItem)
+	d.index = len(*h)
+	*h = append(*h, d)
+}
+
+func (h *priorityHeap) Pop() interface{} {
+	old := *h
+	d := old[len(old)-1]
+	old[len(old)-1] = nil
+	d.index = -1
+	*h = old[:len(old)-1]
+	return d
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// progressions tracks the reconcile.Progressions of the Requests requeued by ProgressiveRequeue Results,
+// for as long as they keep returning them.
+type progressions struct {
+	mu     sync.Mutex
+	states map[reconcile.Request]progressionState
+}
+
+// progressionState is how far the Progression of a Request went.
+type progressionState struct {
+	key      string
+	attempts int
+}
+
+// next returns the delay of the next requeue of req for p.  The progression starts over if its key changed.
+func (p *progressions) next(req reconcile.Request, prog reconcile.Progression, ceiling time.Duration) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.states == nil {
+		p.states = map[reconcile.Request]progressionState{}
+	}
+	s, found := p.states[req]
+	if !found || s.key != prog.Key {
+		s = progressionState{key: prog.Key}
+	}
+	delay := progressionDelay(prog, s.attempts, ceiling)
+	s.attempts++
+	p.states[req] = s
+	return delay
+}
+
+// reset forgets the Progression of req.
+func (p *progressions) reset(req reconcile.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.states, req)
+}
+
+// watchesFor reports whether src watches the objects of the For type of the Controller.
+func (c *Controller) watchesFor(src source.Source) bool {
+	kind, isKind := src.(*source.Kind)
+	if !isKind || kind.Type == nil || c.For == nil {
+		return false
+	}
+	if u, isUnstructured := c.For.(*unstructured.Unstructured); isUnstructured {
+		ku, isUnstructured := kind.Type.(*unstructured.Unstructured)
+		return isUnstructured && ku.GroupVersionKind() == u.GroupVersionKind()
+	}
+	return reflect.TypeOf(kind.Type) == reflect.TypeOf(c.For)
+}
+
+var _ handler.EventHandler = resetProgressionOnUpdateHandler{}
+
+// resetProgressionOnUpdateHandler wraps the EventHandler of the objects of the For type so that any Request
+// it enqueues in response to an update carrying a new resourceVersion starts its Progression over.
+type resetProgressionOnUpdateHandler struct {
+	handler.EventHandler
+	reset func(reconcile.Request)
+}
+
+// Update implements handler.EventHandler
+func (h resetProgressionOnUpdateHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if evt.MetaOld == nil || evt.MetaNew == nil ||
+		evt.MetaOld.GetResourceVersion() == evt.MetaNew.GetResourceVersion() {
+		h.EventHandler.Update(evt, q)
+		return
+	}
+	h.EventHandler.Update(evt, &progressionResettingQueue{RateLimitingInterface: q, reset: h.reset})
+}
+
+// progressionResettingQueue resets the Progression of every Request added to it.
+type progressionResettingQueue struct {
+	workqueue.RateLimitingInterface
+	reset func(reconcile.Request)
+}
+
+// Add implements workqueue.Interface
+func (q *progressionResettingQueue) Add(item interface{}) {
+	if req, ok := item.(reconcile.Request); ok {
+		q.reset(req)
+	}
+	q.RateLimitingInterface.Add(item)
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *progressionResettingQueue) AddWithPriority(item interface{}, priority int) {
+	if req, ok := item.(reconcile.Request); ok {
+		q.reset(req)
+	}
+	addWithPriority(q.RateLimitingInterface, item, priority)
+}
+
+// progressionDelay returns the delay of the requeue after the given number of previous ones.
+func progressionDelay(prog reconcile.Progression, attempts int, ceiling time.Duration) time.Duration {
+	base, factor := prog.Base, prog.Factor
+	if base <= 0 {
+		base = time.Second
+	}
+	if factor < 1 {
+		factor = 2
+	}
+	limit := time.Duration(math.MaxInt64)
+	if prog.Max > 0 {
+		limit = prog.Max
+	}
+	if ceiling > 0 && ceiling < limit {
+		limit = ceiling
+	}
+	delay := float64(base) * math.Pow(factor, float64(attempts))
+	if delay >= float64(limit) {
+		return limit
+	}
+	return time.Duration(delay)
+}
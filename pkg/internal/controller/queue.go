@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// NewDelayDedupingQueue wraps q so that adding an item supersedes any delayed add of the same item which is
+// still pending, e.g. a reconcile.Result.RequeueAfter which hasn't elapsed yet when a fresh event arrives.
+// The workqueue only deduplicates items which are queued at the same time, so without this the item would
+// be processed once for the event and a second time when the delay elapses.
+//
+// Delayed adds of an item which is already waiting to be added are merged, keeping the earliest one.  Rate
+// limited adds, i.e. retries after errors, are left to q and are never superseded, so that errors keep
+// backing off.
+func NewDelayDedupingQueue(q workqueue.RateLimitingInterface) workqueue.RateLimitingInterface {
+	return &delayDedupingQueue{RateLimitingInterface: q, pending: map[interface{}]*delayedAdd{}}
+}
+
+// delayDedupingQueue implements delayed adds itself, so that they can be cancelled.
+type delayDedupingQueue struct {
+	workqueue.RateLimitingInterface
+
+	mu      sync.Mutex
+	pending map[interface{}]*delayedAdd
+}
+
+// delayedAdd is a pending delayed add of an item.
+type delayedAdd struct {
+	timer   *time.Timer
+	readyAt time.Time
+}
+
+// Add implements workqueue.Interface
+func (q *delayDedupingQueue) Add(item interface{}) {
+	q.cancelPending(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *delayDedupingQueue) AddWithPriority(item interface{}, priority int) {
+	q.cancelPending(item)
+	addWithPriority(q.RateLimitingInterface, item, priority)
+}
+
+// cancelPending cancels the pending delayed add of item, if there is one.
+func (q *delayDedupingQueue) cancelPending(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if d, found := q.pending[item]; found {
+		d.timer.Stop()
+		delete(q.pending, item)
+	}
+}
+
+// AddAfter implements workqueue.DelayingInterface
+func (q *delayDedupingQueue) AddAfter(item interface{}, duration time.Duration) {
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	if q.ShuttingDown() {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	readyAt := time.Now().Add(duration)
+	if d, found := q.pending[item]; found {
+		if !readyAt.Before(d.readyAt) {
+			return
+		}
+		d.timer.Stop()
+	}
+
+	d := &delayedAdd{readyAt: readyAt}
+	d.timer = time.AfterFunc(duration, func() {
+		q.mu.Lock()
+		if q.pending[item] != d {
+			// Superseded in the meantime.
+			q.mu.Unlock()
+			return
+		}
+		delete(q.pending, item)
+		q.mu.Unlock()
+
+		// Add through q.AddAfter, so that q still counts the delayed add as a retry.
+		q.RateLimitingInterface.AddAfter(item, 0)
+	})
+	q.pending[item] = d
+}
+
+// addWithPriority adds item to q with the given priority if q is a handler.PriorityQueue, and plainly
+// otherwise.
+func addWithPriority(q workqueue.RateLimitingInterface, item interface{}, priority int) {
+	if pq, ok := q.(handler.PriorityQueue); ok {
+		pq.AddWithPriority(item, priority)
+		return
+	}
+	q.Add(item)
+}
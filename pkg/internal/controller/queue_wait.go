@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// enqueueTimingQueue records when EventHandlers add Requests to it, so that the time they wait in the
+// queue until their reconcile starts can be measured.
+type enqueueTimingQueue struct {
+	workqueue.RateLimitingInterface
+
+	record func(reconcile.Request, time.Time)
+}
+
+func (q *enqueueTimingQueue) recordAt(item interface{}, at time.Time) {
+	if req, ok := item.(reconcile.Request); ok {
+		q.record(req, at)
+	}
+}
+
+// Add implements workqueue.Interface
+func (q *enqueueTimingQueue) Add(item interface{}) {
+	// Record before adding, so that a worker picking up the item right away finds the time.
+	q.recordAt(item, time.Now())
+	q.RateLimitingInterface.Add(item)
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *enqueueTimingQueue) AddWithPriority(item interface{}, priority int) {
+	q.recordAt(item, time.Now())
+	addWithPriority(q.RateLimitingInterface, item, priority)
+}
+
+// AddAfter implements workqueue.DelayingInterface
+func (q *enqueueTimingQueue) AddAfter(item interface{}, duration time.Duration) {
+	// The Request only starts waiting for a worker once the delay has passed.
+	q.recordAt(item, time.Now().Add(duration))
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+// recordEnqueue records that req was enqueued at the given time, unless it has been enqueued before
+// without being reconciled since.
+func (c *Controller) recordEnqueue(req reconcile.Request, at time.Time) {
+	c.enqueuedMu.Lock()
+	defer c.enqueuedMu.Unlock()
+	if c.enqueued == nil {
+		c.enqueued = map[reconcile.Request]time.Time{}
+	}
+	if first, found := c.enqueued[req]; found && !at.Before(first) {
+		return
+	}
+	c.enqueued[req] = at
+}
+
+// takeEnqueueTime returns the time req was enqueued, and forgets it so that requeues of req don't report
+// it again.
+func (c *Controller) takeEnqueueTime(req reconcile.Request) (time.Time, bool) {
+	c.enqueuedMu.Lock()
+	defer c.enqueuedMu.Unlock()
+	at, found := c.enqueued[req]
+	if found {
+		delete(c.enqueued, req)
+	}
+	return at, found
+}
+
+// queueWait returns how long a Request enqueued at the given time waited until its reconcile started.
+func queueWait(enqueued, started time.Time) time.Duration {
+	if wait := started.Sub(enqueued); wait > 0 {
+		return wait
+	}
+	// Delayed adds are expected to be ready at the time they were enqueued for, but timers may fire early.
+	return 0
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// shutdownCanceller cancels the reconciles in flight once the Controller stops, and lets it wait for them
+// to return, so that their save-points run before the Controller returns from Start.
+type shutdownCanceller struct {
+	// ctx is the context of the reconciles, which is cancelled once the Controller stops.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	idle     *sync.Cond
+	inFlight int
+}
+
+func newShutdownCanceller() *shutdownCanceller {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &shutdownCanceller{ctx: ctx, cancel: cancel}
+	s.idle = sync.NewCond(&s.mu)
+	return s
+}
+
+// begin tracks a reconcile about to start, unless the Controller is stopping already.
+func (s *shutdownCanceller) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return false
+	}
+	s.inFlight++
+	return true
+}
+
+// end tracks a reconcile which has returned.
+func (s *shutdownCanceller) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if s.inFlight == 0 {
+		s.idle.Broadcast()
+	}
+}
+
+// stopping reports whether the Controller is stopping.
+func (s *shutdownCanceller) stopping() bool {
+	return s.ctx.Err() != nil
+}
+
+// stop cancels the reconciles in flight, and waits for them to return.  No reconcile starts afterwards.
+func (s *shutdownCanceller) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel()
+	for s.inFlight > 0 {
+		s.idle.Wait()
+	}
+}
+
+// runSavePoints runs the save-points of the reconcile of req, which was cancelled by the shutdown.
+func (c *Controller) runSavePoints(req reconcile.Request, savePoints *reconcile.SavePoints) {
+	if savePoints.Len() == 0 {
+		return
+	}
+	log.Info("Running the save-points of a reconcile cancelled by the shutdown",
+		"controller", c.Name, "request", req, "savePoints", savePoints.Len())
+	if err := savePoints.Run(context.Background()); err != nil {
+		log.Error(err, "Failed to run the save-points of a reconcile cancelled by the shutdown",
+			"controller", c.Name, "request", req)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ handler.EventHandler = triggerEventHandler{}
+
+// triggerEventHandler wraps an EventHandler so that the event which enqueues a Request is recorded
+// for its reconcile.
+type triggerEventHandler struct {
+	handler.EventHandler
+
+	record func(reconcile.Request, reconcile.TriggerEvent)
+}
+
+// Create implements handler.EventHandler
+func (h triggerEventHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Create(evt, h.queueFor(reconcile.TriggerEvent{Verb: reconcile.TriggerCreate}, q))
+}
+
+// Update implements handler.EventHandler
+func (h triggerEventHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	trigger := reconcile.TriggerEvent{Verb: reconcile.TriggerUpdate, ChangedFields: changedFields(evt.ObjectOld, evt.ObjectNew)}
+	if evt.MetaOld != nil {
+		trigger.OldResourceVersion = evt.MetaOld.GetResourceVersion()
+	}
+	h.EventHandler.Update(evt, h.queueFor(trigger, q))
+}
+
+// Delete implements handler.EventHandler
+func (h triggerEventHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Delete(evt, h.queueFor(reconcile.TriggerEvent{Verb: reconcile.TriggerDelete}, q))
+}
+
+// Generic implements handler.EventHandler
+func (h triggerEventHandler) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Generic(evt, h.queueFor(reconcile.TriggerEvent{Verb: reconcile.TriggerGeneric}, q))
+}
+
+func (h triggerEventHandler) queueFor(trigger reconcile.TriggerEvent, q workqueue.RateLimitingInterface) workqueue.RateLimitingInterface {
+	return &recordingQueue{RateLimitingInterface: q, record: func(req reconcile.Request) { h.record(req, trigger) }}
+}
+
+// recordingQueue records the event which is being handled for every Request added to it.  Every
+// Request is only recorded once, since EventHandlers may add it several times for the same event,
+// e.g. for both the old and the new object of an update.
+type recordingQueue struct {
+	workqueue.RateLimitingInterface
+
+	record   func(reconcile.Request)
+	recorded map[reconcile.Request]bool
+}
+
+func (q *recordingQueue) recordOnce(item interface{}) {
+	req, ok := item.(reconcile.Request)
+	if !ok || q.recorded[req] {
+		return
+	}
+	if q.recorded == nil {
+		q.recorded = map[reconcile.Request]bool{}
+	}
+	q.recorded[req] = true
+	q.record(req)
+}
+
+// Add implements workqueue.Interface
+func (q *recordingQueue) Add(item interface{}) {
+	// Record before adding, so that a worker picking up the item right away finds the event.
+	q.recordOnce(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+// AddAfter implements workqueue.DelayingInterface
+func (q *recordingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.recordOnce(item)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+// AddRateLimited implements workqueue.RateLimitingInterface
+func (q *recordingQueue) AddRateLimited(item interface{}) {
+	q.recordOnce(item)
+	q.RateLimitingInterface.AddRateLimited(item)
+}
+
+// AddWithPriority implements handler.PriorityQueue
+func (q *recordingQueue) AddWithPriority(item interface{}, priority int) {
+	q.recordOnce(item)
+	addWithPriority(q.RateLimitingInterface, item, priority)
+}
+
+// recordTrigger records the event which enqueued req.  If another event is still waiting for the same
+// Request, the two are coalesced into one reconcile whose trigger is unknown.
+func (c *Controller) recordTrigger(req reconcile.Request, trigger reconcile.TriggerEvent) {
+	c.triggersMu.Lock()
+	defer c.triggersMu.Unlock()
+	if c.triggers == nil {
+		c.triggers = map[reconcile.Request]reconcile.TriggerEvent{}
+	}
+	if _, found := c.triggers[req]; found {
+		trigger = reconcile.TriggerEvent{Verb: reconcile.TriggerUnknown}
+	}
+	c.triggers[req] = trigger
+}
+
+// takeTrigger returns the event which enqueued req, and forgets it so that requeues of req don't
+// report it again.
+func (c *Controller) takeTrigger(req reconcile.Request) reconcile.TriggerEvent {
+	c.triggersMu.Lock()
+	defer c.triggersMu.Unlock()
+	trigger, found := c.triggers[req]
+	if !found {
+		return reconcile.TriggerEvent{Verb: reconcile.TriggerUnknown}
+	}
+	delete(c.triggers, req)
+	return trigger
+}
+
+// changedFields returns the top-level fields which differ between oldObj and newObj, ignoring the
+// resourceVersion.  It returns nil if either object can't be converted to unstructured content.
+func changedFields(oldObj, newObj runtime.Object) []string {
+	oldContent, err := toContent(oldObj)
+	if err != nil {
+		return nil
+	}
+	newContent, err := toContent(newObj)
+	if err != nil {
+		return nil
+	}
+
+	var changed []string
+	for field, newValue := range newContent {
+		if !reflect.DeepEqual(oldContent[field], newValue) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range oldContent {
+		if _, found := newContent[field]; !found {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// toContent returns the unstructured content of obj without its resourceVersion.
+func toContent(obj runtime.Object) (map[string]interface{}, error) {
+	if obj == nil {
+		return nil, fmt.Errorf("object is nil")
+	}
+	var content map[string]interface{}
+	if u, isUnstructured := obj.(runtime.Unstructured); isUnstructured {
+		content = u.UnstructuredContent()
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata, ok := content["metadata"].(map[string]interface{})
+	if !ok {
+		return content, nil
+	}
+	// Copy rather than modify the content of unstructured objects, which is shared with the cache.
+	withoutVersion := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if k != "resourceVersion" {
+			withoutVersion[k] = v
+		}
+	}
+	copied := make(map[string]interface{}, len(content))
+	for k, v := range content {
+		copied[k] = v
+	}
+	copied["metadata"] = withoutVersion
+	return copied, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigDescription is the effective configuration of a manager, as assembled from its Options and the
+// Runnables added to it.  It marshals to JSON.
+type ConfigDescription struct {
+	// Namespace is the namespace the cache is restricted to, or empty for all namespaces.
+	Namespace string `json:"namespace,omitempty"`
+
+	// SyncPeriod is the resync period of the cache, or nil for the default one.
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
+
+	// LeaderElection describes the leader election, or is nil if it is disabled.
+	LeaderElection *LeaderElectionDescription `json:"leaderElection,omitempty"`
+
+	// MetricsAddress is the address the metrics are served at, or empty if they aren't served.
+	MetricsAddress string `json:"metricsAddress,omitempty"`
+
+	// Webhook describes the webhook server, or is nil if it hasn't been created.
+	Webhook *WebhookDescription `json:"webhook,omitempty"`
+
+	// GracefulShutdownTimeout is how long the manager waits for the Runnables to stop.
+	GracefulShutdownTimeout metav1.Duration `json:"gracefulShutdownTimeout"`
+
+	// Started is true once the manager has been started.
+	Started bool `json:"started"`
+
+	// Runnables describe the Runnables added to the manager: first those which don't need leader election,
+	// as they are started first, and then the others, each in the order they were added.
+	Runnables []RunnableDescription `json:"runnables"`
+}
+
+// LeaderElectionDescription describes the leader election of a manager.
+type LeaderElectionDescription struct {
+	// Lock describes the resource lock, as namespace/name.
+	Lock string `json:"lock"`
+
+	// Identity is the identity of the manager in the election.
+	Identity string `json:"identity"`
+
+	LeaseDuration metav1.Duration `json:"leaseDuration"`
+	RenewDeadline metav1.Duration `json:"renewDeadline"`
+	RetryPeriod   metav1.Duration `json:"retryPeriod"`
+}
+
+// WebhookDescription describes the webhook server of a manager.
+type WebhookDescription struct {
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port"`
+
+	// Paths are the paths of the registered webhooks, sorted.
+	Paths []string `json:"paths"`
+}
+
+// RunnableDescription describes a Runnable added to a manager.
+type RunnableDescription struct {
+	// Type is the Go type of the Runnable.
+	Type string `json:"type"`
+
+	// LeaderElection is true if the Runnable only runs while the manager is the leader.
+	LeaderElection bool `json:"leaderElection"`
+
+	// Config is the configuration the Runnable describes itself, if it does, such as the name and the
+	// watches of a Controller.
+	Config interface{} `json:"config,omitempty"`
+}
+
+// ConfigDescriber is implemented by the Managers which can describe their effective configuration, such as
+// the ones returned by New.  It isn't part of Manager, which other implementations don't have to extend.
+type ConfigDescriber interface {
+	// DescribeConfig returns the effective configuration of the manager and of the Runnables added to it,
+	// e.g. to log it or to attach it to a support ticket.
+	DescribeConfig() ConfigDescription
+}
+
+var _ ConfigDescriber = &controllerManager{}
+
+// runnableDescriber is implemented by Runnables which can describe their configuration, such as Controllers.
+type runnableDescriber interface {
+	// DescribeConfig returns the configuration of the Runnable, marshaling to JSON.
+	DescribeConfig() interface{}
+}
+
+// String returns d as indented JSON.
+func (d ConfigDescription) String() string {
+	out, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("unable to describe the configuration: %v", err)
+	}
+	return string(out)
+}
+
+// DescribeConfig implements ConfigDescriber
+func (cm *controllerManager) DescribeConfig() ConfigDescription {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	d := ConfigDescription{
+		Namespace:               cm.namespace,
+		GracefulShutdownTimeout: metav1.Duration{Duration: cm.gracefulShutdownTimeout},
+		Started:                 cm.started,
+		Runnables:               []RunnableDescription{},
+	}
+	if cm.syncPeriod != nil {
+		d.SyncPeriod = &metav1.Duration{Duration: *cm.syncPeriod}
+	}
+	if cm.resourceLock != nil {
+		d.LeaderElection = &LeaderElectionDescription{
+			Lock:          cm.resourceLock.Describe(),
+			Identity:      cm.resourceLock.Identity(),
+			LeaseDuration: metav1.Duration{Duration: cm.leaseDuration},
+			RenewDeadline: metav1.Duration{Duration: cm.renewDeadline},
+			RetryPeriod:   metav1.Duration{Duration: cm.retryPeriod},
+		}
+	}
+	if cm.metricsListener != nil {
+		d.MetricsAddress = cm.metricsListener.Addr().String()
+	}
+	if cm.webhookServer != nil {
+		d.Webhook = &WebhookDescription{
+			Host:  cm.webhookServer.Host,
+			Port:  cm.webhookServer.Port,
+			Paths: cm.webhookServer.Paths(),
+		}
+	}
+
+	for _, r := range cm.nonLeaderElectionRunnables {
+		d.Runnables = append(d.Runnables, describeRunnable(r, false))
+	}
+	for _, r := range cm.leaderElectionRunnables {
+		d.Runnables = append(d.Runnables, describeRunnable(r, true))
+	}
+	return d
+}
+
+func describeRunnable(r Runnable, leaderElection bool) RunnableDescription {
+	d := RunnableDescription{Type: fmt.Sprintf("%T", r), LeaderElection: leaderElection}
+	if cd, ok := r.(runnableDescriber); ok {
+		d.Config = cd.DescribeConfig()
+	}
+	return d
+}
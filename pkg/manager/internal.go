@@ -123,6 +123,26 @@ type controllerManager struct {
 	// retryPeriod is the duration the LeaderElector clients should wait
 	// between tries of actions.
 	retryPeriod time.Duration
+
+	// stopOnce closes internalStopper once.
+	stopOnce sync.Once
+
+	// gracefulShutdownTimeout is how long Start waits for the Runnables to stop.
+	gracefulShutdownTimeout time.Duration
+	// shutdownDiagnostics logs the Runnables still running after gracefulShutdownTimeout.
+	shutdownDiagnostics bool
+	// shutdownGoroutineDump logs the goroutine stacks with the shutdown diagnostics.
+	shutdownGoroutineDump bool
+
+	// running are the Runnables which have been started and haven't returned yet, by start order.
+	running          map[int]Runnable
+	runnablesStarted int
+	runningMu        sync.Mutex
+
+	// namespace is the namespace the cache is restricted to, for DescribeConfig.
+	namespace string
+	// syncPeriod is the resync period of the cache, for DescribeConfig.
+	syncPeriod *time.Duration
 }
 
 // Add sets dependencies on i, and adds it to the list of Runnables to start.
@@ -144,9 +164,7 @@ func (cm *controllerManager) Add(r Runnable) error {
 
 	if cm.started {
 		// If already started, start the controller
-		go func() {
-			cm.errChan <- r.Start(cm.internalStop)
-		}()
+		cm.startRunnable(r)
 	}
 
 	return nil
@@ -254,7 +272,7 @@ func (cm *controllerManager) serveMetrics(stop <-chan struct{}) {
 
 func (cm *controllerManager) Start(stop <-chan struct{}) error {
 	// join the passed-in stop channel as an upstream feeding into cm.internalStopper
-	defer close(cm.internalStopper)
+	defer cm.stopRunnables()
 
 	// Metrics should be served whether the controller is leader or not.
 	// (If we don't serve metrics for non-leaders, prometheus will still scrape
@@ -277,7 +295,7 @@ func (cm *controllerManager) Start(stop <-chan struct{}) error {
 	select {
 	case <-stop:
 		// We are done
-		return nil
+		return cm.waitForRunnables()
 	case err := <-cm.errChan:
 		// Error starting a controller
 		return err
@@ -294,10 +312,7 @@ func (cm *controllerManager) startNonLeaderElectionRunnables() {
 	for _, c := range cm.nonLeaderElectionRunnables {
 		// Controllers block, but we want to return an error if any have an error starting.
 		// Write any Start errors to a channel so we can return them
-		ctrl := c
-		go func() {
-			cm.errChan <- ctrl.Start(cm.internalStop)
-		}()
+		cm.startRunnable(c)
 	}
 }
 
@@ -311,10 +326,7 @@ func (cm *controllerManager) startLeaderElectionRunnables() {
 	for _, c := range cm.leaderElectionRunnables {
 		// Controllers block, but we want to return an error if any have an error starting.
 		// Write any Start errors to a channel so we can return them
-		ctrl := c
-		go func() {
-			cm.errChan <- ctrl.Start(cm.internalStop)
-		}()
+		cm.startRunnable(c)
 	}
 }
 
@@ -340,16 +352,20 @@ func (cm *controllerManager) waitForCache() {
 }
 
 func (cm *controllerManager) startLeaderElection() (err error) {
+	leMetrics := newLeaderElectionMetrics(cm.resourceLock)
 	l, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
-		Lock:          cm.resourceLock,
+		Lock:          leMetrics,
 		LeaseDuration: cm.leaseDuration,
 		RenewDeadline: cm.renewDeadline,
 		RetryPeriod:   cm.retryPeriod,
 		Callbacks: leaderelection.LeaderCallbacks{
 			OnStartedLeading: func(_ context.Context) {
+				leMetrics.startedLeading()
 				cm.startLeaderElectionRunnables()
 			},
+			OnNewLeader: leMetrics.newLeader,
 			OnStoppedLeading: func() {
+				leMetrics.stoppedLeading()
 				// Most implementations of leader election log.Fatal() here.
 				// Since Start is wrapped in log.Fatal when called, we can just return
 				// an error here which will cause the program to exit.
@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// leaderElectionIsLeader is 1 while this process holds the leader election lock, and 0 otherwise.
+	leaderElectionIsLeader = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "controller_runtime_leader_election_is_leader",
+		Help: "Whether this process currently holds the leader election lock",
+	}, []string{"lock"})
+
+	// leaderElectionAcquisitions counts how often this process acquired the leader election lock.
+	leaderElectionAcquisitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_leader_election_acquisitions_total",
+		Help: "Total number of times this process acquired the leader election lock",
+	}, []string{"lock"})
+
+	// leaderElectionLeaderChanges counts the changes of the leader observed by this process, whoever
+	// the leader is.
+	leaderElectionLeaderChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_leader_election_leader_changes_total",
+		Help: "Total number of changes of the leader observed by this process",
+	}, []string{"lock"})
+
+	// leaderElectionRenewalFailures counts the failed attempts of the leader to renew its lock.
+	leaderElectionRenewalFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_leader_election_renewal_failures_total",
+		Help: "Total number of failed attempts to renew the leader election lock",
+	}, []string{"lock"})
+
+	// leaderElectionLastRenewal is the time of the last successful renewal of the lock by this process,
+	// so that time() minus it is the time since.
+	leaderElectionLastRenewal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "controller_runtime_leader_election_last_renewal_timestamp_seconds",
+		Help: "Unix time of the last successful renewal of the leader election lock",
+	}, []string{"lock"})
+
+	registerLeaderElectionMetrics sync.Once
+)
+
+// leaderElectionMetrics records the metrics of the leader election of a manager.  It wraps the resource
+// lock to observe renewals, and is notified of the leader election callbacks.
+type leaderElectionMetrics struct {
+	resourcelock.Interface
+
+	// lock is the label of the metrics, the description of the resource lock.
+	lock string
+
+	// leading is 1 while this process holds the lock.
+	leading int32
+}
+
+// newLeaderElectionMetrics registers the leader election metrics, if they haven't been yet, and returns
+// a leaderElectionMetrics for lock.
+func newLeaderElectionMetrics(lock resourcelock.Interface) *leaderElectionMetrics {
+	registerLeaderElectionMetrics.Do(func() {
+		metrics.Registry.MustRegister(
+			leaderElectionIsLeader,
+			leaderElectionAcquisitions,
+			leaderElectionLeaderChanges,
+			leaderElectionRenewalFailures,
+			leaderElectionLastRenewal)
+	})
+	m := &leaderElectionMetrics{Interface: lock, lock: lock.Describe()}
+	leaderElectionIsLeader.WithLabelValues(m.lock).Set(0)
+	return m
+}
+
+// Get implements resourcelock.Interface
+func (m *leaderElectionMetrics) Get() (*resourcelock.LeaderElectionRecord, error) {
+	record, err := m.Interface.Get()
+	if err != nil {
+		m.renewalFailed()
+	}
+	return record, err
+}
+
+// Update implements resourcelock.Interface
+func (m *leaderElectionMetrics) Update(ler resourcelock.LeaderElectionRecord) error {
+	if err := m.Interface.Update(ler); err != nil {
+		m.renewalFailed()
+		return err
+	}
+	if atomic.LoadInt32(&m.leading) == 1 {
+		m.renewed()
+	}
+	return nil
+}
+
+// startedLeading records the acquisition of the lock.
+func (m *leaderElectionMetrics) startedLeading() {
+	atomic.StoreInt32(&m.leading, 1)
+	leaderElectionAcquisitions.WithLabelValues(m.lock).Inc()
+	leaderElectionIsLeader.WithLabelValues(m.lock).Set(1)
+	m.renewed()
+}
+
+// stoppedLeading records the loss of the lock.
+func (m *leaderElectionMetrics) stoppedLeading() {
+	atomic.StoreInt32(&m.leading, 0)
+	leaderElectionIsLeader.WithLabelValues(m.lock).Set(0)
+}
+
+// newLeader records a change of the leader.
+func (m *leaderElectionMetrics) newLeader(identity string) {
+	leaderElectionLeaderChanges.WithLabelValues(m.lock).Inc()
+}
+
+func (m *leaderElectionMetrics) renewed() {
+	leaderElectionLastRenewal.WithLabelValues(m.lock).Set(float64(time.Now().UnixNano()) / float64(time.Second))
+}
+
+// renewalFailed counts a failed access to the lock, if it happened while renewing it as the leader.
+// Candidates fail to acquire the lock all the time, which isn't worth recording.
+func (m *leaderElectionMetrics) renewalFailed() {
+	if atomic.LoadInt32(&m.leading) == 1 {
+		leaderElectionRenewalFailures.WithLabelValues(m.lock).Inc()
+	}
+}
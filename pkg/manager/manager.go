@@ -104,7 +104,10 @@ type Options struct {
 	SyncPeriod *time.Duration
 
 	// LeaderElection determines whether or not to use leader election when
-	// starting the manager.
+	// starting the manager.  With leader election, the manager also exports
+	// the controller_runtime_leader_election_* metrics: whether it is the leader,
+	// how often it acquired the lock, the leader changes it observed, its failed
+	// renewals and the time of its last renewal.
 	LeaderElection bool
 
 	// LeaderElectionNamespace determines the namespace in which the leader
@@ -135,16 +138,46 @@ type Options struct {
 	Namespace string
 
 	// MetricsBindAddress is the TCP address that the controller should bind to
-	// for serving prometheus metrics
+	// for serving prometheus metrics, as host:port.  It can be set to "0" to
+	// disable the metrics serving, which is the default.
+	//
+	// Bind to a loopback address (e.g. "127.0.0.1:8080") to only serve the
+	// metrics inside the Pod, e.g. to a sidecar proxy.  Note that Prometheus
+	// (and anything else outside the Pod) connects to the Pod IP, so it can
+	// only scrape such an address through the sidecar.
 	MetricsBindAddress string
 
+	// ClientRateLimiter, if set, limits the rate of the requests of the Clients of the manager, i.e. of
+	// GetClient, except for its reads from the cache, and of GetAPIReader.  Its limits can be adjusted while
+	// the manager runs with client.RateLimiter.SetLimits, e.g. to throttle the operator during an incident.
+	// The manager doesn't serve it: being an http.Handler, it can be mounted on a server of your own, which
+	// should be restricted to the operators allowed to throttle the manager.  The watches of the cache, the
+	// event recorders and the leader election aren't limited by it.  Defaults to nil, only limiting the
+	// requests with the QPS and Burst of the rest.Config.
+	ClientRateLimiter *client.RateLimiter
+
 	// Port is the port that the webhook server serves at.
 	// It is used to set webhook.Server.Port.
 	Port int
 	// Host is the hostname that the webhook server binds to.
-	// It is used to set webhook.Server.Host.
+	// It is used to set webhook.Server.Host.  Defaults to all interfaces,
+	// as the API server calls webhooks through the Pod IP.
 	Host string
 
+	// GracefulShutdownTimeout is how long Start waits for the Runnables to stop once the stop channel is
+	// closed.  Controllers then wait for their reconciles in flight to return before stopping.  Start returns
+	// an error if the Runnables don't stop in time.  Defaults to 0, returning at once without waiting for the
+	// Runnables.
+	GracefulShutdownTimeout time.Duration
+
+	// ShutdownDiagnostics, if true, logs the Runnables which are still running when GracefulShutdownTimeout
+	// has elapsed, with the Requests which Controllers are still reconciling, to find out what keeps the
+	// Manager from shutting down.
+	ShutdownDiagnostics bool
+
+	// ShutdownGoroutineDump, if true, also logs the stacks of all goroutines with the ShutdownDiagnostics.
+	ShutdownGoroutineDump bool
+
 	// Functions to all for a user to customize the values that will be injected.
 
 	// NewCache is the function that will create the cache to be used
@@ -215,12 +248,13 @@ func New(config *rest.Config, options Options) (Manager, error) {
 		return nil, err
 	}
 
-	apiReader, err := client.New(config, client.Options{Scheme: options.Scheme, Mapper: mapper})
+	clientOptions := client.Options{Scheme: options.Scheme, Mapper: mapper, RateLimiter: options.ClientRateLimiter}
+	apiReader, err := client.New(config, clientOptions)
 	if err != nil {
 		return nil, err
 	}
 
-	writeObj, err := options.NewClient(cache, config, client.Options{Scheme: options.Scheme, Mapper: mapper})
+	writeObj, err := options.NewClient(cache, config, clientOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -270,6 +304,13 @@ func New(config *rest.Config, options Options) (Manager, error) {
 		leaseDuration:    *options.LeaseDuration,
 		renewDeadline:    *options.RenewDeadline,
 		retryPeriod:      *options.RetryPeriod,
+
+		gracefulShutdownTimeout: options.GracefulShutdownTimeout,
+		shutdownDiagnostics:     options.ShutdownDiagnostics,
+		shutdownGoroutineDump:   options.ShutdownGoroutineDump,
+		running:                 map[int]Runnable{},
+		namespace:               options.Namespace,
+		syncPeriod:              options.SyncPeriod,
 	}, nil
 }
 
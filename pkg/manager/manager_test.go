@@ -21,18 +21,25 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-logr/logr"
+	tlog "github.com/go-logr/logr/testing"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/internal/controller"
 	"sigs.k8s.io/controller-runtime/pkg/leaderelection"
 	fakeleaderelection "sigs.k8s.io/controller-runtime/pkg/leaderelection/fake"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -178,6 +185,30 @@ var _ = Describe("manger.Manager", func() {
 			Expect(listener.Close()).ToNot(HaveOccurred())
 		})
 
+		It("should bind the metrics listener to a loopback address if one is provided", func() {
+			var listener net.Listener
+			m, err := New(cfg, Options{
+				MetricsBindAddress: "127.0.0.1:0",
+				newMetricsListener: func(addr string) (net.Listener, error) {
+					var err error
+					listener, err = metrics.NewListener(addr)
+					return listener, err
+				},
+			})
+			Expect(m).ToNot(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(listener).ToNot(BeNil())
+			Expect(listener.Addr().(*net.TCPAddr).IP.IsLoopback()).To(BeTrue())
+			Expect(listener.Close()).ToNot(HaveOccurred())
+		})
+
+		It("should return an error if the metrics bind address is invalid", func() {
+			m, err := New(cfg, Options{MetricsBindAddress: "127.0.0.1"})
+			Expect(m).To(BeNil())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid metrics bind address"))
+		})
+
 		It("should return an error if the metrics bind address is already in use", func() {
 			ln, err := metrics.NewListener(":0")
 			Expect(err).ShouldNot(HaveOccurred())
@@ -290,6 +321,104 @@ var _ = Describe("manger.Manager", func() {
 			})
 		}
 
+		Context("with a GracefulShutdownTimeout", func() {
+			It("should wait for the Components to stop", func(done Done) {
+				m, err := New(cfg, Options{GracefulShutdownTimeout: 5 * time.Second})
+				Expect(err).NotTo(HaveOccurred())
+				started := make(chan struct{})
+				var stopped int32
+				Expect(m.Add(RunnableFunc(func(s <-chan struct{}) error {
+					close(started)
+					<-s
+					time.Sleep(100 * time.Millisecond)
+					atomic.StoreInt32(&stopped, 1)
+					return nil
+				}))).To(Succeed())
+
+				s := make(chan struct{})
+				go func() {
+					<-started
+					close(s)
+				}()
+				Expect(m.Start(s)).To(Succeed())
+				Expect(atomic.LoadInt32(&stopped)).To(Equal(int32(1)))
+
+				close(done)
+			})
+
+			It("should return an error if the Components don't stop in time", func(done Done) {
+				m, err := New(cfg, Options{
+					GracefulShutdownTimeout: 200 * time.Millisecond,
+					ShutdownDiagnostics:     true,
+					ShutdownGoroutineDump:   true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				started := make(chan struct{})
+				stuck := make(chan struct{})
+				defer close(stuck)
+				Expect(m.Add(RunnableFunc(func(<-chan struct{}) error {
+					close(started)
+					<-stuck
+					return nil
+				}))).To(Succeed())
+
+				s := make(chan struct{})
+				go func() {
+					<-started
+					close(s)
+				}()
+				err = m.Start(s)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("1 runnables did not stop within the graceful shutdown timeout"))
+
+				close(done)
+			})
+
+			It("should report the reconciles keeping Controllers from stopping", func(done Done) {
+				m, err := New(cfg, Options{
+					GracefulShutdownTimeout: 200 * time.Millisecond,
+					ShutdownDiagnostics:     true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				reconciling := make(chan struct{})
+				stuck := make(chan struct{})
+				defer close(stuck)
+				ctrl := &controller.Controller{
+					Name:                    "foo-controller",
+					MaxConcurrentReconciles: 1,
+					Do: reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+						close(reconciling)
+						<-stuck
+						return reconcile.Result{}, nil
+					}),
+					Queue:            workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+					WaitForCacheSync: func(<-chan struct{}) bool { return true },
+				}
+				Expect(m.Add(ctrl)).To(Succeed())
+				ctrl.Queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "foo", Name: "bar"}})
+
+				logged := &recordingLogger{}
+				defer func(l logr.Logger) { log = l }(log)
+				log = logged
+
+				s := make(chan struct{})
+				go func() {
+					<-reconciling
+					close(s)
+				}()
+				err = m.Start(s)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("1 runnables did not stop within the graceful shutdown timeout"))
+				Expect(logged.infos).To(ContainElement([]interface{}{
+					"Runnable did not stop within the graceful shutdown timeout",
+					"runnable", "*controller.Controller",
+					"controller", "foo-controller", "reconcilesInFlight", 1, "requests", []string{"foo/bar"},
+				}))
+
+				close(done)
+			})
+		})
+
 		Context("with defaults", func() {
 			startSuite(Options{})
 		})
@@ -631,8 +760,122 @@ var _ = Describe("manger.Manager", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(m.GetAPIReader()).NotTo(BeNil())
 	})
+
+	Describe("DescribeConfig", func() {
+		It("should describe the effective configuration and the Runnables", func() {
+			syncPeriod := time.Hour
+			m, err := New(cfg, Options{Namespace: "ns", SyncPeriod: &syncPeriod, Port: 9443})
+			Expect(err).NotTo(HaveOccurred())
+			m.GetWebhookServer().Register("/validate", http.NotFoundHandler())
+			Expect(m.Add(describedRunnable{})).To(Succeed())
+
+			d := m.(ConfigDescriber).DescribeConfig()
+			Expect(d.Namespace).To(Equal("ns"))
+			Expect(d.SyncPeriod.Duration).To(Equal(time.Hour))
+			Expect(d.LeaderElection).To(BeNil())
+			Expect(d.Started).To(BeFalse())
+			Expect(d.Webhook.Port).To(Equal(9443))
+			Expect(d.Webhook.Paths).To(Equal([]string{"/convert", "/validate"}))
+			Expect(d.Runnables).To(Equal([]RunnableDescription{
+				{Type: "*webhook.Server"},
+				{Type: "manager.describedRunnable", LeaderElection: true, Config: "described"},
+			}))
+			Expect(d.String()).To(ContainSubstring(`"config": "described"`))
+		})
+
+		It("should describe the leader election", func() {
+			m, err := New(cfg, Options{
+				LeaderElection:          true,
+				LeaderElectionNamespace: "default",
+				LeaderElectionID:        "test-lock",
+				newResourceLock:         fakeleaderelection.NewResourceLock,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			d := m.(ConfigDescriber).DescribeConfig()
+			Expect(d.LeaderElection).NotTo(BeNil())
+			Expect(d.LeaderElection.LeaseDuration.Duration).To(Equal(defaultLeaseDuration))
+		})
+	})
 })
 
+type describedRunnable struct{}
+
+func (describedRunnable) Start(<-chan struct{}) error { return nil }
+
+func (describedRunnable) DescribeConfig() interface{} { return "described" }
+
+var _ = Describe("leader election metrics", func() {
+	var lock *failingLock
+	var m *leaderElectionMetrics
+
+	counter := func(c *prometheus.CounterVec) float64 {
+		var metric dto.Metric
+		Expect(c.WithLabelValues(m.lock).Write(&metric)).To(Succeed())
+		return metric.GetCounter().GetValue()
+	}
+	gauge := func(g *prometheus.GaugeVec) float64 {
+		var metric dto.Metric
+		Expect(g.WithLabelValues(m.lock).Write(&metric)).To(Succeed())
+		return metric.GetGauge().GetValue()
+	}
+
+	BeforeEach(func() {
+		rl, err := fakeleaderelection.NewResourceLock(nil, nil, leaderelection.Options{})
+		Expect(err).NotTo(HaveOccurred())
+		lock = &failingLock{Interface: rl}
+		m = newLeaderElectionMetrics(lock)
+	})
+
+	It("should record acquisitions and renewals of the leader", func() {
+		Expect(gauge(leaderElectionIsLeader)).To(Equal(0.0))
+		m.startedLeading()
+		Expect(gauge(leaderElectionIsLeader)).To(Equal(1.0))
+		Expect(counter(leaderElectionAcquisitions)).To(Equal(1.0))
+
+		leaderElectionLastRenewal.WithLabelValues(m.lock).Set(0)
+		Expect(m.Update(resourcelock.LeaderElectionRecord{})).To(Succeed())
+		Expect(gauge(leaderElectionLastRenewal)).To(BeNumerically("~", float64(time.Now().Unix()), 5))
+
+		m.stoppedLeading()
+		Expect(gauge(leaderElectionIsLeader)).To(Equal(0.0))
+	})
+
+	It("should only count failed renewals of the leader", func() {
+		lock.fail = true
+		By("failing to acquire the lock as a candidate")
+		Expect(m.Update(resourcelock.LeaderElectionRecord{})).NotTo(Succeed())
+		Expect(counter(leaderElectionRenewalFailures)).To(Equal(0.0))
+
+		By("failing to renew the lock as the leader")
+		m.startedLeading()
+		Expect(m.Update(resourcelock.LeaderElectionRecord{})).NotTo(Succeed())
+		_, err := m.Get()
+		Expect(err).To(HaveOccurred())
+		Expect(counter(leaderElectionRenewalFailures)).To(Equal(2.0))
+	})
+})
+
+// failingLock fails to access the lock if fail is set.
+type failingLock struct {
+	resourcelock.Interface
+	fail bool
+}
+
+func (l *failingLock) Get() (*resourcelock.LeaderElectionRecord, error) {
+	if l.fail {
+		return nil, fmt.Errorf("expected error")
+	}
+	return l.Interface.Get()
+}
+
+func (l *failingLock) Update(ler resourcelock.LeaderElectionRecord) error {
+	if l.fail {
+		return fmt.Errorf("expected error")
+	}
+	return l.Interface.Update(ler)
+}
+
 var _ reconcile.Reconciler = &failRec{}
 var _ inject.Client = &failRec{}
 
@@ -711,3 +954,21 @@ func (i *injectable) InjectStopChannel(stop <-chan struct{}) error {
 func (i *injectable) Start(<-chan struct{}) error {
 	return nil
 }
+
+// recordingLogger records the messages logged with Info, each followed by its key/value pairs.
+type recordingLogger struct {
+	tlog.NullLogger
+	infos [][]interface{}
+}
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.infos = append(l.infos, append([]interface{}{msg}, keysAndValues...))
+}
+
+func (l *recordingLogger) WithName(string) logr.Logger {
+	return l
+}
+
+func (l *recordingLogger) WithValues(...interface{}) logr.Logger {
+	return l
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/pprof"
+	"sort"
+	"time"
+)
+
+// shutdownPollInterval is how often waitForRunnables checks whether the Runnables have stopped.
+const shutdownPollInterval = 100 * time.Millisecond
+
+// shutdownDiagnoser is implemented by Runnables which can describe what they are still doing, such as the
+// reconciles in flight of a Controller.
+type shutdownDiagnoser interface {
+	// ShutdownDiagnostics returns key/value pairs to log if the Runnable takes too long to stop.
+	ShutdownDiagnostics() []interface{}
+}
+
+// workerWaiter is implemented by Runnables which can wait for their workers to return before returning
+// from Start, such as Controllers, so that the work keeping them from stopping can be diagnosed.
+type workerWaiter interface {
+	// WaitForWorkers makes Start wait for the workers of the Runnable once stopped.
+	WaitForWorkers()
+}
+
+// startRunnable starts r, tracking it until it returns.
+func (cm *controllerManager) startRunnable(r Runnable) {
+	cm.runningMu.Lock()
+	cm.runnablesStarted++
+	id := cm.runnablesStarted
+	cm.running[id] = r
+	cm.runningMu.Unlock()
+
+	if w, ok := r.(workerWaiter); ok && cm.gracefulShutdownTimeout > 0 {
+		w.WaitForWorkers()
+	}
+
+	go func() {
+		err := r.Start(cm.internalStop)
+
+		cm.runningMu.Lock()
+		delete(cm.running, id)
+		cm.runningMu.Unlock()
+
+		cm.errChan <- err
+	}()
+}
+
+// stopRunnables closes the internal stop channel, stopping the Runnables.
+func (cm *controllerManager) stopRunnables() {
+	cm.stopOnce.Do(func() { close(cm.internalStopper) })
+}
+
+// waitForRunnables stops the Runnables and waits up to the graceful shutdown timeout for them to return.
+func (cm *controllerManager) waitForRunnables() error {
+	if cm.gracefulShutdownTimeout <= 0 {
+		return nil
+	}
+	cm.stopRunnables()
+
+	deadline := time.Now().Add(cm.gracefulShutdownTimeout)
+	for {
+		stillRunning := cm.stillRunning()
+		if len(stillRunning) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			if cm.shutdownDiagnostics {
+				cm.logShutdownDiagnostics(stillRunning)
+			}
+			return fmt.Errorf("%d runnables did not stop within the graceful shutdown timeout of %v",
+				len(stillRunning), cm.gracefulShutdownTimeout)
+		}
+		time.Sleep(shutdownPollInterval)
+	}
+}
+
+// stillRunning returns the Runnables which haven't returned yet, in start order.
+func (cm *controllerManager) stillRunning() []Runnable {
+	cm.runningMu.Lock()
+	defer cm.runningMu.Unlock()
+	ids := make([]int, 0, len(cm.running))
+	for id := range cm.running {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	runnables := make([]Runnable, 0, len(ids))
+	for _, id := range ids {
+		runnables = append(runnables, cm.running[id])
+	}
+	return runnables
+}
+
+// logShutdownDiagnostics logs what keeps the given Runnables from stopping.
+func (cm *controllerManager) logShutdownDiagnostics(stillRunning []Runnable) {
+	for _, r := range stillRunning {
+		keysAndValues := []interface{}{"runnable", fmt.Sprintf("%T", r)}
+		if d, ok := r.(shutdownDiagnoser); ok {
+			keysAndValues = append(keysAndValues, d.ShutdownDiagnostics()...)
+		}
+		log.Info("Runnable did not stop within the graceful shutdown timeout", keysAndValues...)
+	}
+
+	if cm.shutdownGoroutineDump {
+		var stacks bytes.Buffer
+		if err := pprof.Lookup("goroutine").WriteTo(&stacks, 2); err != nil {
+			log.Error(err, "Failed to dump the goroutine stacks")
+			return
+		}
+		log.Info("Goroutine stacks at the graceful shutdown timeout", "stacks", stacks.String())
+	}
+}
@@ -27,7 +27,8 @@ import (
 // TODO: Flip the default by changing DefaultBindAddress back to ":8080" in the v0.2.0.
 var DefaultBindAddress = "0"
 
-// NewListener creates a new TCP listener bound to the given address.
+// NewListener creates a new TCP listener bound to the given address.  Use a loopback address
+// (e.g. "127.0.0.1:8080") to only serve the metrics to the Pod itself, such as to a sidecar.
 func NewListener(addr string) (net.Listener, error) {
 	if addr == "" {
 		// If the metrics bind address is empty, default to ":8080"
@@ -39,6 +40,10 @@ func NewListener(addr string) (net.Listener, error) {
 		return nil, nil
 	}
 
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid metrics bind address %q, must be host:port or \"0\": %v", addr, err)
+	}
+
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, fmt.Errorf("error listening on %s: %v", addr, err)
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Recorder receives the core reconcile metrics of all controllers, independently of the Prometheus client
+// library, e.g. to export them through an OpenTelemetry meter.  The metrics are recorded by the Prometheus
+// collectors in Registry either way.
+type Recorder interface {
+	// RecordReconcile is called for every finished reconcile of the named controller, with its result:
+	// success, error, requeue, requeue_after, cancelled or invalid_key.
+	RecordReconcile(controller, result string)
+
+	// RecordReconcileTime is called with the duration of every reconcile of the named controller.
+	RecordReconcileTime(controller string, duration time.Duration)
+
+	// RecordReconcilePaused is called whenever the workers of the named controller are checked for being
+	// paused by a failing health check.
+	RecordReconcilePaused(controller string, paused bool)
+}
+
+// QueueWaitRecorder is implemented by Recorders which also receive how long Requests waited in the queue
+// from the event enqueueing them until their reconcile started.
+type QueueWaitRecorder interface {
+	// RecordReconcileQueueWait is called with the queue wait of every reconcile of the named controller
+	// of a Request enqueued by an event.
+	RecordReconcileQueueWait(controller string, wait time.Duration)
+}
+
+// Options configure the metrics of controller-runtime.
+type Options struct {
+	// Recorder, if set, receives the core reconcile metrics in addition to the Prometheus collectors.
+	// Defaults to nil.
+	Recorder Recorder
+}
+
+var (
+	mu      sync.RWMutex
+	options Options
+)
+
+// SetOptions configures the metrics of controller-runtime.  It should be called before any controller is
+// started, since metrics recorded before aren't replayed.
+func SetOptions(opts Options) {
+	mu.Lock()
+	defer mu.Unlock()
+	options = opts
+}
+
+// ConfiguredRecorder returns the Recorder set with SetOptions, or nil.
+func ConfiguredRecorder() Recorder {
+	mu.RLock()
+	defer mu.RUnlock()
+	return options.Recorder
+}
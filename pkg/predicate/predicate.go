@@ -17,6 +17,10 @@ limitations under the License.
 package predicate
 
 import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	logf "sigs.k8s.io/controller-runtime/pkg/internal/log"
 )
@@ -40,6 +44,7 @@ type Predicate interface {
 
 var _ Predicate = Funcs{}
 var _ Predicate = ResourceVersionChangedPredicate{}
+var _ Predicate = &MinAgePredicate{}
 
 // Funcs is a function that implements Predicate.
 type Funcs struct {
@@ -116,3 +121,136 @@ func (ResourceVersionChangedPredicate) Update(e event.UpdateEvent) bool {
 	}
 	return true
 }
+
+// MinAgePredicate filters out events for objects which are younger than a minimum age, e.g. to leave other
+// controllers time to finish setting them up.  Delete events are never filtered out.
+//
+// The events which are filtered out are delivered again as GenericEvents on Requeue once their object is old
+// enough, so that they aren't lost.  Watch Requeue with a source.Channel, the same EventHandler and the
+// predicate:
+//
+//	p := predicate.MinAge(30 * time.Second)
+//	c.Watch(&source.Kind{Type: &v1.Pod{}}, &handler.EnqueueRequestForObject{}, p)
+//	c.Watch(&source.Channel{Source: p.Requeue()}, &handler.EnqueueRequestForObject{}, p)
+//
+// Once the stop channel injected by the manager is closed, the events waiting to be requeued are dropped.
+type MinAgePredicate struct {
+	minAge  time.Duration
+	requeue chan event.GenericEvent
+
+	mu sync.Mutex
+	// pending holds the latest filtered out event of each object which is waiting to be requeued.
+	pending map[types.NamespacedName]*pendingEvent
+	// stop is closed once the manager stops, and stopped is set then.
+	stop    <-chan struct{}
+	stopped bool
+}
+
+// pendingEvent is an event waiting to be requeued by its timer.
+type pendingEvent struct {
+	event event.GenericEvent
+	timer *time.Timer
+}
+
+// MinAge returns a MinAgePredicate which filters out events for objects whose creationTimestamp is less
+// than minAge ago.
+func MinAge(minAge time.Duration) *MinAgePredicate {
+	return &MinAgePredicate{
+		minAge:  minAge,
+		requeue: make(chan event.GenericEvent),
+		pending: map[types.NamespacedName]*pendingEvent{},
+	}
+}
+
+// InjectStopChannel is internal should be called only by the Controller.
+// It drops the events waiting to be requeued once stop is closed, so that their timers don't block forever
+// on a Requeue channel which isn't read anymore.
+func (p *MinAgePredicate) InjectStopChannel(stop <-chan struct{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		// The predicate is shared by several watches.
+		return nil
+	}
+	p.stop = stop
+	go func() {
+		<-stop
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.stopped = true
+		for key, pending := range p.pending {
+			pending.timer.Stop()
+			delete(p.pending, key)
+		}
+	}()
+	return nil
+}
+
+// Requeue returns the channel on which filtered out events are delivered again once their object is old
+// enough.
+func (p *MinAgePredicate) Requeue() <-chan event.GenericEvent {
+	return p.requeue
+}
+
+// Create implements Predicate
+func (p *MinAgePredicate) Create(e event.CreateEvent) bool {
+	return p.oldEnough(event.GenericEvent{Meta: e.Meta, Object: e.Object})
+}
+
+// Delete implements Predicate
+func (p *MinAgePredicate) Delete(event.DeleteEvent) bool {
+	return true
+}
+
+// Update implements Predicate
+func (p *MinAgePredicate) Update(e event.UpdateEvent) bool {
+	return p.oldEnough(event.GenericEvent{Meta: e.MetaNew, Object: e.ObjectNew})
+}
+
+// Generic implements Predicate
+func (p *MinAgePredicate) Generic(e event.GenericEvent) bool {
+	return p.oldEnough(e)
+}
+
+// oldEnough returns true if the object of e is at least minAge old, and otherwise schedules e to be
+// requeued once it is.
+func (p *MinAgePredicate) oldEnough(e event.GenericEvent) bool {
+	if e.Meta == nil {
+		log.Error(nil, "Event has no metadata", "event", e)
+		return false
+	}
+	created := e.Meta.GetCreationTimestamp()
+	if created.IsZero() {
+		return true
+	}
+	remaining := p.minAge - time.Since(created.Time)
+	if remaining <= 0 {
+		return true
+	}
+
+	key := types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return false
+	}
+	if pending, found := p.pending[key]; found {
+		// Requeue the latest version of the object.
+		pending.event = e
+		return false
+	}
+	pending := &pendingEvent{event: e}
+	p.pending[key] = pending
+	stop := p.stop
+	pending.timer = time.AfterFunc(remaining, func() {
+		p.mu.Lock()
+		delete(p.pending, key)
+		e := pending.event
+		p.mu.Unlock()
+		select {
+		case p.requeue <- e:
+		case <-stop:
+		}
+	})
+	return false
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package predicate_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -308,4 +310,48 @@ var _ = Describe("Predicate", func() {
 		})
 
 	})
+
+	Describe("When checking a MinAgePredicate", func() {
+		var instance *predicate.MinAgePredicate
+		BeforeEach(func() {
+			instance = predicate.MinAge(100 * time.Millisecond)
+		})
+
+		It("should return true for objects which are old enough", func() {
+			pod.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+			Expect(instance.Create(event.CreateEvent{Meta: pod.GetObjectMeta(), Object: pod})).To(BeTrue())
+			Expect(instance.Update(event.UpdateEvent{MetaNew: pod.GetObjectMeta(), ObjectNew: pod})).To(BeTrue())
+			Expect(instance.Generic(event.GenericEvent{Meta: pod.GetObjectMeta(), Object: pod})).To(BeTrue())
+		})
+
+		It("should always return true for Delete events", func() {
+			pod.CreationTimestamp = metav1.Now()
+			Expect(instance.Delete(event.DeleteEvent{Meta: pod.GetObjectMeta(), Object: pod})).To(BeTrue())
+		})
+
+		It("should requeue events of young objects once they are old enough", func() {
+			pod.CreationTimestamp = metav1.Now()
+			Expect(instance.Create(event.CreateEvent{Meta: pod.GetObjectMeta(), Object: pod})).To(BeFalse())
+
+			updated := pod.DeepCopy()
+			updated.ResourceVersion = "2"
+			Expect(instance.Update(event.UpdateEvent{MetaNew: updated.GetObjectMeta(), ObjectNew: updated})).To(BeFalse())
+
+			var evt event.GenericEvent
+			Eventually(instance.Requeue()).Should(Receive(&evt))
+			Expect(evt.Object).To(Equal(updated))
+			Expect(instance.Generic(evt)).To(BeTrue())
+			Consistently(instance.Requeue(), 200*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should drop the events waiting to be requeued once stopped", func() {
+			stop := make(chan struct{})
+			Expect(instance.InjectStopChannel(stop)).To(Succeed())
+			pod.CreationTimestamp = metav1.Now()
+			Expect(instance.Create(event.CreateEvent{Meta: pod.GetObjectMeta(), Object: pod})).To(BeFalse())
+
+			close(stop)
+			Consistently(instance.Requeue(), 200*time.Millisecond).ShouldNot(Receive())
+		})
+	})
 })
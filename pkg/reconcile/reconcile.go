@@ -17,9 +17,13 @@ limitations under the License.
 package reconcile
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Result contains the result of a Reconciler invocation.
@@ -30,6 +34,49 @@ type Result struct {
 	// RequeueAfter if greater than 0, tells the Controller to requeue the reconcile key after the Duration.
 	// Implies that Requeue is true, there is no need to set Requeue to true at the same time as RequeueAfter.
 	RequeueAfter time.Duration
+
+	// RequeueReason, if set, tells why the reconcile key is requeued, e.g. "waiting-for-dependency".  The
+	// Controller logs it and counts the requeue under it in the controller_runtime_reconcile_requeue_total
+	// metric, if it is one of the requeue reasons the Controller has been configured with, and under "other"
+	// otherwise.  It has no effect if the key isn't requeued.
+	RequeueReason string
+
+	// Progression, if set, tells the Controller to requeue the reconcile key after a delay growing with
+	// every consecutive Result of the same Progression, e.g. to poll an external system with backoff.  It
+	// takes precedence over Requeue and RequeueAfter.  See ProgressiveRequeue.
+	Progression *Progression
+}
+
+// Progression describes the growing delays of the requeues of a ProgressiveRequeue.
+type Progression struct {
+	// Key identifies the Progression among the ones of the reconcile key.  A Result with another Key
+	// starts over from Base.
+	Key string
+
+	// Base is the delay of the first requeue.  Defaults to 1 second.
+	Base time.Duration
+
+	// Factor multiplies the delay of every further requeue.  Defaults to 2, if less than 1.
+	Factor float64
+
+	// Max, if positive, caps the delay.
+	Max time.Duration
+}
+
+// ProgressiveRequeue returns a Result requeueing the reconcile key after base the first time, and after
+// factor times the previous delay, up to max, every consecutive time it is returned for the same key.  The
+// Controller tracks the progression, which starts over once a reconcile of the key returns without an error
+// nor a Progression, or once its object is updated, if the Controller has been given the For type of its
+// objects.  Requeues for other reasons, e.g. events of owned objects, don't reset it.  The delay is further
+// capped by the MaxProgressiveRequeueAfter of the Controller.  It has no effect on batches.
+func ProgressiveRequeue(key string, base time.Duration, factor float64, max time.Duration) Result {
+	return Result{Progression: &Progression{Key: key, Base: base, Factor: factor, Max: max}}
+}
+
+// RequeueAfterWithReason returns a Result requeueing the reconcile key after the Duration for the given
+// reason.
+func RequeueAfterWithReason(after time.Duration, reason string) Result {
+	return Result{RequeueAfter: after, RequeueReason: reason}
 }
 
 // Request contains the information necessary to reconcile a Kubernetes object.  This includes the
@@ -91,3 +138,285 @@ var _ Reconciler = Func(nil)
 
 // Reconcile implements Reconciler.
 func (r Func) Reconcile(o Request) (Result, error) { return r(o) }
+
+// ContextReconciler is a Reconciler which also accepts a context.  Controllers will call ReconcileContext
+// instead of Reconcile for Reconcilers implementing this interface.  The context may be cancelled by the
+// Controller (e.g. if the Controller has been configured to abandon reconciles of stale objects), so
+// implementations performing long-running work should respect ctx.Done().
+type ContextReconciler interface {
+	Reconciler
+
+	// ReconcileContext performs a full reconciliation for the object referred to by the Request, in the same
+	// way as Reconcile.  It should return early once ctx is done.
+	ReconcileContext(ctx context.Context, req Request) (Result, error)
+}
+
+// ContextFunc is a function that implements the ContextReconciler interface.
+type ContextFunc func(context.Context, Request) (Result, error)
+
+var _ ContextReconciler = ContextFunc(nil)
+
+// Reconcile implements Reconciler.  It calls the function with a background context.
+func (r ContextFunc) Reconcile(o Request) (Result, error) { return r(context.Background(), o) }
+
+// ReconcileContext implements ContextReconciler.
+func (r ContextFunc) ReconcileContext(ctx context.Context, o Request) (Result, error) { return r(ctx, o) }
+
+// BatchRequest contains the information necessary to reconcile a group of related Kubernetes objects in a
+// single call.  All Requests in a BatchRequest share the same batch key.
+type BatchRequest struct {
+	// Key is the batch key shared by all Requests in the batch.
+	Key string
+
+	// Requests are the objects to reconcile.  Each Request appears at most once, and Requests are ordered
+	// by Namespace and then by Name.
+	Requests []Request
+}
+
+// BatchReconciler is a Reconciler which can reconcile a group of related objects at once.  Controllers
+// configured with a batch key function will call ReconcileBatch with all Requests enqueued under the
+// same batch key instead of calling Reconcile once per Request.
+type BatchReconciler interface {
+	Reconciler
+
+	// ReconcileBatch performs a full reconciliation for all objects referred to by the BatchRequest.
+	// The Result and error apply to the batch as a whole: if the batch is requeued, all of its
+	// Requests are delivered again, together with any Requests enqueued under the same key meanwhile.
+	ReconcileBatch(BatchRequest) (Result, error)
+}
+
+// enqueueAllKey is the context key of the function enqueueing all objects of the Controller.
+type enqueueAllKey struct{}
+
+// WithEnqueueAll returns a copy of ctx carrying the function which enqueues a Request for every object
+// reconciled by the Controller.  Controllers call this before passing ctx to a ContextReconciler.
+func WithEnqueueAll(ctx context.Context, enqueueAll func() error) context.Context {
+	return context.WithValue(ctx, enqueueAllKey{}, enqueueAll)
+}
+
+// EnqueueAllFromContext returns the function which enqueues a Request for every object reconciled by the
+// Controller calling ReconcileContext, e.g. to reconcile all objects again after a change to some global
+// configuration.  It returns nil if the Controller doesn't know the type of the objects it reconciles.
+func EnqueueAllFromContext(ctx context.Context) func() error {
+	enqueueAll, _ := ctx.Value(enqueueAllKey{}).(func() error)
+	return enqueueAll
+}
+
+// Verbs of the event which triggered a reconcile.
+const (
+	TriggerCreate  = "create"
+	TriggerUpdate  = "update"
+	TriggerDelete  = "delete"
+	TriggerGeneric = "generic"
+
+	// TriggerUnknown is the verb of reconciles whose Request wasn't enqueued by a single event, e.g.
+	// because several events were coalesced into one Request while it was waiting, or because it was
+	// requeued after a failure, a Result asking for a requeue or EnqueueAll.
+	TriggerUnknown = "unknown"
+)
+
+// TriggerEvent describes the event which enqueued the Request being reconciled.  Note that the event is
+// about the object watched, which is not the reconciled object if the EventHandler maps it to another one,
+// e.g. to its owner.
+type TriggerEvent struct {
+	// Verb is one of TriggerCreate, TriggerUpdate, TriggerDelete, TriggerGeneric or TriggerUnknown.
+	Verb string
+
+	// OldResourceVersion is the resourceVersion of the object before an update.
+	OldResourceVersion string
+
+	// ChangedFields are the top-level fields of the object changed by an update, e.g. "spec" or
+	// "status", in alphabetical order.  Changes of the resourceVersion alone don't count as a change
+	// of "metadata".
+	ChangedFields []string
+}
+
+// triggerEventKey is the context key of the TriggerEvent of a reconcile.
+type triggerEventKey struct{}
+
+// WithTriggerEvent returns a copy of ctx carrying the event which enqueued the Request.  Controllers call
+// this before passing ctx to a ContextReconciler.
+func WithTriggerEvent(ctx context.Context, evt TriggerEvent) context.Context {
+	return context.WithValue(ctx, triggerEventKey{}, evt)
+}
+
+// TriggerEventFromContext returns the event which enqueued the Request passed to ReconcileContext, if the
+// Controller has been created with controller.Options.RecordTriggerEvents.
+//
+// The event is only a hint: its Verb is TriggerUnknown whenever the Request wasn't enqueued by a single
+// event, and reconcilers must still reconcile the whole state of the object rather than only the fields
+// which changed.  The second return value is false if the Controller doesn't record events at all.
+func TriggerEventFromContext(ctx context.Context) (TriggerEvent, bool) {
+	evt, ok := ctx.Value(triggerEventKey{}).(TriggerEvent)
+	return evt, ok
+}
+
+// clientKey is the context key of the Client of a reconcile.
+type clientKey struct{}
+
+// WithClient returns a copy of ctx carrying the Client to use for the reconcile.  Controllers call this
+// before passing ctx to a ContextReconciler.
+func WithClient(ctx context.Context, c client.Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// ClientFromContext returns the Client to use for the reconcile passed ctx, or fallback if the Controller
+// doesn't provide one, e.g.
+//
+//	c := reconcile.ClientFromContext(ctx, r.client)
+//
+// Controllers created with controller.Options.ReadYourWrites provide a client.NewOverlayClient for every
+// reconcile, whose reads see the writes made through it during the same reconcile.
+func ClientFromContext(ctx context.Context, fallback client.Client) client.Client {
+	if c, ok := ctx.Value(clientKey{}).(client.Client); ok {
+		return c
+	}
+	return fallback
+}
+
+// statusWriterKey is the context key of the StatusWriter of a reconcile.
+type statusWriterKey struct{}
+
+// WithStatusWriter returns a copy of ctx carrying the StatusWriter to write the status of objects with during
+// the reconcile.  Controllers call this before passing ctx to a ContextReconciler.
+func WithStatusWriter(ctx context.Context, w client.StatusWriter) context.Context {
+	return context.WithValue(ctx, statusWriterKey{}, w)
+}
+
+// StatusWriterFromContext returns the StatusWriter to use for the reconcile passed ctx, or fallback if the
+// Controller doesn't provide one, e.g.
+//
+//	err := reconcile.StatusWriterFromContext(ctx, r.client.Status()).Update(ctx, obj)
+//
+// Controllers created with controller.Options.StatusFlushInterval provide a client.BatchingStatusWriter,
+// which defers the updates and coalesces those of the same object.
+func StatusWriterFromContext(ctx context.Context, fallback client.StatusWriter) client.StatusWriter {
+	if w, ok := ctx.Value(statusWriterKey{}).(client.StatusWriter); ok {
+		return w
+	}
+	return fallback
+}
+
+// SavePoint persists the progress of a reconcile which was cancelled by the shutdown of its Controller, e.g.
+// by updating a status field, so that the reconcile can resume from there after a restart instead of
+// redoing its work.  ctx isn't cancelled, but the Manager only waits for the save-points as long as its
+// GracefulShutdownTimeout, so they should be quick.
+type SavePoint func(ctx context.Context) error
+
+// SavePoints are the save-points registered during a reconcile.
+type SavePoints struct {
+	mu         sync.Mutex
+	savePoints []SavePoint
+}
+
+// Run calls the save-points in the order they were registered, and returns their errors.
+func (sp *SavePoints) Run(ctx context.Context) error {
+	sp.mu.Lock()
+	savePoints := append([]SavePoint(nil), sp.savePoints...)
+	sp.mu.Unlock()
+	var errs []error
+	for _, savePoint := range savePoints {
+		if err := savePoint(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Len returns how many save-points are registered.
+func (sp *SavePoints) Len() int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return len(sp.savePoints)
+}
+
+// savePointsKey is the context key of the SavePoints of a reconcile.
+type savePointsKey struct{}
+
+// WithSavePoints returns a copy of ctx in which RegisterSavePoint registers save-points into sp.  Controllers
+// call this before passing ctx to a ContextReconciler.
+func WithSavePoints(ctx context.Context, sp *SavePoints) context.Context {
+	return context.WithValue(ctx, savePointsKey{}, sp)
+}
+
+// RegisterSavePoint registers savePoint to be called if the reconcile passed ctx is cancelled because its
+// Controller shuts down, once the Reconciler has returned, e.g.
+//
+//	reconcile.RegisterSavePoint(ctx, func(ctx context.Context) error {
+//		obj.Status.Progress = progress
+//		return r.client.Status().Update(ctx, obj)
+//	})
+//
+// Save-points registered by reconciles which aren't cancelled by the shutdown are dropped once they
+// return.  It returns false, without registering savePoint, if the Controller doesn't cancel reconciles on
+// shutdown, i.e. wasn't created with controller.Options.CancelOnShutdown.
+func RegisterSavePoint(ctx context.Context, savePoint SavePoint) bool {
+	sp, ok := ctx.Value(savePointsKey{}).(*SavePoints)
+	if !ok {
+		return false
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.savePoints = append(sp.savePoints, savePoint)
+	return true
+}
+
+// KeyParser parses the name of the object of a Request into a key of some type, e.g. for objects named
+// "tenant--resource" into a struct with a Tenant and a Resource field.  An error means that the Request can
+// never be reconciled, so it isn't retried.
+type KeyParser func(Request) (interface{}, error)
+
+// keyKey is the context key of the parsed key of a reconcile.
+type keyKey struct{}
+
+// WithKey returns a copy of ctx carrying the key parsed from the Request.  Controllers call this before
+// passing ctx to a ContextReconciler.
+func WithKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// KeyFromContext returns the key parsed from the Request passed to ReconcileContext by the KeyParser of the
+// Controller, or its NamespacedName if the Controller has no KeyParser, e.g.
+//
+//	key := reconcile.KeyFromContext(ctx).(TenantKey)
+//
+// It returns nil if ctx wasn't passed by a Controller.
+func KeyFromContext(ctx context.Context) interface{} {
+	return ctx.Value(keyKey{})
+}
+
+// correlationIDKey is the context key of the correlation ID of a reconcile.
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the ID identifying the reconcile.  Controllers call this
+// before passing ctx to a ContextReconciler.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFromContext returns the ID identifying the reconcile passed ctx, e.g. to correlate the logs of
+// a Reconciler with its audit records.  Controllers created with controller.Options.AuditSink give every
+// reconcile a random ID, which is also the CorrelationID of its audit.Record.  It returns "" otherwise.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// enqueueTimeKey is the context key of the time the Request of a reconcile was enqueued.
+type enqueueTimeKey struct{}
+
+// WithEnqueueTime returns a copy of ctx carrying the time the Request of the reconcile was enqueued.
+// Controllers call this before passing ctx to a ContextReconciler.
+func WithEnqueueTime(ctx context.Context, enqueued time.Time) context.Context {
+	return context.WithValue(ctx, enqueueTimeKey{}, enqueued)
+}
+
+// EnqueueTimeFromContext returns the time the Request of the reconcile passed ctx was enqueued by an
+// EventHandler in response to an event, e.g. to start a trace span covering the time it waited in the
+// queue.  Requests enqueued by several events meanwhile carry the time of the first one.  It returns false
+// for Requests which were only requeued, e.g. after an error, and for controllers which weren't created
+// with controller.Options.RecordQueueWait.
+func EnqueueTimeFromContext(ctx context.Context) (time.Time, bool) {
+	enqueued, ok := ctx.Value(enqueueTimeKey{}).(time.Time)
+	return enqueued, ok
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package reconcile_test
 
 import (
+	"context"
 	"fmt"
 
 	. "github.com/onsi/ginkgo"
@@ -66,4 +67,63 @@ var _ = Describe("reconcile", func() {
 			Expect(actualErr).To(Equal(err))
 		})
 	})
+
+	Describe("ContextFunc", func() {
+		It("should call the function with the context and request.", func() {
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "foo", Namespace: "bar"},
+			}
+			result := reconcile.Result{
+				Requeue: true,
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			instance := reconcile.ContextFunc(func(c context.Context, r reconcile.Request) (reconcile.Result, error) {
+				defer GinkgoRecover()
+				Expect(c).To(Equal(ctx))
+				Expect(r).To(Equal(request))
+
+				return result, nil
+			})
+			actualResult, actualErr := instance.ReconcileContext(ctx, request)
+			Expect(actualResult).To(Equal(result))
+			Expect(actualErr).NotTo(HaveOccurred())
+		})
+
+		It("should call the function with a background context from Reconcile.", func() {
+			request := reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "foo", Namespace: "bar"},
+			}
+
+			instance := reconcile.ContextFunc(func(c context.Context, r reconcile.Request) (reconcile.Result, error) {
+				defer GinkgoRecover()
+				Expect(c).To(Equal(context.Background()))
+				Expect(r).To(Equal(request))
+
+				return reconcile.Result{}, nil
+			})
+			_, actualErr := instance.Reconcile(request)
+			Expect(actualErr).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("EnqueueAllFromContext", func() {
+		It("should return the function stored in the context.", func() {
+			called := false
+			ctx := reconcile.WithEnqueueAll(context.Background(), func() error {
+				called = true
+				return nil
+			})
+
+			enqueueAll := reconcile.EnqueueAllFromContext(ctx)
+			Expect(enqueueAll).NotTo(BeNil())
+			Expect(enqueueAll()).To(Succeed())
+			Expect(called).To(BeTrue())
+		})
+
+		It("should return nil if the context carries no function.", func() {
+			Expect(reconcile.EnqueueAllFromContext(context.Background())).To(BeNil())
+		})
+	})
 })
@@ -0,0 +1,359 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/source/internal"
+)
+
+const (
+	// defaultDynamicResyncPeriod is how often a DynamicWatcher re-queries discovery by default.
+	defaultDynamicResyncPeriod = 30 * time.Second
+)
+
+var _ Source = &DynamicWatcher{}
+
+// DynamicWatcher is used to provide a source of events for every resource type served by the API server
+// which matches a discovery query (e.g. all resources of an API group, or all CRDs carrying a category).
+//
+// DynamicWatcher periodically re-runs the discovery query and starts watching newly served resource types
+// as they appear (e.g. when a CRD is installed).  Objects are delivered as *unstructured.Unstructured.
+//
+// When a resource type is no longer served, its informer is stopped if the Cache implements
+// cache.InformerStopper, as the Caches of cache.New do, and a new one is started if the resource type is
+// served again.  Informers of other Caches can not be stopped, so they are kept, but their events are
+// dropped until the resource type is served again.  Meanwhile they keep failing to list the resource type,
+// retrying after about a second every time.
+type DynamicWatcher struct {
+	// Predicate selects the resource types to watch from the server's preferred resources.
+	// Only resource types which support list and watch are considered.  Subresources are never watched.
+	Predicate discovery.ResourcePredicate
+
+	// Discovery is used to query the resource types served by the API server.
+	// Defaults to a discovery client built from the injected rest.Config.
+	Discovery discovery.DiscoveryInterface
+
+	// ResyncPeriod is how often discovery is queried for added or removed resource types.
+	// Defaults to 30 seconds if not specified.
+	ResyncPeriod time.Duration
+
+	// cache used to watch APIs
+	cache cache.Cache
+
+	// config is used to build the discovery client if Discovery is not set
+	config *rest.Config
+
+	// stop ends the discovery loop
+	stop <-chan struct{}
+
+	// mu guards watches and handlers.  It is never held while waiting for informers.
+	mu sync.Mutex
+
+	// watches are the resource types that have been watched so far
+	watches map[schema.GroupVersionKind]*dynamicWatch
+
+	// handlers are the event handlers added by Start, registered on every watched resource type
+	handlers []internal.EventHandler
+
+	// started is set by the first Start, which starts the discovery loop
+	started bool
+}
+
+// dynamicWatch is a single resource type watched by a DynamicWatcher.
+type dynamicWatch struct {
+	informer cache.Informer
+
+	mu     sync.RWMutex
+	active bool
+}
+
+func (w *dynamicWatch) setActive(active bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.active = active
+}
+
+func (w *dynamicWatch) isActive() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.active
+}
+
+// gatedEventHandler only forwards events while its watch is active.
+type gatedEventHandler struct {
+	watch   *dynamicWatch
+	handler toolscache.ResourceEventHandler
+}
+
+func (g gatedEventHandler) OnAdd(obj interface{}) {
+	if g.watch.isActive() {
+		g.handler.OnAdd(obj)
+	}
+}
+
+func (g gatedEventHandler) OnUpdate(oldObj, newObj interface{}) {
+	if g.watch.isActive() {
+		g.handler.OnUpdate(oldObj, newObj)
+	}
+}
+
+func (g gatedEventHandler) OnDelete(obj interface{}) {
+	if g.watch.isActive() {
+		g.handler.OnDelete(obj)
+	}
+}
+
+// Start is internal and should be called only by the Controller to register an EventHandler with the
+// Informers of all matching resource types to enqueue reconcile.Requests.
+func (dw *DynamicWatcher) Start(handler handler.EventHandler, queue workqueue.RateLimitingInterface,
+	prct ...predicate.Predicate) error {
+
+	// Predicate should have been specified by the user.
+	if dw.Predicate == nil {
+		return fmt.Errorf("must specify DynamicWatcher.Predicate")
+	}
+
+	// cache should have been injected before Start was called
+	if dw.cache == nil {
+		return fmt.Errorf("must call CacheInto on DynamicWatcher before calling Start")
+	}
+
+	// stop should have been injected before Start was called
+	if dw.stop == nil {
+		return fmt.Errorf("must call InjectStop on DynamicWatcher before calling Start")
+	}
+
+	if dw.Discovery == nil {
+		if dw.config == nil {
+			return fmt.Errorf("must specify DynamicWatcher.Discovery or call ConfigInto before calling Start")
+		}
+		dc, err := discovery.NewDiscoveryClientForConfig(dw.config)
+		if err != nil {
+			return err
+		}
+		dw.Discovery = dc
+	}
+
+	if dw.ResyncPeriod == 0 {
+		dw.ResyncPeriod = defaultDynamicResyncPeriod
+	}
+
+	evtHandler := internal.EventHandler{Queue: queue, EventHandler: handler, Predicates: prct}
+
+	// Register the handler on the informers outside of the lock, which sync doesn't hold while it waits for
+	// the informers of new resource types either.  Watches committed by sync from now on include it.
+	dw.mu.Lock()
+	first := !dw.started
+	dw.started = true
+	dw.handlers = append(dw.handlers, evtHandler)
+	watches := make([]*dynamicWatch, 0, len(dw.watches))
+	for _, w := range dw.watches {
+		watches = append(watches, w)
+	}
+	dw.mu.Unlock()
+	for _, w := range watches {
+		w.informer.AddEventHandler(gatedEventHandler{watch: w, handler: evtHandler})
+	}
+
+	if !first {
+		return nil
+	}
+
+	// Run the first discovery synchronously so that misconfigurations surface from Start.
+	if err := dw.sync(); err != nil {
+		return err
+	}
+	go wait.Until(func() {
+		if err := dw.sync(); err != nil {
+			log.Error(err, "failed to sync dynamically watched resource types")
+		}
+	}, dw.ResyncPeriod, dw.stop)
+	return nil
+}
+
+// sync reconciles the watched resource types against the result of the discovery query.
+func (dw *DynamicWatcher) sync() error {
+	served, failed, err := dw.discover()
+	if err != nil {
+		return err
+	}
+
+	// Snapshot the resource types which aren't watched yet, and get their informers without holding the
+	// lock, since getting an informer can block until it has synced.
+	var added []schema.GroupVersionKind
+	dw.mu.Lock()
+	for gvk := range served {
+		if w, found := dw.watches[gvk]; found {
+			w.setActive(true)
+			continue
+		}
+		added = append(added, gvk)
+	}
+	dw.mu.Unlock()
+
+	for _, gvk := range added {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		i, err := dw.cache.GetInformer(obj)
+		if err != nil {
+			log.Error(err, "failed to watch dynamically discovered resource type", "kind", gvk)
+			continue
+		}
+		if w, handlers := dw.commitWatch(gvk, i); w != nil {
+			for _, h := range handlers {
+				i.AddEventHandler(gatedEventHandler{watch: w, handler: h})
+			}
+			log.Info("Started watching dynamically discovered resource type", "kind", gvk)
+		}
+	}
+
+	stopper, canStop := dw.cache.(cache.InformerStopper)
+	var removed []schema.GroupVersionKind
+	dw.mu.Lock()
+	for gvk, w := range dw.watches {
+		if _, found := served[gvk]; found || !w.isActive() {
+			continue
+		}
+		// Don't drop events because a group could temporarily not be discovered
+		if failed.Has(gvk.GroupVersion().String()) {
+			continue
+		}
+		w.setActive(false)
+		if canStop {
+			// Forget the watch, so that it is watched anew by a new informer if it is served again.
+			delete(dw.watches, gvk)
+			removed = append(removed, gvk)
+		}
+		log.Info("Stopped watching resource type which is no longer served", "kind", gvk)
+	}
+	dw.mu.Unlock()
+
+	// Stop the informers outside of the lock, since stopping one waits for a restart in progress.
+	for _, gvk := range removed {
+		if err := stopper.StopInformer(gvk); err != nil {
+			log.Error(err, "failed to stop the informer of resource type which is no longer served", "kind", gvk)
+		}
+	}
+
+	return nil
+}
+
+// commitWatch records the watch of gvk with the informer i, and returns it with the handlers to register
+// on it, or nil if gvk has been watched meanwhile.  The handlers added by Start afterwards are registered
+// by Start itself.
+func (dw *DynamicWatcher) commitWatch(gvk schema.GroupVersionKind, i cache.Informer) (*dynamicWatch, []internal.EventHandler) {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	if _, found := dw.watches[gvk]; found {
+		return nil, nil
+	}
+	if dw.watches == nil {
+		dw.watches = map[schema.GroupVersionKind]*dynamicWatch{}
+	}
+	w := &dynamicWatch{informer: i, active: true}
+	dw.watches[gvk] = w
+	return w, append([]internal.EventHandler(nil), dw.handlers...)
+}
+
+// discover returns the matching resource types, and the group versions which could not be discovered.
+func (dw *DynamicWatcher) discover() (map[schema.GroupVersionKind]struct{}, sets.String, error) {
+	failed := sets.NewString()
+	lists, err := dw.Discovery.ServerPreferredResources()
+	if err != nil {
+		groupErr, isGroupErr := err.(*discovery.ErrGroupDiscoveryFailed)
+		if !isGroupErr {
+			return nil, nil, err
+		}
+		for gv := range groupErr.Groups {
+			failed.Insert(gv.String())
+		}
+	}
+
+	watchable := discovery.SupportsAllVerbs{Verbs: []string{"list", "watch"}}
+	served := map[schema.GroupVersionKind]struct{}{}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range list.APIResources {
+			r := &list.APIResources[i]
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			if !watchable.Match(list.GroupVersion, r) || !dw.Predicate.Match(list.GroupVersion, r) {
+				continue
+			}
+			served[gv.WithKind(r.Kind)] = struct{}{}
+		}
+	}
+	return served, failed, nil
+}
+
+func (dw *DynamicWatcher) String() string {
+	return fmt.Sprintf("dynamic watcher source: %p", dw)
+}
+
+var _ inject.Cache = &DynamicWatcher{}
+
+// InjectCache is internal should be called only by the Controller.  InjectCache is used to inject
+// the Cache dependency initialized by the ControllerManager.
+func (dw *DynamicWatcher) InjectCache(c cache.Cache) error {
+	if dw.cache == nil {
+		dw.cache = c
+	}
+	return nil
+}
+
+var _ inject.Config = &DynamicWatcher{}
+
+// InjectConfig is internal should be called only by the Controller.  InjectConfig is used to inject
+// the rest.Config used to build the discovery client.
+func (dw *DynamicWatcher) InjectConfig(config *rest.Config) error {
+	if dw.config == nil {
+		dw.config = config
+	}
+	return nil
+}
+
+var _ inject.Stoppable = &DynamicWatcher{}
+
+// InjectStopChannel is internal should be called only by the Controller.
+// It is used to inject the stop channel initialized by the ControllerManager.
+func (dw *DynamicWatcher) InjectStopChannel(stop <-chan struct{}) error {
+	if dw.stop == nil {
+		dw.stop = stop
+	}
+	return nil
+}
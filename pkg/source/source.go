@@ -60,12 +60,23 @@ type Kind struct {
 	// Type is the type of object to watch.  e.g. &v1.Pod{}
 	Type runtime.Object
 
+	// TODO(community): Surface watch bookmarks, e.g. with a callback receiving their resourceVersion, so that
+	// watch progress can be persisted.  This requires a client-go version which can request bookmarks with
+	// ListOptions.AllowWatchBookmarks: the current one can't, so the API server never sends any.
+
 	// cache used to watch APIs
 	cache cache.Cache
 }
 
 var _ Source = &Kind{}
 
+// NewKindWithCache returns a Source watching objects of the type of obj in c, rather than in the cache
+// injected by the Controller, e.g. in the cache of a cluster.Cluster to watch another cluster.  The
+// Controller doesn't wait for c to sync before it starts reconciling.
+func NewKindWithCache(obj runtime.Object, c cache.Cache) Source {
+	return &Kind{Type: obj, cache: c}
+}
+
 // Start is internal and should be called only by the Controller to register an EventHandler with the Informer
 // to enqueue reconcile.Requests.
 func (ks *Kind) Start(handler handler.EventHandler, queue workqueue.RateLimitingInterface,
@@ -18,9 +18,13 @@ package source_test
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -30,6 +34,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -191,6 +200,29 @@ var _ = Describe("Source", func() {
 			})
 		})
 
+		It("should watch the cache it was created with rather than the injected one", func(done Done) {
+			other := &informertest.FakeInformers{}
+			instance := source.NewKindWithCache(&corev1.Pod{}, other)
+			Expect(inject.CacheInto(ic, instance)).To(BeTrue())
+
+			q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test")
+			err := instance.Start(handler.Funcs{
+				CreateFunc: func(evt event.CreateEvent, q2 workqueue.RateLimitingInterface) {
+					defer GinkgoRecover()
+					Expect(evt.Object).To(Equal(p))
+					close(c)
+				},
+			}, q)
+			Expect(err).NotTo(HaveOccurred())
+
+			i, err := other.FakeInformerFor(&corev1.Pod{})
+			Expect(err).NotTo(HaveOccurred())
+			i.Add(p)
+			<-c
+
+			close(done)
+		})
+
 		It("should return an error from Start if informers were not injected", func(done Done) {
 			instance := source.Kind{Type: &corev1.Pod{}}
 			err := instance.Start(nil, nil)
@@ -472,4 +504,277 @@ var _ = Describe("Source", func() {
 			})
 		})
 	})
+
+	Describe("DynamicWatcher", func() {
+		var ic *informertest.FakeInformers
+		var dc *preferredResourcesDiscovery
+		var q workqueue.RateLimitingInterface
+		var stop chan struct{}
+		var widgetGVK = schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+
+		widget := func(name string) *unstructured.Unstructured {
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(widgetGVK)
+			u.SetNamespace("default")
+			u.SetName(name)
+			return u
+		}
+
+		BeforeEach(func() {
+			ic = &informertest.FakeInformers{Scheme: runtime.NewScheme()}
+			dc = &preferredResourcesDiscovery{}
+			dc.setResources([]*metav1.APIResourceList{
+				{
+					GroupVersion: "example.com/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "widgets", Kind: "Widget", Namespaced: true,
+							Verbs: metav1.Verbs{"get", "list", "watch"}},
+						{Name: "widgets/status", Kind: "Widget", Namespaced: true,
+							Verbs: metav1.Verbs{"get", "list", "watch"}},
+						{Name: "gadgets", Kind: "Gadget", Namespaced: true,
+							Verbs: metav1.Verbs{"get", "list", "watch"}},
+					},
+				},
+				{
+					GroupVersion: "other.com/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "things", Kind: "Thing", Namespaced: true,
+							Verbs: metav1.Verbs{"get", "list", "watch"}},
+					},
+				},
+			})
+			q = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test")
+			stop = make(chan struct{})
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		newWatcher := func() *source.DynamicWatcher {
+			instance := &source.DynamicWatcher{
+				Predicate: discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+					return groupVersion == "example.com/v1" && r.Kind == "Widget"
+				}),
+				Discovery:    dc,
+				ResyncPeriod: 10 * time.Millisecond,
+			}
+			Expect(inject.CacheInto(ic, instance)).To(BeTrue())
+			Expect(inject.StopChannelInto(stop, instance)).To(BeTrue())
+			return instance
+		}
+
+		It("should only watch the matching resource types", func() {
+			instance := newWatcher()
+			Expect(instance.Start(handler.Funcs{}, q)).To(Succeed())
+
+			Expect(ic.InformersByGVK).To(HaveLen(1))
+			Expect(ic.InformersByGVK).To(HaveKey(widgetGVK))
+		})
+
+		It("should provide events for the matching resource types", func() {
+			instance := newWatcher()
+			created := make(chan event.CreateEvent, 1)
+			Expect(instance.Start(handler.Funcs{
+				CreateFunc: func(evt event.CreateEvent, q2 workqueue.RateLimitingInterface) {
+					defer GinkgoRecover()
+					Expect(q2).To(Equal(q))
+					created <- evt
+				},
+			}, q)).To(Succeed())
+
+			i, err := ic.FakeInformerFor(widget(""))
+			Expect(err).NotTo(HaveOccurred())
+			i.Add(widget("foo"))
+
+			var evt event.CreateEvent
+			Eventually(created).Should(Receive(&evt))
+			Expect(evt.Meta.GetName()).To(Equal("foo"))
+		})
+
+		It("should not block other Starts while waiting for an informer", func() {
+			release, waiting := make(chan struct{}), make(chan struct{}, 1)
+			instance := &source.DynamicWatcher{
+				Predicate: discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+					return groupVersion == "example.com/v1" && r.Kind == "Widget"
+				}),
+				Discovery:    dc,
+				ResyncPeriod: 10 * time.Millisecond,
+			}
+			Expect(inject.CacheInto(&blockingInformers{FakeInformers: ic, waiting: waiting, release: release}, instance)).To(BeTrue())
+			Expect(inject.StopChannelInto(stop, instance)).To(BeTrue())
+
+			var first, second int32
+			started := make(chan error, 1)
+			go func() {
+				started <- instance.Start(handler.Funcs{
+					CreateFunc: func(event.CreateEvent, workqueue.RateLimitingInterface) {
+						atomic.AddInt32(&first, 1)
+					},
+				}, q)
+			}()
+
+			By("starting again while the first Start waits for the informer")
+			Eventually(waiting).Should(Receive())
+			done := make(chan error, 1)
+			go func() {
+				done <- instance.Start(handler.Funcs{
+					CreateFunc: func(event.CreateEvent, workqueue.RateLimitingInterface) {
+						atomic.AddInt32(&second, 1)
+					},
+				}, q)
+			}()
+			Eventually(done).Should(Receive(BeNil()))
+			Consistently(started).ShouldNot(Receive())
+
+			By("providing events to both handlers once the informer is available")
+			close(release)
+			Eventually(started).Should(Receive(BeNil()))
+			i, err := ic.FakeInformerFor(widget(""))
+			Expect(err).NotTo(HaveOccurred())
+			i.Add(widget("foo"))
+			Expect(atomic.LoadInt32(&first)).To(Equal(int32(1)))
+			Expect(atomic.LoadInt32(&second)).To(Equal(int32(1)))
+		})
+
+		It("should stop providing events once the resource type is no longer served", func() {
+			instance := newWatcher()
+			var count int32
+			Expect(instance.Start(handler.Funcs{
+				CreateFunc: func(event.CreateEvent, workqueue.RateLimitingInterface) {
+					atomic.AddInt32(&count, 1)
+				},
+			}, q)).To(Succeed())
+
+			i, err := ic.FakeInformerFor(widget(""))
+			Expect(err).NotTo(HaveOccurred())
+
+			i.Add(widget("foo"))
+			Expect(atomic.LoadInt32(&count)).To(Equal(int32(1)))
+
+			By("removing the resource type from discovery")
+			dc.setResources(nil)
+			Eventually(func() int32 {
+				before := atomic.LoadInt32(&count)
+				i.Add(widget("foo"))
+				return atomic.LoadInt32(&count) - before
+			}).Should(BeZero())
+		})
+
+		It("should stop the informer of a resource type which is no longer served", func() {
+			stopping := &stoppingInformers{FakeInformers: ic}
+			instance := &source.DynamicWatcher{
+				Predicate: discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+					return groupVersion == "example.com/v1" && r.Kind == "Widget"
+				}),
+				Discovery:    dc,
+				ResyncPeriod: 10 * time.Millisecond,
+			}
+			Expect(inject.CacheInto(stopping, instance)).To(BeTrue())
+			Expect(inject.StopChannelInto(stop, instance)).To(BeTrue())
+			var count int32
+			Expect(instance.Start(handler.Funcs{
+				CreateFunc: func(event.CreateEvent, workqueue.RateLimitingInterface) {
+					atomic.AddInt32(&count, 1)
+				},
+			}, q)).To(Succeed())
+			served, _ := dc.ServerPreferredResources()
+
+			By("removing the resource type from discovery")
+			dc.setResources(nil)
+			Eventually(stopping.stoppedKinds).Should(ConsistOf(widgetGVK))
+
+			By("serving the resource type again")
+			dc.setResources(served)
+			Eventually(func() bool {
+				stopping.mu.Lock()
+				defer stopping.mu.Unlock()
+				_, found := ic.InformersByGVK[widgetGVK]
+				return found
+			}).Should(BeTrue())
+			stopping.mu.Lock()
+			i, err := ic.FakeInformerFor(widget(""))
+			stopping.mu.Unlock()
+			Expect(err).NotTo(HaveOccurred())
+			i.Add(widget("foo"))
+			Expect(atomic.LoadInt32(&count)).To(Equal(int32(1)))
+		})
+
+		It("should return an error if discovery fails", func() {
+			instance := newWatcher()
+			dc.err = fmt.Errorf("discovery failed")
+			Expect(instance.Start(handler.Funcs{}, q)).NotTo(Succeed())
+		})
+
+		It("should return an error if the Predicate is not specified", func() {
+			instance := newWatcher()
+			instance.Predicate = nil
+			Expect(instance.Start(handler.Funcs{}, q)).NotTo(Succeed())
+		})
+	})
 })
+
+// blockingInformers blocks getting informers until release is closed, and signals waiting meanwhile.
+type blockingInformers struct {
+	*informertest.FakeInformers
+	waiting chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingInformers) GetInformer(obj runtime.Object) (cache.Informer, error) {
+	select {
+	case c.waiting <- struct{}{}:
+	default:
+	}
+	<-c.release
+	return c.FakeInformers.GetInformer(obj)
+}
+
+// stoppingInformers records the informers it is asked to stop, and removes them.
+type stoppingInformers struct {
+	*informertest.FakeInformers
+
+	mu      sync.Mutex
+	stopped []schema.GroupVersionKind
+}
+
+func (c *stoppingInformers) GetInformer(obj runtime.Object) (cache.Informer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.FakeInformers.GetInformer(obj)
+}
+
+func (c *stoppingInformers) StopInformer(gvk schema.GroupVersionKind) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = append(c.stopped, gvk)
+	delete(c.InformersByGVK, gvk)
+	return nil
+}
+
+func (c *stoppingInformers) stoppedKinds() []schema.GroupVersionKind {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]schema.GroupVersionKind(nil), c.stopped...)
+}
+
+// preferredResourcesDiscovery serves a fixed set of preferred resources.
+type preferredResourcesDiscovery struct {
+	fakediscovery.FakeDiscovery
+
+	mu        sync.Mutex
+	resources []*metav1.APIResourceList
+	err       error
+}
+
+func (d *preferredResourcesDiscovery) setResources(resources []*metav1.APIResourceList) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resources = resources
+}
+
+func (d *preferredResourcesDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.resources, d.err
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gomodules.xyz/jsonpatch/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+const (
+	defaultResponseCacheTTL  = 10 * time.Second
+	defaultResponseCacheSize = 1024
+)
+
+// ResponseCacheOptions configures the caching of the responses of a Webhook.
+//
+// Responses are cached by the UID and resourceVersion of the object under admission, the operation and
+// a digest of the submitted object(s), so only requests for the very same change share a response.
+// Requests for objects without a UID or resourceVersion (e.g. creations) are never cached, nor are
+// responses reporting a server error.  Only enable caching for handlers whose decision depends solely
+// on the object(s) in the request, and not on e.g. the requesting user or other cluster state.
+type ResponseCacheOptions struct {
+	// TTL is how long a response is cached.  Defaults to 10 seconds.
+	TTL time.Duration
+
+	// Size is the maximum number of responses cached.  The least recently used responses are evicted
+	// once the cache is full.  Defaults to 1024.
+	Size int
+}
+
+// responseCache caches the completed responses of a Webhook.
+type responseCache struct {
+	ttl   time.Duration
+	cache *utilcache.LRUExpireCache
+}
+
+func newResponseCache(opts ResponseCacheOptions) *responseCache {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultResponseCacheTTL
+	}
+	if opts.Size <= 0 {
+		opts.Size = defaultResponseCacheSize
+	}
+	return &responseCache{ttl: opts.TTL, cache: utilcache.NewLRUExpireCache(opts.Size)}
+}
+
+// get returns the cached response for req, if any.
+func (c *responseCache) get(req Request) (Response, bool) {
+	key, ok := responseCacheKey(req)
+	if !ok {
+		return Response{}, false
+	}
+	cached, found := c.cache.Get(key)
+	if !found {
+		return Response{}, false
+	}
+
+	resp := copyResponse(cached.(Response))
+	resp.UID = req.UID
+	return resp, true
+}
+
+// add caches the completed response for req, if both are cacheable.
+func (c *responseCache) add(req Request, resp Response) {
+	if resp.Result != nil && resp.Result.Code >= http.StatusInternalServerError {
+		return
+	}
+	key, ok := responseCacheKey(req)
+	if !ok {
+		return
+	}
+	c.cache.Add(key, copyResponse(resp), c.ttl)
+}
+
+// copyResponse returns a copy of resp sharing nothing with it, so that neither the handler nor the callers
+// of the Webhook modify the cached responses.
+func copyResponse(resp Response) Response {
+	resp.AdmissionResponse = *resp.AdmissionResponse.DeepCopy()
+	if resp.Patches != nil {
+		resp.Patches = append([]jsonpatch.JsonPatchOperation(nil), resp.Patches...)
+	}
+	return resp
+}
+
+// responseCacheKey returns the key to cache the response to req under.  It returns false if the
+// request can't be cached.
+func responseCacheKey(req Request) (string, bool) {
+	raw := req.Object.Raw
+	if len(raw) == 0 {
+		raw = req.OldObject.Raw
+	}
+	if len(raw) == 0 {
+		return "", false
+	}
+
+	obj := struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return "", false
+	}
+	if len(obj.Metadata.UID) == 0 || len(obj.Metadata.ResourceVersion) == 0 {
+		return "", false
+	}
+
+	digest := sha256.New()
+	digest.Write(req.Object.Raw)    // nolint: errcheck
+	digest.Write([]byte{0})         // nolint: errcheck
+	digest.Write(req.OldObject.Raw) // nolint: errcheck
+
+	return fmt.Sprintf("%s/%s/%s/%s/%x", obj.Metadata.UID, obj.Metadata.ResourceVersion,
+		req.Operation, req.SubResource, digest.Sum(nil)), true
+}
@@ -43,6 +43,7 @@ type mutatingHandler struct {
 }
 
 var _ DecoderInjector = &mutatingHandler{}
+var _ typedHandler = &mutatingHandler{}
 
 // InjectDecoder injects the decoder into a mutatingHandler.
 func (h *mutatingHandler) InjectDecoder(d *Decoder) error {
@@ -50,6 +51,11 @@ func (h *mutatingHandler) InjectDecoder(d *Decoder) error {
 	return nil
 }
 
+// object implements typedHandler
+func (h *mutatingHandler) object() runtime.Object {
+	return h.defaulter
+}
+
 // Handle handles admission requests.
 func (h *mutatingHandler) Handle(ctx context.Context, req Request) Response {
 	if h.defaulter == nil {
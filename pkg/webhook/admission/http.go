@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/internal/metrics"
 )
 
 var admissionScheme = runtime.NewScheme()
@@ -84,7 +85,11 @@ func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// TODO: add panic-recovery for Handle
-	reviewResponse = wh.Handle(context.Background(), req)
+	ctx := context.Background()
+	if path := metrics.PathFromContext(r.Context()); len(path) > 0 {
+		ctx = metrics.WithPath(ctx, path)
+	}
+	reviewResponse = wh.Handle(ctx, req)
 	wh.writeResponse(w, reviewResponse)
 }
 
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SkippedReason is the reason of the responses allowing requests which don't match the ObjectSelector or
+// NamespaceSelector of a Webhook.
+const SkippedReason = "skipped"
+
+// InjectCache injects the cache into the webhook, to read the labels of namespaces from for its
+// NamespaceSelector.
+func (w *Webhook) InjectCache(c cache.Cache) error {
+	w.reader = c
+	return nil
+}
+
+// checkSelectors returns the response to req if it doesn't match the ObjectSelector or NamespaceSelector of
+// the webhook, allowing it without being handled, or if it can't be matched, or nil otherwise.
+func (w *Webhook) checkSelectors(ctx context.Context, req Request) *Response {
+	if w.ObjectSelector != nil {
+		matches, err := matchesObject(w.ObjectSelector, req)
+		if err != nil {
+			resp := Errored(http.StatusBadRequest, err)
+			return &resp
+		}
+		if !matches {
+			resp := Allowed(SkippedReason)
+			return &resp
+		}
+	}
+	if w.NamespaceSelector != nil {
+		matches, err := w.matchesNamespace(ctx, req)
+		if err != nil {
+			resp := Errored(http.StatusInternalServerError, err)
+			return &resp
+		}
+		if !matches {
+			resp := Allowed(SkippedReason)
+			return &resp
+		}
+	}
+	return nil
+}
+
+// matchesObject reports whether the labels of the new or the old object of req match selector, like the
+// objectSelector of a webhook configuration.
+func matchesObject(selector labels.Selector, req Request) (bool, error) {
+	for _, raw := range []runtime.RawExtension{req.Object, req.OldObject} {
+		if len(raw.Raw) == 0 {
+			continue
+		}
+		objLabels, err := labelsOf(raw)
+		if err != nil {
+			return false, err
+		}
+		if selector.Matches(labels.Set(objLabels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchesNamespace reports whether the labels of the namespace of the object of req match the
+// NamespaceSelector, like the namespaceSelector of a webhook configuration: namespaces are matched by
+// their own labels, and other cluster-scoped objects always match.
+func (w *Webhook) matchesNamespace(ctx context.Context, req Request) (bool, error) {
+	if req.Kind.Group == "" && req.Kind.Kind == "Namespace" {
+		return matchesObject(w.NamespaceSelector, req)
+	}
+	if len(req.Namespace) == 0 {
+		return true, nil
+	}
+	if w.reader == nil {
+		return false, fmt.Errorf("unable to read namespace %q to match the NamespaceSelector: no cache injected", req.Namespace)
+	}
+	ns := &corev1.Namespace{}
+	if err := w.reader.Get(ctx, client.ObjectKey{Name: req.Namespace}, ns); err != nil {
+		return false, fmt.Errorf("unable to read namespace %q to match the NamespaceSelector: %v", req.Namespace, err)
+	}
+	return w.NamespaceSelector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// labelsOf decodes the labels of a raw object.
+func labelsOf(raw runtime.RawExtension) (map[string]string, error) {
+	var obj struct {
+		metav1.ObjectMeta `json:"metadata,omitempty"`
+	}
+	if err := json.Unmarshal(raw.Raw, &obj); err != nil {
+		return nil, err
+	}
+	return obj.Labels, nil
+}
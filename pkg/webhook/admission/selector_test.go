@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Webhook selectors", func() {
+	var handled int
+	var webhook *Webhook
+
+	BeforeEach(func() {
+		handled = 0
+		webhook = &Webhook{Handler: HandlerFunc(func(context.Context, Request) Response {
+			handled++
+			return Denied("handled")
+		})}
+	})
+
+	request := func(namespace string, newLabels, oldLabels string) Request {
+		req := Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Namespace: namespace,
+			Operation: admissionv1beta1.Update,
+		}}
+		if len(newLabels) > 0 {
+			req.Object = runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"metadata":{"labels":%s}}`, newLabels))}
+		}
+		if len(oldLabels) > 0 {
+			req.OldObject = runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"metadata":{"labels":%s}}`, oldLabels))}
+		}
+		return req
+	}
+
+	Context("with an ObjectSelector", func() {
+		BeforeEach(func() {
+			webhook.ObjectSelector = labels.SelectorFromSet(labels.Set{"managed": "true"})
+		})
+
+		It("should skip requests whose objects don't match", func() {
+			resp := webhook.Handle(context.Background(), request("default", `{"managed":"false"}`, `{}`))
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(string(resp.Result.Reason)).To(Equal(SkippedReason))
+			Expect(handled).To(Equal(0))
+		})
+
+		It("should handle requests whose new or old object matches", func() {
+			webhook.Handle(context.Background(), request("default", `{"managed":"true"}`, `{}`))
+			webhook.Handle(context.Background(), request("default", `{}`, `{"managed":"true"}`))
+			Expect(handled).To(Equal(2))
+		})
+	})
+
+	Context("with a NamespaceSelector", func() {
+		BeforeEach(func() {
+			webhook.NamespaceSelector = labels.SelectorFromSet(labels.Set{"env": "prod"})
+			webhook.reader = fake.NewFakeClient(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "prod"}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"env": "dev"}}})
+		})
+
+		It("should only handle requests for objects in matching namespaces", func() {
+			resp := webhook.Handle(context.Background(), request("dev", `{}`, ""))
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(string(resp.Result.Reason)).To(Equal(SkippedReason))
+			Expect(handled).To(Equal(0))
+
+			webhook.Handle(context.Background(), request("prod", `{}`, ""))
+			Expect(handled).To(Equal(1))
+		})
+
+		It("should match namespaces by their own labels", func() {
+			req := request("", `{"env":"dev"}`, "")
+			req.Kind = metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+			resp := webhook.Handle(context.Background(), req)
+			Expect(string(resp.Result.Reason)).To(Equal(SkippedReason))
+		})
+
+		It("should error requests if the namespace can't be read", func() {
+			resp := webhook.Handle(context.Background(), request("missing", `{}`, ""))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("unable to read namespace"))
+			Expect(handled).To(Equal(0))
+		})
+	})
+})
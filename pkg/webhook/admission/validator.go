@@ -22,6 +22,9 @@ import (
 
 	"k8s.io/api/admission/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
 )
 
 // Validator defines functions for validating an operation
@@ -31,6 +34,42 @@ type Validator interface {
 	ValidateUpdate(old runtime.Object) error
 }
 
+// ContextValidator is a Validator which also accepts a context, e.g. to read related objects from the
+// cluster with ReaderFromContext in order to validate uniqueness across a namespace.  Validating webhooks
+// will call the context methods instead of ValidateCreate and ValidateUpdate for types implementing this
+// interface.
+type ContextValidator interface {
+	Validator
+
+	// ValidateCreateContext validates the creation of the object, in the same way as ValidateCreate.
+	ValidateCreateContext(ctx context.Context) error
+
+	// ValidateUpdateContext validates the update of the object from old, in the same way as ValidateUpdate.
+	ValidateUpdateContext(ctx context.Context, old runtime.Object) error
+}
+
+// readerKey is the context key of the client.Reader of a validating webhook.
+type readerKey struct{}
+
+// WithReader returns a copy of ctx carrying reader.  Validating webhooks call this with the cache of the
+// manager before passing ctx to a ContextValidator.
+func WithReader(ctx context.Context, reader client.Reader) context.Context {
+	return context.WithValue(ctx, readerKey{}, reader)
+}
+
+// ReaderFromContext returns the client.Reader with which a ContextValidator can read other objects, or nil
+// if the webhook hasn't been injected with a cache.
+//
+// The reader is backed by the cache of the manager, so it may not yet reflect recent changes; in
+// particular, objects admitted concurrently are only seen once their creation has been observed.  Cross-object
+// validation through it is best-effort, and shouldn't be relied on for strict guarantees such as uniqueness
+// under concurrent creations.  Reading a type for the first time starts an informer for it and waits for its
+// initial list, which counts against the timeout of the admission request.
+func ReaderFromContext(ctx context.Context) client.Reader {
+	reader, _ := ctx.Value(readerKey{}).(client.Reader)
+	return reader
+}
+
 // ValidatingWebhookFor creates a new Webhook for validating the provided type.
 func ValidatingWebhookFor(validator Validator) *Webhook {
 	return &Webhook{
@@ -41,9 +80,12 @@ func ValidatingWebhookFor(validator Validator) *Webhook {
 type validatingHandler struct {
 	validator Validator
 	decoder   *Decoder
+	reader    client.Reader
 }
 
 var _ DecoderInjector = &validatingHandler{}
+var _ inject.Cache = &validatingHandler{}
+var _ typedHandler = &validatingHandler{}
 
 // InjectDecoder injects the decoder into a validatingHandler.
 func (h *validatingHandler) InjectDecoder(d *Decoder) error {
@@ -51,11 +93,25 @@ func (h *validatingHandler) InjectDecoder(d *Decoder) error {
 	return nil
 }
 
+// InjectCache injects the cache into a validatingHandler, to be read from by ContextValidators.
+func (h *validatingHandler) InjectCache(c cache.Cache) error {
+	h.reader = c
+	return nil
+}
+
+// object implements typedHandler
+func (h *validatingHandler) object() runtime.Object {
+	return h.validator
+}
+
 // Handle handles admission requests.
 func (h *validatingHandler) Handle(ctx context.Context, req Request) Response {
 	if h.validator == nil {
 		panic("validator should never be nil")
 	}
+	if h.reader != nil {
+		ctx = WithReader(ctx, h.reader)
+	}
 
 	// Get the object in the request
 	obj := h.validator.DeepCopyObject().(Validator)
@@ -65,7 +121,7 @@ func (h *validatingHandler) Handle(ctx context.Context, req Request) Response {
 			return Errored(http.StatusBadRequest, err)
 		}
 
-		err = obj.ValidateCreate()
+		err = validateCreate(ctx, obj)
 		if err != nil {
 			return Denied(err.Error())
 		}
@@ -83,7 +139,7 @@ func (h *validatingHandler) Handle(ctx context.Context, req Request) Response {
 			return Errored(http.StatusBadRequest, err)
 		}
 
-		err = obj.ValidateUpdate(oldObj)
+		err = validateUpdate(ctx, obj, oldObj)
 		if err != nil {
 			return Denied(err.Error())
 		}
@@ -91,3 +147,19 @@ func (h *validatingHandler) Handle(ctx context.Context, req Request) Response {
 
 	return Allowed("")
 }
+
+// validateCreate validates the creation of obj, passing ctx on if it is a ContextValidator.
+func validateCreate(ctx context.Context, obj Validator) error {
+	if cv, ok := obj.(ContextValidator); ok {
+		return cv.ValidateCreateContext(ctx)
+	}
+	return obj.ValidateCreate()
+}
+
+// validateUpdate validates the update of obj from old, passing ctx on if it is a ContextValidator.
+func validateUpdate(ctx context.Context, obj Validator, old runtime.Object) error {
+	if cv, ok := obj.(ContextValidator); ok {
+		return cv.ValidateUpdateContext(ctx, old)
+	}
+	return obj.ValidateUpdate(old)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Validating Webhooks", func() {
+	var decoder *Decoder
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "foo.test.org", Version: "v1", Kind: "UniqueName"}, &uniqueName{})
+		var err error
+		decoder, err = NewDecoder(scheme)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	createRequest := func(name string) Request {
+		return Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Object: runtime.RawExtension{Raw: []byte(fmt.Sprintf(
+				`{"apiVersion":"foo.test.org/v1","kind":"UniqueName","metadata":{"name":"%s","namespace":"default"}}`, name))},
+		}}
+	}
+
+	It("should pass a reader of the injected cache to ContextValidators", func() {
+		h := &validatingHandler{validator: &uniqueName{}, decoder: decoder}
+		h.reader = fake.NewFakeClient(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "taken", Namespace: "default"}})
+
+		By("denying a name which is taken")
+		resp := h.Handle(context.Background(), createRequest("taken"))
+		Expect(resp.Allowed).To(BeFalse())
+		Expect(string(resp.Result.Reason)).To(ContainSubstring("already taken"))
+
+		By("allowing a name which is free")
+		resp = h.Handle(context.Background(), createRequest("free"))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("should not pass a reader if no cache has been injected", func() {
+		h := &validatingHandler{validator: &uniqueName{}, decoder: decoder}
+
+		resp := h.Handle(context.Background(), createRequest("taken"))
+		Expect(resp.Allowed).To(BeFalse())
+		Expect(string(resp.Result.Reason)).To(ContainSubstring("no reader"))
+	})
+})
+
+// uniqueName is a ContextValidator which only admits objects whose name isn't taken by a ConfigMap in
+// their namespace.
+type uniqueName struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+var _ ContextValidator = &uniqueName{}
+
+func (u *uniqueName) DeepCopyObject() runtime.Object {
+	return &uniqueName{TypeMeta: u.TypeMeta, ObjectMeta: *u.ObjectMeta.DeepCopy()}
+}
+
+func (u *uniqueName) ValidateCreate() error {
+	return fmt.Errorf("ValidateCreate should not be called for a ContextValidator")
+}
+
+func (u *uniqueName) ValidateUpdate(old runtime.Object) error {
+	return fmt.Errorf("ValidateUpdate should not be called for a ContextValidator")
+}
+
+func (u *uniqueName) ValidateCreateContext(ctx context.Context) error {
+	reader := ReaderFromContext(ctx)
+	if reader == nil {
+		return fmt.Errorf("no reader")
+	}
+	err := reader.Get(ctx, client.ObjectKey{Namespace: u.Namespace, Name: u.Name}, &corev1.ConfigMap{})
+	if err == nil {
+		return fmt.Errorf("name %q is already taken", u.Name)
+	}
+	return client.IgnoreNotFound(err)
+}
+
+func (u *uniqueName) ValidateUpdateContext(ctx context.Context, old runtime.Object) error {
+	return nil
+}
@@ -19,22 +19,83 @@ package admission
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"gomodules.xyz/jsonpatch/v2"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/internal/metrics"
 )
 
 var (
 	errUnableToEncodeResponse = errors.New("unable to encode response")
 )
 
+// SelfTestUID is the UID of the synthetic requests sent to the webhooks of a webhook.Server with
+// SelfTestOnStart set.  Self-test requests are also dry-run requests, so Handlers with side effects
+// should already skip them.
+const SelfTestUID types.UID = "controller-runtime-webhook-self-test"
+
+// IsSelfTest returns true if req is a synthetic request sent by a webhook.Server to self-test its webhooks.
+func IsSelfTest(req Request) bool {
+	return req.UID == SelfTestUID
+}
+
+// typedHandler is implemented by the Handlers of the objects of a given type, such as the ones of
+// DefaultingWebhookFor and ValidatingWebhookFor.
+type typedHandler interface {
+	// object returns an object of the type handled.
+	object() runtime.Object
+}
+
+// SelfTestRequest returns the synthetic dry-run request to send to w to self-test it.  The webhooks of
+// DefaultingWebhookFor and ValidatingWebhookFor get a new object of their type, with its apiVersion and
+// kind looked up in the injected scheme, so that it decodes and the self-test runs their Default or
+// Validate methods.  Other webhooks get an empty object.
+func (w *Webhook) SelfTestRequest() (Request, error) {
+	dryRun := true
+	req := Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		UID:       SelfTestUID,
+		Operation: admissionv1beta1.Create,
+		DryRun:    &dryRun,
+		Object:    runtime.RawExtension{Raw: []byte("{}")},
+	}}
+
+	h, isTyped := w.Handler.(typedHandler)
+	if !isTyped || h.object() == nil {
+		return req, nil
+	}
+	if w.scheme == nil {
+		return Request{}, fmt.Errorf("no scheme injected to look up the kind of %T", h.object())
+	}
+	gvk, err := apiutil.GVKForObject(h.object(), w.scheme)
+	if err != nil {
+		return Request{}, err
+	}
+	obj, err := w.scheme.New(gvk)
+	if err != nil {
+		return Request{}, err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	if req.Object.Raw, err = json.Marshal(obj); err != nil {
+		return Request{}, err
+	}
+	req.Kind = metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+	return req, nil
+}
+
 // Request defines the input for an admission handler.
 // It contains information to identify the object in
 // question (group, version, kind, resource, subresource,
@@ -111,9 +172,37 @@ type Webhook struct {
 	// and potentially patches to apply to the handler.
 	Handler Handler
 
+	// ResponseCache, if set, caches the responses of the Handler, so that repeated requests for the same
+	// change of an object (e.g. retries) are answered without invoking the Handler again.
+	// See ResponseCacheOptions for which requests are cached.  Defaults to nil, caching nothing.
+	ResponseCache *ResponseCacheOptions
+
+	// ObjectSelector, if set, allows the requests whose new and old objects both don't have labels matching
+	// it without invoking the Handler, like the objectSelector of the webhook configuration.  This keeps
+	// the webhook correct if the configuration's selector is missing or wrong, and makes it testable
+	// without an API server.  Defaults to nil, handling all requests.
+	ObjectSelector labels.Selector
+
+	// NamespaceSelector, if set, allows the requests for objects in namespaces whose labels don't match it
+	// without invoking the Handler, like the namespaceSelector of the webhook configuration.  Namespaces are
+	// matched by their own labels, and other cluster-scoped objects are always handled.  The namespaces are
+	// read from the injected cache, which requires permission to list and watch them.  Defaults to nil,
+	// handling all requests.
+	NamespaceSelector labels.Selector
+
+	// reader is the injected cache, to read namespaces from
+	reader client.Reader
+
+	// cache is constructed from ResponseCache on the first request
+	cache     *responseCache
+	cacheOnce sync.Once
+
 	// decoder is constructed on receiving a scheme and passed down to then handler
 	decoder *Decoder
 
+	// scheme is the injected scheme, to look up the kinds of typed Handlers with
+	scheme *runtime.Scheme
+
 	log logr.Logger
 }
 
@@ -127,20 +216,66 @@ func (w *Webhook) InjectLogger(l logr.Logger) error {
 // If the webhook is mutating type, it delegates the AdmissionRequest to each handler and merge the patches.
 // If the webhook is validating type, it delegates the AdmissionRequest to each handler and
 // deny the request if anyone denies.
-func (w *Webhook) Handle(ctx context.Context, req Request) Response {
-	resp := w.Handler.Handle(ctx, req)
+//
+// Requests which don't match the ObjectSelector or NamespaceSelector are allowed with SkippedReason
+// without invoking the Handler.
+//
+// Requests served by a webhook.Server are counted and timed by their decision in the
+// controller_runtime_webhook_admission_requests_total and controller_runtime_webhook_admission_duration_seconds
+// metrics, labeled by the path the webhook is registered at.  Self-test requests aren't.
+func (w *Webhook) Handle(ctx context.Context, req Request) (resp Response) {
+	if path := metrics.PathFromContext(ctx); len(path) > 0 && !IsSelfTest(req) {
+		startTS := time.Now()
+		defer func() { metrics.RecordAdmission(path, decision(resp), time.Since(startTS)) }()
+	}
+
+	if skipped := w.checkSelectors(ctx, req); skipped != nil {
+		resp = *skipped
+		resp.UID = req.UID
+		return resp
+	}
+
+	w.cacheOnce.Do(func() {
+		if w.ResponseCache != nil {
+			w.cache = newResponseCache(*w.ResponseCache)
+		}
+	})
+	if w.cache != nil {
+		if resp, found := w.cache.get(req); found {
+			return resp
+		}
+	}
+
+	resp = w.Handler.Handle(ctx, req)
 	if err := resp.Complete(req); err != nil {
 		w.log.Error(err, "unable to encode response")
 		return Errored(http.StatusInternalServerError, errUnableToEncodeResponse)
 	}
 
+	if w.cache != nil {
+		w.cache.add(req, resp)
+	}
 	return resp
 }
 
+// decision returns the decision of resp for the metrics: allowed, denied, or errored if the request was
+// refused with the code of a failure, as by Errored.
+func decision(resp Response) string {
+	if resp.Allowed {
+		return "allowed"
+	}
+	if resp.Result != nil && (resp.Result.Code == http.StatusBadRequest || resp.Result.Code >= http.StatusInternalServerError) {
+		return "errored"
+	}
+	return "denied"
+}
+
 // InjectScheme injects a scheme into the webhook, in order to construct a Decoder.
 func (w *Webhook) InjectScheme(s *runtime.Scheme) error {
 	// TODO(directxman12): we should have a better way to pass this down
 
+	w.scheme = s
+
 	var err error
 	w.decoder, err = NewDecoder(s)
 	if err != nil {
@@ -18,18 +18,23 @@ package admission
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"gomodules.xyz/jsonpatch/v2"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	machinerytypes "k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/internal/metrics"
 )
 
 var _ = Describe("Admission Webhooks", func() {
@@ -186,6 +191,176 @@ var _ = Describe("Admission Webhooks", func() {
 			Expect(handler.dep.decoder).NotTo(BeNil())
 		})
 	})
+
+	Describe("response caching", func() {
+		var calls int
+		var webhook *Webhook
+
+		updateRequest := func(uid machinerytypes.UID, object string) Request {
+			return Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				UID:       uid,
+				Operation: admissionv1beta1.Update,
+				Object: runtime.RawExtension{
+					Raw: []byte(`{"metadata":{"uid":"1234","resourceVersion":"1"},` + object + `}`),
+				},
+				OldObject: runtime.RawExtension{
+					Raw: []byte(`{"metadata":{"uid":"1234","resourceVersion":"1"}}`),
+				},
+			}}
+		}
+
+		BeforeEach(func() {
+			calls = 0
+			webhook = &Webhook{
+				Handler: HandlerFunc(func(ctx context.Context, req Request) Response {
+					calls++
+					return Denied("not allowed")
+				}),
+				ResponseCache: &ResponseCacheOptions{},
+			}
+		})
+
+		It("should answer repeated requests for the same change from the cache", func() {
+			By("invoking the webhook twice with the same change")
+			first := webhook.Handle(context.Background(), updateRequest("first", `"spec":{}`))
+			second := webhook.Handle(context.Background(), updateRequest("second", `"spec":{}`))
+
+			By("checking that the handler was only invoked once")
+			Expect(calls).To(Equal(1))
+			Expect(second.Allowed).To(BeFalse())
+			Expect(second.Result).To(Equal(first.Result))
+
+			By("checking that the cached response carries the UID of its request")
+			Expect(second.UID).To(Equal(machinerytypes.UID("second")))
+		})
+
+		It("should not share the patches of the cached responses with the callers", func() {
+			webhook.Handler = HandlerFunc(func(ctx context.Context, req Request) Response {
+				calls++
+				return PatchResponseFromRaw([]byte(`{}`), []byte(`{"a":1}`))
+			})
+			first := webhook.Handle(context.Background(), updateRequest("first", `"spec":{}`))
+			Expect(first.Patches).To(HaveLen(1))
+			first.Patches[0].Path = "/modified"
+			first.Patches = append(first.Patches, first.Patches[0])
+
+			second := webhook.Handle(context.Background(), updateRequest("second", `"spec":{}`))
+			Expect(calls).To(Equal(1))
+			Expect(second.Patches).To(HaveLen(1))
+			Expect(second.Patches[0].Path).To(Equal("/a"))
+		})
+
+		It("should invoke the handler for a different change of the same object version", func() {
+			webhook.Handle(context.Background(), updateRequest("first", `"spec":{}`))
+			webhook.Handle(context.Background(), updateRequest("second", `"spec":{"replicas":2}`))
+
+			Expect(calls).To(Equal(2))
+		})
+
+		It("should not cache requests for objects without a UID", func() {
+			req := Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"foo"}}`)},
+			}}
+			webhook.Handle(context.Background(), req)
+			webhook.Handle(context.Background(), req)
+
+			Expect(calls).To(Equal(2))
+		})
+
+		It("should not cache server errors", func() {
+			webhook.Handler = HandlerFunc(func(ctx context.Context, req Request) Response {
+				calls++
+				return Errored(http.StatusInternalServerError, fmt.Errorf("unavailable"))
+			})
+			webhook.Handle(context.Background(), updateRequest("first", `"spec":{}`))
+			webhook.Handle(context.Background(), updateRequest("second", `"spec":{}`))
+
+			Expect(calls).To(Equal(2))
+		})
+
+		It("should not cache anything by default", func() {
+			webhook.ResponseCache = nil
+			webhook.Handle(context.Background(), updateRequest("first", `"spec":{}`))
+			webhook.Handle(context.Background(), updateRequest("second", `"spec":{}`))
+
+			Expect(calls).To(Equal(2))
+		})
+	})
+
+	Describe("metrics", func() {
+		const path = "/validate-foo"
+
+		respondWith := func(resp Response) *Webhook {
+			return &Webhook{Handler: HandlerFunc(func(context.Context, Request) Response { return resp })}
+		}
+
+		admissions := func(decision string) float64 {
+			var m dto.Metric
+			Expect(metrics.AdmissionRequests.WithLabelValues(path, decision).Write(&m)).To(Succeed())
+			return m.GetCounter().GetValue()
+		}
+
+		BeforeEach(func() {
+			metrics.AdmissionRequests.Reset()
+			metrics.AdmissionLatency.Reset()
+		})
+
+		It("should count the requests served at a path by decision", func() {
+			ctx := metrics.WithPath(context.Background(), path)
+			respondWith(Allowed("")).Handle(ctx, Request{})
+			respondWith(Allowed("")).Handle(ctx, Request{})
+			respondWith(Denied("no")).Handle(ctx, Request{})
+			respondWith(Errored(http.StatusInternalServerError, fmt.Errorf("boom"))).Handle(ctx, Request{})
+
+			Expect(admissions("allowed")).To(Equal(2.0))
+			Expect(admissions("denied")).To(Equal(1.0))
+			Expect(admissions("errored")).To(Equal(1.0))
+
+			var m dto.Metric
+			hist := metrics.AdmissionLatency.WithLabelValues(path, "allowed").(prometheus.Histogram)
+			Expect(hist.Write(&m)).To(Succeed())
+			Expect(m.GetHistogram().GetSampleCount()).To(Equal(uint64(2)))
+		})
+
+		It("should not count self-test requests or requests not served at a path", func() {
+			respondWith(Allowed("")).Handle(context.Background(), Request{})
+			respondWith(Allowed("")).Handle(metrics.WithPath(context.Background(), path),
+				Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{UID: SelfTestUID}})
+
+			Expect(admissions("allowed")).To(Equal(0.0))
+		})
+	})
+
+	Describe("SelfTestRequest", func() {
+		It("should send an object of their type to typed webhooks", func() {
+			scheme := runtime.NewScheme()
+			gvk := schema.GroupVersionKind{Group: "foo.test.org", Version: "v1", Kind: "UniqueName"}
+			scheme.AddKnownTypeWithName(gvk, &uniqueName{})
+			wh := ValidatingWebhookFor(&uniqueName{})
+			Expect(wh.InjectScheme(scheme)).To(Succeed())
+
+			req, err := wh.SelfTestRequest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IsSelfTest(req)).To(BeTrue())
+			Expect(req.Kind).To(Equal(metav1.GroupVersionKind{Group: "foo.test.org", Version: "v1", Kind: "UniqueName"}))
+			obj := &uniqueName{}
+			Expect(wh.GetDecoder().Decode(req, obj)).To(Succeed())
+			Expect(obj.GroupVersionKind()).To(Equal(gvk))
+		})
+
+		It("should fail for typed webhooks without a scheme", func() {
+			_, err := ValidatingWebhookFor(&uniqueName{}).SelfTestRequest()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should send an empty object to other webhooks", func() {
+			req, err := (&Webhook{Handler: HandlerFunc(nil)}).SelfTestRequest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IsSelfTest(req)).To(BeTrue())
+			Expect(req.Object.Raw).To(Equal([]byte("{}")))
+		})
+	})
 })
 
 type stringInjector interface {
@@ -29,6 +29,9 @@ type Defaulter = admission.Defaulter
 // Validator defines functions for validating an operation
 type Validator = admission.Validator
 
+// ContextValidator is a Validator which also accepts a context
+type ContextValidator = admission.ContextValidator
+
 // AdmissionRequest defines the input for an admission handler.
 // It contains information to identify the object in
 // question (group, version, kind, resource, subresource,
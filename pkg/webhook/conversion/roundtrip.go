@@ -0,0 +1,216 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/diff"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ReadinessCheckPath is the path at which the webhook server of a manager serves the ReadinessCheck of the
+// types whose webhooks are built with a conversion readiness check.
+const ReadinessCheckPath = "/readyz/conversion"
+
+// CheckRoundTrip checks that sample survives being converted from the Hub of its group-kind to every other
+// version of it and back, and returns an error describing the conversions which fail or lose data, sorted
+// by version.  sample may be of any version: a sample of a spoke version is converted to the Hub first, and
+// must survive being converted back to its own version too.  A zero object only catches conversions failing
+// outright, a sample with every field set also catches the fields lost by a conversion.  It checks the
+// convertibility of the type with CheckConvertibility first.
+func CheckRoundTrip(scheme *runtime.Scheme, sample runtime.Object) error {
+	if err := CheckConvertibility(scheme, sample); err != nil {
+		return err
+	}
+
+	gvks, _, err := scheme.ObjectKinds(sample)
+	if err != nil {
+		return fmt.Errorf("error retriving object kinds for given object : %v", err)
+	}
+	sampleGVK := gvks[0]
+	gk := sampleGVK.GroupKind()
+
+	// the versions of the group-kind are registered as distinct types
+	var hub runtime.Object
+	var hubGVK schema.GroupVersionKind
+	spokes := map[schema.GroupVersionKind]conversion.Convertible{}
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.GroupKind() != gk {
+			continue
+		}
+		instance, err := scheme.New(gvk)
+		if err != nil {
+			return fmt.Errorf("failed to allocate an instance for gvk %v %v", gvk, err)
+		}
+		if isHub(instance) {
+			if hub != nil {
+				return fmt.Errorf("multiple hub version defined for %v", gk)
+			}
+			hub, hubGVK = instance, gvk
+		} else if spoke, ok := instance.(conversion.Convertible); ok {
+			spokes[gvk] = spoke
+		}
+	}
+	if hub == nil {
+		// single version, or a multi-version built-in type
+		return nil
+	}
+
+	var errs []error
+	if isHub(sample) {
+		hub = sample.DeepCopyObject()
+	} else if spoke, ok := sample.DeepCopyObject().(conversion.Convertible); !ok {
+		return fmt.Errorf("%T is not convertible to hub version %T", sample, hub)
+	} else if err := spoke.ConvertTo(hub.(conversion.Hub)); err != nil {
+		return fmt.Errorf("%T failed to convert to hub version %T : %v", sample, hub, err)
+	} else if err := roundTripFromSpoke(scheme, sample, sampleGVK, hub, hubGVK); err != nil {
+		// What the spoke loses on its way to the hub is best seen in the spoke itself
+		errs = append(errs, err)
+	}
+
+	// Check the versions in a stable order, so that the errors don't change from one check to the next
+	spokeGVKs := make([]schema.GroupVersionKind, 0, len(spokes))
+	for gvk := range spokes {
+		spokeGVKs = append(spokeGVKs, gvk)
+	}
+	sort.Slice(spokeGVKs, func(i, j int) bool { return spokeGVKs[i].String() < spokeGVKs[j].String() })
+	for _, spokeGVK := range spokeGVKs {
+		if err := roundTrip(scheme, hub, hubGVK, spokes[spokeGVK], spokeGVK); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// roundTripFromSpoke converts hub, converted from sample, back to the spoke version of sample, and returns
+// an error if the result differs from sample.
+func roundTripFromSpoke(scheme *runtime.Scheme, sample runtime.Object, sampleGVK schema.GroupVersionKind,
+	hub runtime.Object, hubGVK schema.GroupVersionKind) error {
+	instance, err := scheme.New(sampleGVK)
+	if err != nil {
+		return err
+	}
+	back, ok := instance.(conversion.Convertible)
+	if !ok {
+		return fmt.Errorf("%v is not convertible to hub version %v", sampleGVK, hubGVK)
+	}
+	if err := back.ConvertFrom(hub.DeepCopyObject().(conversion.Hub)); err != nil {
+		return fmt.Errorf("%v failed to convert from hub version %v : %v", sampleGVK, hubGVK, err)
+	}
+	// Conversions don't have to set the TypeMeta, the webhook does
+	if kind, sampleKind := back.GetObjectKind(), sample.GetObjectKind(); kind != nil && sampleKind != nil {
+		kind.SetGroupVersionKind(sampleKind.GroupVersionKind())
+	}
+	if !equality.Semantic.DeepEqual(sample, back) {
+		return fmt.Errorf("converting %v through %v loses data: %s", sampleGVK, hubGVK, diff.ObjectReflectDiff(sample, back))
+	}
+	return nil
+}
+
+// roundTrip converts hub to spoke and back, and returns an error if the result differs from hub.
+func roundTrip(scheme *runtime.Scheme, hub runtime.Object, hubGVK schema.GroupVersionKind,
+	spoke conversion.Convertible, spokeGVK schema.GroupVersionKind) error {
+	if err := spoke.ConvertFrom(hub.DeepCopyObject().(conversion.Hub)); err != nil {
+		return fmt.Errorf("%v failed to convert from hub version %v : %v", spokeGVK, hubGVK, err)
+	}
+	back, err := scheme.New(hubGVK)
+	if err != nil {
+		return err
+	}
+	if err := spoke.ConvertTo(back.(conversion.Hub)); err != nil {
+		return fmt.Errorf("%v failed to convert to hub version %v : %v", spokeGVK, hubGVK, err)
+	}
+	// Conversions don't have to set the TypeMeta, the webhook does
+	if kind := back.GetObjectKind(); kind != nil {
+		kind.SetGroupVersionKind(hub.GetObjectKind().GroupVersionKind())
+	}
+	if !equality.Semantic.DeepEqual(hub, back) {
+		return fmt.Errorf("converting %v through %v loses data: %s", hubGVK, spokeGVK, diff.ObjectReflectDiff(hub, back))
+	}
+	return nil
+}
+
+// ReadinessCheck is an http.Handler reporting whether the conversions of a set of types round-trip, for the
+// readiness probe of a pod serving a conversion webhook.  It answers 200 if CheckRoundTrip passes for every
+// sample added to it, and 503 with the failures otherwise, so that a misconfigured conversion keeps the pod
+// out of service instead of corrupting the objects converted by it.  Its scheme is injected by the webhook
+// server it is registered with, when the server starts.
+//
+// The conversions don't change while the process runs, so the samples are only round-tripped by the first
+// check, and later checks answer the same result until the samples or the scheme change.
+type ReadinessCheck struct {
+	mu      sync.Mutex
+	scheme  *runtime.Scheme
+	samples []runtime.Object
+
+	// checked is true once the current samples have been round-tripped, with the result in err.
+	checked bool
+	err     error
+}
+
+// InjectScheme injects the scheme of the samples into the check.
+func (c *ReadinessCheck) InjectScheme(s *runtime.Scheme) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scheme, c.checked = s, false
+	return nil
+}
+
+// Add adds sample to the samples to round-trip.
+func (c *ReadinessCheck) Add(sample runtime.Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples, c.checked = append(c.samples, sample), false
+}
+
+// Check returns an error if any of the samples fails CheckRoundTrip.
+func (c *ReadinessCheck) Check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scheme == nil {
+		return fmt.Errorf("no scheme has been injected")
+	}
+	if c.checked {
+		return c.err
+	}
+	var errs []error
+	for _, sample := range c.samples {
+		if err := CheckRoundTrip(c.scheme, sample); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.checked, c.err = true, utilerrors.NewAggregate(errs)
+	return c.err
+}
+
+// ServeHTTP implements http.Handler
+func (c *ReadinessCheck) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if err := c.Check(); err != nil {
+		log.Error(err, "conversion readiness check failed")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprint(w, "ok") // nolint: errcheck
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	jobsapis "sigs.k8s.io/controller-runtime/examples/conversion/pkg/apis"
+	jobsv1 "sigs.k8s.io/controller-runtime/examples/conversion/pkg/apis/jobs/v1"
+	jobsv2 "sigs.k8s.io/controller-runtime/examples/conversion/pkg/apis/jobs/v2"
+)
+
+var _ = Describe("CheckRoundTrip", func() {
+	var scheme *runtime.Scheme
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(jobsapis.AddToScheme(scheme)).To(Succeed())
+		scheme.AddKnownTypeWithName(lossyGroupKind.WithVersion("v1"), &lossySpoke{})
+		scheme.AddKnownTypeWithName(lossyGroupKind.WithVersion("v2"), &lossyHub{})
+	})
+
+	It("should pass for conversions which round-trip", func() {
+		sample := &jobsv2.ExternalJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"foo": "bar"}},
+			Spec:       jobsv2.ExternalJobSpec{ScheduleAt: "every 2 minutes"},
+		}
+		Expect(CheckRoundTrip(scheme, sample)).To(Succeed())
+
+		By("converting samples of other versions to the hub first")
+		Expect(CheckRoundTrip(scheme, &jobsv1.ExternalJob{Spec: jobsv1.ExternalJobSpec{RunAt: "every 2 minutes"}})).To(Succeed())
+	})
+
+	It("should report the data lost by a conversion", func() {
+		Expect(CheckRoundTrip(scheme, &lossyHub{})).To(Succeed())
+
+		err := CheckRoundTrip(scheme, &lossyHub{A: "a", B: "b"})
+		Expect(err).To(MatchError(ContainSubstring("loses data")))
+		Expect(err.Error()).To(ContainSubstring("lossy.example.org/v1"))
+	})
+
+	It("should report the data lost by converting a sample of a spoke version to the hub", func() {
+		Expect(CheckRoundTrip(scheme, &lossySpoke{A: "a"})).To(Succeed())
+
+		err := CheckRoundTrip(scheme, &lossySpoke{A: "a", C: "c"})
+		Expect(err).To(MatchError(ContainSubstring("loses data")))
+		Expect(err.Error()).To(ContainSubstring("converting lossy.example.org/v1, Kind=Lossy through lossy.example.org/v2"))
+	})
+
+	It("should report the failures sorted by version", func() {
+		scheme.AddKnownTypeWithName(lossyGroupKind.WithVersion("v1beta1"), &lossySpoke{})
+		scheme.AddKnownTypeWithName(lossyGroupKind.WithVersion("v1alpha1"), &lossySpoke{})
+
+		err := CheckRoundTrip(scheme, &lossyHub{A: "a", B: "b"})
+		Expect(err).To(HaveOccurred())
+		v1 := strings.Index(err.Error(), "through lossy.example.org/v1,")
+		v1alpha1 := strings.Index(err.Error(), "through lossy.example.org/v1alpha1,")
+		v1beta1 := strings.Index(err.Error(), "through lossy.example.org/v1beta1,")
+		Expect(v1).To(BeNumerically(">=", 0))
+		Expect(v1alpha1).To(BeNumerically(">", v1))
+		Expect(v1beta1).To(BeNumerically(">", v1alpha1))
+		for i := 0; i < 10; i++ {
+			Expect(CheckRoundTrip(scheme, &lossyHub{A: "a", B: "b"})).To(MatchError(err.Error()))
+		}
+	})
+
+	It("should serve its result as a readiness check", func() {
+		check := &ReadinessCheck{}
+		check.Add(&jobsv2.ExternalJob{Spec: jobsv2.ExternalJobSpec{ScheduleAt: "every 2 minutes"}})
+
+		By("reporting not ready without a scheme")
+		w := httptest.NewRecorder()
+		check.ServeHTTP(w, httptest.NewRequest("GET", ReadinessCheckPath, nil))
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+
+		Expect(check.InjectScheme(scheme)).To(Succeed())
+		w = httptest.NewRecorder()
+		check.ServeHTTP(w, httptest.NewRequest("GET", ReadinessCheckPath, nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		By("reporting not ready with the failures once a sample loses data")
+		check.Add(&lossyHub{A: "a", B: "b"})
+		w = httptest.NewRecorder()
+		check.ServeHTTP(w, httptest.NewRequest("GET", ReadinessCheckPath, nil))
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Body.String()).To(ContainSubstring("loses data"))
+	})
+
+	It("should only round-trip the samples again once they change", func() {
+		sample := &lossyHub{A: "a"}
+		check := &ReadinessCheck{}
+		check.Add(sample)
+		Expect(check.InjectScheme(scheme)).To(Succeed())
+		Expect(check.Check()).To(Succeed())
+
+		By("answering the same result while the samples are unchanged")
+		sample.B = "b"
+		Expect(check.Check()).To(Succeed())
+
+		By("round-tripping the samples again once one is added")
+		check.Add(&lossyHub{})
+		Expect(check.Check()).To(MatchError(ContainSubstring("loses data")))
+	})
+})
+
+var lossyGroupKind = schema.GroupKind{Group: "lossy.example.org", Kind: "Lossy"}
+
+// lossyHub is the hub of a type whose spoke loses the B field.
+type lossyHub struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+	A, B string
+}
+
+func (l *lossyHub) Hub() {}
+
+func (l *lossyHub) DeepCopyObject() runtime.Object {
+	out := *l
+	l.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+// lossySpoke is a spoke of lossyHub which has no B field, and a C field which the hub has no room for.
+type lossySpoke struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+	A, C string
+}
+
+func (l *lossySpoke) ConvertTo(dst conversion.Hub) error {
+	hub := dst.(*lossyHub)
+	hub.ObjectMeta, hub.A = l.ObjectMeta, l.A
+	return nil
+}
+
+func (l *lossySpoke) ConvertFrom(src conversion.Hub) error {
+	hub := src.(*lossyHub)
+	l.ObjectMeta, l.A = hub.ObjectMeta, hub.A
+	return nil
+}
+
+func (l *lossySpoke) DeepCopyObject() runtime.Object {
+	out := *l
+	l.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
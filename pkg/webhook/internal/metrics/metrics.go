@@ -17,6 +17,9 @@ limitations under the License.
 package metrics
 
 import (
+	"context"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -42,10 +45,55 @@ var (
 		},
 		[]string{"webhook"},
 	)
+
+	// AdmissionRequests is a prometheus metric which counts the admission requests handled by
+	// the admission webhooks of the webhook server, by their decision: allowed, denied or errored.
+	AdmissionRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "controller_runtime_webhook_admission_requests_total",
+			Help: "Total number of admission requests by decision",
+		},
+		[]string{"webhook", "decision"},
+	)
+
+	// AdmissionLatency is a prometheus metric which is a histogram of the time the admission
+	// webhooks of the webhook server take to decide on admission requests, by their decision.
+	AdmissionLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "controller_runtime_webhook_admission_duration_seconds",
+			Help: "Histogram of the time taken to decide on admission requests by decision",
+		},
+		[]string{"webhook", "decision"},
+	)
 )
 
 func init() {
 	metrics.Registry.MustRegister(
 		TotalRequests,
-		RequestLatency)
+		RequestLatency,
+		AdmissionRequests,
+		AdmissionLatency)
+}
+
+// RecordAdmission counts an admission request handled by the webhook at path with the given decision,
+// and observes how long it took.
+func RecordAdmission(path, decision string, duration time.Duration) {
+	AdmissionRequests.WithLabelValues(path, decision).Inc()
+	AdmissionLatency.WithLabelValues(path, decision).Observe(duration.Seconds())
+}
+
+// pathKey is the context key of the path a webhook is served at.
+type pathKey struct{}
+
+// WithPath returns a copy of ctx carrying path, the path the webhook handling a request is registered at.
+// The webhook server calls this for every request, so that the webhooks can label their metrics with it.
+func WithPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, pathKey{}, path)
+}
+
+// PathFromContext returns the path set with WithPath, or "" if the request wasn't served by the webhook
+// server.
+func PathFromContext(ctx context.Context) string {
+	path, _ := ctx.Value(pathKey{}).(string)
+	return path
 }
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// selfTest sends a synthetic AdmissionReview to every admission webhook of hooks, and returns an error
+// if any of them panics, fails to respond with an AdmissionReview, or reports a server error.  The
+// requests are built by admission.Webhook.SelfTestRequest.
+func selfTest(hooks map[string]http.Handler) error {
+	for hookPath, hook := range hooks {
+		wh, isAdmission := hook.(*admission.Webhook)
+		if !isAdmission {
+			// Only admission webhooks know how to handle an AdmissionReview
+			continue
+		}
+		if err := selfTestHook(hookPath, wh); err != nil {
+			return fmt.Errorf("webhook %s failed its self-test: %v", hookPath, err)
+		}
+		log.V(1).Info("webhook passed its self-test", "webhook", hookPath)
+	}
+	return nil
+}
+
+func selfTestHook(hookPath string, hook *admission.Webhook) (err error) {
+	selfTestReq, err := hook.SelfTestRequest()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1beta1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request:  &selfTestReq.AdmissionRequest,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, hookPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	recorder := httptest.NewRecorder()
+	hook.ServeHTTP(recorder, req)
+
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &review); err != nil {
+		return fmt.Errorf("unable to decode the response: %v", err)
+	}
+	if review.Response == nil {
+		return fmt.Errorf("no response")
+	}
+	if review.Response.Result != nil && review.Response.Result.Code >= http.StatusInternalServerError {
+		return fmt.Errorf("server error: %s", review.Response.Result.Message)
+	}
+	return nil
+}
@@ -20,10 +20,13 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -41,6 +44,9 @@ const (
 // DefaultPort is the default port that the webhook server serves.
 var DefaultPort = 443
 
+// DefaultSocketMode is the default file mode of the Unix domain socket the webhook server serves on.
+var DefaultSocketMode os.FileMode = 0600
+
 // Server is an admission webhook server that can serve traffic and
 // generates related k8s resources for deploying.
 type Server struct {
@@ -59,6 +65,32 @@ type Server struct {
 	// the user is responsible to mount the secret to the this location for the server to consume.
 	CertDir string
 
+	// SocketPath is the path of a Unix domain socket that the server will serve on, in addition to Host and Port.
+	// A stale socket left at this path (e.g. by a previous run) is replaced.
+	// Defaults to "" - no Unix domain socket.
+	SocketPath string
+
+	// SocketMode is the file mode of the Unix domain socket.
+	// It will be defaulted to 0600 (accessible by the owner only) if unspecified.
+	SocketMode os.FileMode
+
+	// SocketInsecure, if true, serves plain HTTP instead of TLS on the Unix domain socket.  This is only
+	// meant for sockets fronted by a trusted local proxy which terminates TLS itself, since the API server
+	// can't reach the socket directly.
+	SocketInsecure bool
+
+	// DisableTCP, if true, serves on the Unix domain socket only, and Host and Port are ignored.
+	// SocketPath must be set.
+	DisableTCP bool
+
+	// SelfTestOnStart, if true, sends a synthetic AdmissionReview to every registered admission webhook
+	// before serving, and fails Start if any of them panics or reports a server error, turning e.g. a
+	// nil Handler into a startup failure.  The webhooks of admission.DefaultingWebhookFor and
+	// admission.ValidatingWebhookFor are sent a new object of their type, so that its Default or Validate
+	// methods run.  Handlers can tell self-test requests by admission.IsSelfTest, and they are dry-run
+	// requests, so Handlers with side effects must not act on them.
+	SelfTestOnStart bool
+
 	// WebhookMux is the multiplexer that handles different webhooks.
 	WebhookMux *http.ServeMux
 
@@ -66,6 +98,9 @@ type Server struct {
 	// and to provide better panic messages on duplicate webhook registration.
 	webhooks map[string]http.Handler
 
+	// mu guards webhooks, which may be registered and listed concurrently.
+	mu sync.Mutex
+
 	// setFields allows injecting dependencies from an external source
 	setFields inject.Func
 
@@ -87,6 +122,10 @@ func (s *Server) setDefaults() {
 	if len(s.CertDir) == 0 {
 		s.CertDir = path.Join("/tmp", "k8s-webhook-server", "serving-certs")
 	}
+
+	if s.SocketMode == 0 {
+		s.SocketMode = DefaultSocketMode
+	}
 }
 
 // NeedLeaderElection implements the LeaderElectionRunnable interface, which indicates
@@ -99,6 +138,8 @@ func (*Server) NeedLeaderElection() bool {
 // It panics if two hooks are registered on the same path.
 func (s *Server) Register(path string, hook http.Handler) {
 	s.defaultingOnce.Do(s.setDefaults)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	_, found := s.webhooks[path]
 	if found {
 		panic(fmt.Errorf("can't register duplicate path: %v", path))
@@ -108,14 +149,45 @@ func (s *Server) Register(path string, hook http.Handler) {
 	s.WebhookMux.Handle(path, instrumentedHook(path, hook))
 }
 
+// Webhook returns the webhook registered at the given path, or nil if there is none.
+func (s *Server) Webhook(path string) http.Handler {
+	s.defaultingOnce.Do(s.setDefaults)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhooks[path]
+}
+
+// Paths returns the paths of the registered webhooks, sorted.
+func (s *Server) Paths() []string {
+	s.defaultingOnce.Do(s.setDefaults)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := make([]string, 0, len(s.webhooks))
+	for p := range s.webhooks {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// registered returns a copy of the registered webhooks by path.
+func (s *Server) registered() map[string]http.Handler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hooks := make(map[string]http.Handler, len(s.webhooks))
+	for p, hook := range s.webhooks {
+		hooks[p] = hook
+	}
+	return hooks
+}
+
 // instrumentedHook adds some instrumentation on top of the given webhook.
 func instrumentedHook(path string, hookRaw http.Handler) http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		startTS := time.Now()
 		defer func() { metrics.RequestLatency.WithLabelValues(path).Observe(time.Now().Sub(startTS).Seconds()) }()
-		hookRaw.ServeHTTP(resp, req)
-
-		// TODO(directxman12): add back in metric about total requests broken down by result?
+		// Admission webhooks record their decisions under the path themselves
+		hookRaw.ServeHTTP(resp, req.WithContext(metrics.WithPath(req.Context(), path)))
 	})
 }
 
@@ -125,9 +197,10 @@ func (s *Server) Start(stop <-chan struct{}) error {
 	s.defaultingOnce.Do(s.setDefaults)
 
 	baseHookLog := log.WithName("webhooks")
+	hooks := s.registered()
 	// inject fields here as opposed to in Register so that we're certain to have our setFields
 	// function available.
-	for hookPath, webhook := range s.webhooks {
+	for hookPath, webhook := range hooks {
 		if err := s.setFields(webhook); err != nil {
 			return err
 		}
@@ -140,28 +213,63 @@ func (s *Server) Start(stop <-chan struct{}) error {
 		}
 	}
 
-	certPath := filepath.Join(s.CertDir, certName)
-	keyPath := filepath.Join(s.CertDir, keyName)
+	if s.DisableTCP && len(s.SocketPath) == 0 {
+		return fmt.Errorf("must specify SocketPath if DisableTCP is set")
+	}
 
-	certWatcher, err := certwatcher.New(certPath, keyPath)
-	if err != nil {
-		return err
+	if s.SelfTestOnStart {
+		if err := selfTest(hooks); err != nil {
+			return err
+		}
 	}
 
-	go func() {
-		if err := certWatcher.Start(stop); err != nil {
-			log.Error(err, "certificate watcher error")
+	var listeners []net.Listener
+	defer func() {
+		// Serve closes the listeners it has been given, closing them again is harmless.
+		for _, l := range listeners {
+			l.Close() // nolint: errcheck
 		}
 	}()
 
-	cfg := &tls.Config{
-		NextProtos:     []string{"h2"},
-		GetCertificate: certWatcher.GetCertificate,
+	var cfg *tls.Config
+	if !s.DisableTCP || !s.SocketInsecure {
+		certPath := filepath.Join(s.CertDir, certName)
+		keyPath := filepath.Join(s.CertDir, keyName)
+
+		certWatcher, err := certwatcher.New(certPath, keyPath)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			if err := certWatcher.Start(stop); err != nil {
+				log.Error(err, "certificate watcher error")
+			}
+		}()
+
+		cfg = &tls.Config{
+			NextProtos:     []string{"h2"},
+			GetCertificate: certWatcher.GetCertificate,
+		}
+	}
+
+	if !s.DisableTCP {
+		listener, err := tls.Listen("tcp", net.JoinHostPort(s.Host, strconv.Itoa(int(s.Port))), cfg)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, listener)
 	}
 
-	listener, err := tls.Listen("tcp", net.JoinHostPort(s.Host, strconv.Itoa(int(s.Port))), cfg)
-	if err != nil {
-		return err
+	if len(s.SocketPath) > 0 {
+		listener, err := s.listenSocket()
+		if err != nil {
+			return err
+		}
+		if !s.SocketInsecure {
+			listener = tls.NewListener(listener, cfg)
+		}
+		listeners = append(listeners, listener)
 	}
 
 	srv := &http.Server{
@@ -180,15 +288,79 @@ func (s *Server) Start(stop <-chan struct{}) error {
 		close(idleConnsClosed)
 	}()
 
-	err = srv.Serve(listener)
-	if err != nil && err != http.ErrServerClosed {
-		return err
+	errCh := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			errCh <- srv.Serve(listener)
+		}(listener)
+	}
+
+	for range listeners {
+		if err := <-errCh; err != nil && err != http.ErrServerClosed {
+			return err
+		}
 	}
 
 	<-idleConnsClosed
 	return nil
 }
 
+// listenSocket listens on the Unix domain socket at SocketPath, with the socket file set to SocketMode.
+func (s *Server) listenSocket() (net.Listener, error) {
+	// Remove a socket left over by a previous run, but never anything else.
+	if fi, err := os.Lstat(s.SocketPath); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s already exists and is not a socket", s.SocketPath)
+		}
+		if err := os.Remove(s.SocketPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create the socket in a private directory next to SocketPath, and only move it in place once its mode
+	// is set, so that it is never accessible with looser permissions than SocketMode.
+	dir, err := ioutil.TempDir(filepath.Dir(s.SocketPath), ".sock")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	tmpPath := filepath.Join(dir, "s")
+
+	listener, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	// The socket won't be at the path it was created at anymore, so remove it from SocketPath on close.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener = &socketListener{Listener: listener, path: s.SocketPath}
+	if err := os.Chmod(tmpPath, s.SocketMode); err != nil {
+		listener.Close() // nolint: errcheck
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, s.SocketPath); err != nil {
+		listener.Close() // nolint: errcheck
+		return nil, err
+	}
+	return listener, nil
+}
+
+// socketListener is a listener on a Unix domain socket which removes the socket at path once closed.
+type socketListener struct {
+	net.Listener
+	path string
+
+	closeOnce sync.Once
+}
+
+// Close implements net.Listener
+func (l *socketListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() {
+		os.Remove(l.path) // nolint: errcheck
+	})
+	return err
+}
+
 // InjectFunc injects the field setter into the server.
 func (s *Server) InjectFunc(f inject.Func) error {
 	s.setFields = f
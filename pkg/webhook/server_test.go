@@ -0,0 +1,266 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("Server", func() {
+	var dir string
+	var stop chan struct{}
+	var server *Server
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "webhook-server")
+		Expect(err).NotTo(HaveOccurred())
+		stop = make(chan struct{})
+		server = &Server{
+			SocketPath:     filepath.Join(dir, "webhook.sock"),
+			SocketInsecure: true,
+			DisableTCP:     true,
+		}
+		Expect(server.InjectFunc(func(interface{}) error { return nil })).To(Succeed())
+		server.Register("/validate", http.HandlerFunc(func(resp http.ResponseWriter, _ *http.Request) {
+			resp.Write([]byte("ok")) // nolint: errcheck
+		}))
+	})
+
+	AfterEach(func() {
+		close(stop)
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should list the registered webhooks while others are being registered", func() {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				server.Register(fmt.Sprintf("/hook-%d", i), http.NotFoundHandler())
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			server.Paths()
+			server.Webhook("/validate")
+		}
+		<-done
+		Expect(server.Paths()).To(HaveLen(101))
+		Expect(server.Paths()).To(ContainElement("/validate"))
+	})
+
+	Context("serving on a Unix domain socket", func() {
+		socketClient := func(path string) *http.Client {
+			return &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", path)
+				},
+			}}
+		}
+
+		It("should serve the registered webhooks", func() {
+			go func() {
+				defer GinkgoRecover()
+				Expect(server.Start(stop)).To(Succeed())
+			}()
+
+			var resp *http.Response
+			Eventually(func() error {
+				var err error
+				resp, err = socketClient(server.SocketPath).Get("http://webhook/validate")
+				return err
+			}).Should(Succeed())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(Equal("ok"))
+		})
+
+		It("should restrict the socket to its owner by default", func() {
+			go func() {
+				defer GinkgoRecover()
+				Expect(server.Start(stop)).To(Succeed())
+			}()
+
+			Eventually(func() (os.FileMode, error) {
+				fi, err := os.Stat(server.SocketPath)
+				if err != nil {
+					return 0, err
+				}
+				return fi.Mode().Perm(), nil
+			}).Should(Equal(os.FileMode(0600)))
+		})
+
+		It("should only leave the socket in its directory while serving, and remove it once stopped", func() {
+			serverStop := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(stopped)
+				Expect(server.Start(serverStop)).To(Succeed())
+			}()
+
+			Eventually(func() error {
+				resp, err := socketClient(server.SocketPath).Get("http://webhook/validate")
+				if err == nil {
+					resp.Body.Close()
+				}
+				return err
+			}).Should(Succeed())
+			entries, err := ioutil.ReadDir(dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal("webhook.sock"))
+
+			close(serverStop)
+			Eventually(stopped).Should(BeClosed())
+			_, err = os.Lstat(server.SocketPath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should replace a stale socket", func() {
+			stale, err := net.Listen("unix", server.SocketPath)
+			Expect(err).NotTo(HaveOccurred())
+			// Keep the socket file around, as a crashed process would.
+			stale.(*net.UnixListener).SetUnlinkOnClose(false)
+			Expect(stale.Close()).To(Succeed())
+
+			go func() {
+				defer GinkgoRecover()
+				Expect(server.Start(stop)).To(Succeed())
+			}()
+
+			Eventually(func() error {
+				resp, err := socketClient(server.SocketPath).Get("http://webhook/validate")
+				if err == nil {
+					resp.Body.Close()
+				}
+				return err
+			}).Should(Succeed())
+		})
+
+		It("should refuse to replace a file which isn't a socket", func() {
+			Expect(ioutil.WriteFile(server.SocketPath, []byte("data"), 0644)).To(Succeed())
+
+			err := server.Start(stop)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is not a socket"))
+		})
+
+		It("should return an error if DisableTCP is set without a SocketPath", func() {
+			server.SocketPath = ""
+
+			err := server.Start(stop)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must specify SocketPath"))
+		})
+	})
+
+	Context("with SelfTestOnStart", func() {
+		BeforeEach(func() {
+			server.SelfTestOnStart = true
+		})
+
+		It("should send a dry-run self-test request to the admission webhooks before serving", func() {
+			selfTested := make(chan admission.Request, 1)
+			server.Register("/mutate", &admission.Webhook{
+				Handler: admission.HandlerFunc(func(_ context.Context, req admission.Request) admission.Response {
+					selfTested <- req
+					return admission.Allowed("")
+				}),
+			})
+			go func() {
+				defer GinkgoRecover()
+				Expect(server.Start(stop)).To(Succeed())
+			}()
+
+			var req admission.Request
+			Eventually(selfTested).Should(Receive(&req))
+			Expect(admission.IsSelfTest(req)).To(BeTrue())
+			Expect(req.DryRun).NotTo(BeNil())
+			Expect(*req.DryRun).To(BeTrue())
+		})
+
+		It("should fail to start if an admission webhook panics", func() {
+			server.Register("/mutate", &admission.Webhook{})
+
+			err := server.Start(stop)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("webhook /mutate failed its self-test: panic"))
+		})
+
+		It("should fail to start if an admission webhook reports a server error", func() {
+			server.Register("/mutate", &admission.Webhook{
+				Handler: admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+					return admission.Errored(http.StatusInternalServerError, fmt.Errorf("expected error"))
+				}),
+			})
+
+			err := server.Start(stop)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("server error: expected error"))
+		})
+
+		It("should fail to start if the Validate method of a typed webhook panics", func() {
+			scheme := runtime.NewScheme()
+			scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "foo.test.org", Version: "v1", Kind: "Panicking"}, &panickingValidator{})
+			Expect(server.InjectFunc(func(i interface{}) error {
+				_, err := inject.SchemeInto(scheme, i)
+				return err
+			})).To(Succeed())
+			server.Register("/validate-panicking", admission.ValidatingWebhookFor(&panickingValidator{}))
+
+			err := server.Start(stop)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("webhook /validate-panicking failed its self-test: panic: expected panic"))
+		})
+	})
+})
+
+// panickingValidator is a Validator which panics validating its creation.
+type panickingValidator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+func (v *panickingValidator) DeepCopyObject() runtime.Object {
+	return &panickingValidator{TypeMeta: v.TypeMeta, ObjectMeta: *v.ObjectMeta.DeepCopy()}
+}
+
+func (v *panickingValidator) ValidateCreate() error {
+	panic("expected panic")
+}
+
+func (v *panickingValidator) ValidateUpdate(runtime.Object) error {
+	return nil
+}